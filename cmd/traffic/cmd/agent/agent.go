@@ -18,7 +18,6 @@ import (
 	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dhttp"
 	"github.com/datawire/dlib/dlog"
-	ftp "github.com/datawire/go-ftpserver"
 	"github.com/telepresenceio/telepresence/rpc/v2/agent"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
@@ -84,10 +83,28 @@ func sftpServer(ctx context.Context, sftpPortCh chan<- uint16) error {
 	defer close(sftpPortCh)
 
 	// start an sftp-server for remote sshfs mounts
+	l, sftpPort, err := sftpListen(ctx)
+	if err != nil {
+		return err
+	}
+	sftpPortCh <- sftpPort
+	return serveSFTP(ctx, l, func(conn net.Conn) (sftpSession, error) {
+		return sftp.NewServer(conn)
+	})
+}
+
+// sftpSession is implemented by both the sftp.Server and the sftp.RequestServer.
+type sftpSession interface {
+	Serve() error
+}
+
+// sftpListen creates a listener on the next available port, and returns it together with that port. The
+// listener is closed when the given context is cancelled.
+func sftpListen(ctx context.Context) (net.Listener, uint16, error) {
 	lc := net.ListenConfig{}
 	l, err := lc.Listen(ctx, "tcp", ":0")
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	// Accept doesn't actually return when the context is cancelled so
@@ -99,10 +116,15 @@ func sftpServer(ctx context.Context, sftpPortCh chan<- uint16) error {
 
 	_, sftpPort, err := iputil.SplitToIPPort(l.Addr())
 	if err != nil {
-		return err
+		_ = l.Close()
+		return nil, 0, err
 	}
-	sftpPortCh <- sftpPort
+	return l, sftpPort, nil
+}
 
+// serveSFTP accepts connections on the given listener, and serves each one using a session created by
+// the given function.
+func serveSFTP(ctx context.Context, l net.Listener, newSession func(net.Conn) (sftpSession, error)) error {
 	for {
 		conn, err := l.Accept()
 		if err != nil {
@@ -112,9 +134,12 @@ func sftpServer(ctx context.Context, sftpPortCh chan<- uint16) error {
 			return nil
 		}
 		go func() {
-			s, err := sftp.NewServer(conn)
+			defer metrics.sftpSessionStarted()()
+			s, err := newSession(conn)
 			if err != nil {
-				dlog.Error(ctx, err)
+				_ = conn.Close()
+				dlog.Errorf(ctx, "unable to create sftp server: %v", err)
+				return
 			}
 			dlog.Debugf(ctx, "Serving sftp connection from %s", conn.RemoteAddr())
 			if err = s.Serve(); err != nil {
@@ -162,6 +187,9 @@ func sidecar(ctx context.Context, s SimpleState, info *rpc.AgentInfo) error {
 		if err != nil {
 			return err
 		}
+		cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
+		s.AddAppContainer(cn, env)
+
 		// Group the containers intercepts by agent port
 		icStates := make(map[agentconfig.PortAndProto][]*agentconfig.Intercept, len(cn.Intercepts))
 		for _, ic := range cn.Intercepts {
@@ -179,7 +207,6 @@ func sidecar(ctx context.Context, s SimpleState, info *rpc.AgentInfo) error {
 			dgroup.ParentGroup(ctx).Go(fmt.Sprintf("forward-%s:%d", cn.Name, cp), func(ctx context.Context) error {
 				return fwd.Serve(tunnel.WithPool(ctx, tunnel.NewPool()), nil)
 			})
-			cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
 			s.AddInterceptState(s.NewInterceptState(fwd, NewInterceptTarget(ics), cnMountPoint, env))
 		}
 	}
@@ -263,11 +290,7 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 			return sftpServer(ctx, sftpPortCh)
 		})
 		g.Go("ftp-server", func(ctx context.Context) error {
-			if iputil.IsIpV6Addr(config.PodIP()) {
-				return ftp.Start(ctx, "", agentconfig.ExportsMountPoint, ftpPortCh)
-			} else {
-				return ftp.Start(ctx, config.PodIP(), agentconfig.ExportsMountPoint, ftpPortCh)
-			}
+			return ftpServer(ctx, ftpPublicHost(config.PodIP()), agentconfig.ExportsMountPoint, ftpPortCh)
 		})
 	} else {
 		close(sftpPortCh)
@@ -288,6 +311,12 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 	}
 	srv.SetFileSharingPorts(ftpPort, sftpPort)
 
+	if ac.MetricsPort != 0 {
+		g.Go("metrics-server", func(ctx context.Context) error {
+			return metrics.serve(ctx, ac.MetricsPort)
+		})
+	}
+
 	if ac.APIPort != 0 {
 		g.Go("API-server", func(ctx context.Context) error {
 			return restapi.NewServer(srv.AgentState()).ListenAndServe(ctx, int(ac.APIPort))
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// appContainer is an app container of the workload. Its environment and mounts are made available
+// to ingests, and to intercepts that span several containers.
+type appContainer struct {
+	name       string
+	envPrefix  string
+	mountPoint string
+	env        map[string]string
+}
+
+func (s *state) AddAppContainer(cn *agentconfig.Container, env map[string]string) {
+	s.appContainers = append(s.appContainers, &appContainer{
+		name:       cn.Name,
+		envPrefix:  cn.EnvPrefix,
+		mountPoint: filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint)),
+		env:        env,
+	})
+}
+
+// appContainer returns the app container with the given name, or the first app container when
+// the name is empty.
+func (s *state) appContainer(name string) *appContainer {
+	for _, ac := range s.appContainers {
+		if name == "" || ac.name == name {
+			return ac
+		}
+	}
+	return nil
+}
+
+// containerNames returns the names of the containers that the given intercept spans, starting with
+// the container of the primary port.
+func containerNames(spec *manager.InterceptSpec) []string {
+	names := []string{spec.ContainerName}
+	for _, ap := range spec.AdditionalPorts {
+		if !slices.Contains(names, ap.ContainerName) {
+			names = append(names, ap.ContainerName)
+		}
+	}
+	return names
+}
+
+// spanContainers makes the given review describe the environment and mounts of all the given containers. The
+// environment of each container is added using the container's EnvPrefix as a key prefix, in addition to the
+// unprefixed environment of the first container. The mount point becomes the root of the exported volumes, so
+// that the volumes of each container end up in a subdirectory of their own.
+func (s *state) spanContainers(r *manager.ReviewInterceptRequest, names []string) {
+	env := maps.Clone(r.Environment)
+	if env == nil {
+		env = make(map[string]string)
+	}
+	for _, name := range names {
+		if ac := s.appContainer(name); ac != nil {
+			for k, v := range ac.env {
+				env[ac.envPrefix+k] = v
+			}
+		}
+	}
+	r.Environment = env
+	r.MountPoint = agentconfig.ExportsMountPoint
+}
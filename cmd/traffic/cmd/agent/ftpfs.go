@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// policyFs is an afero.Fs that enforces the mountPolicy of one intercept on the ftp-server. The root of the Fs is
+// the root of the exported volumes, so the first segment of each path is the directory of a container. Paths that
+// the policy doesn't export are reported as nonexistent, and modifications are denied when the policy is read-only.
+type policyFs struct {
+	afero.Fs
+	root   string
+	policy *mountPolicy
+}
+
+// newPolicyFs returns a policyFs that enforces the given policy on the given Fs, which is rooted at the given
+// directory.
+func newPolicyFs(fs afero.Fs, root string, policy *mountPolicy) *policyFs {
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	return &policyFs{Fs: fs, root: root, policy: policy}
+}
+
+// relative returns the given ftp path relative to the directory of its container.
+func (fs *policyFs) relative(name string) string {
+	_, rel, _ := strings.Cut(strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/"), "/")
+	return rel
+}
+
+// check returns os.ErrNotExist unless the given path, and the path that it resolves to, are visible.
+func (fs *policyFs) check(name string) error {
+	abs := filepath.Join(fs.root, filepath.FromSlash(name))
+	isVisible := func(rel, abs string) bool {
+		fi, err := os.Stat(abs)
+		return fs.policy.visible(rel, err == nil && fi.IsDir())
+	}
+	if !isVisible(fs.relative(name), abs) {
+		return os.ErrNotExist
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil && resolved != abs {
+		if rp, err := filepath.Rel(fs.root, resolved); err == nil && !strings.HasPrefix(rp, "..") && !isVisible(fs.relative(rp), resolved) {
+			return os.ErrNotExist
+		}
+	}
+	return nil
+}
+
+// checkWrite is like check, but also denies all modifications when the policy is read-only.
+func (fs *policyFs) checkWrite(name string) error {
+	if fs.policy.readOnly {
+		return os.ErrPermission
+	}
+	return fs.check(name)
+}
+
+func (fs *policyFs) Name() string {
+	return "policyFs"
+}
+
+func (fs *policyFs) Create(name string) (afero.File, error) {
+	if err := fs.checkWrite(name); err != nil {
+		return nil, &os.PathError{Op: "create", Path: name, Err: err}
+	}
+	f, err := fs.Fs.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &policyFile{File: f, fs: fs, name: name}, nil
+}
+
+func (fs *policyFs) Mkdir(name string, perm os.FileMode) error {
+	if err := fs.checkWrite(name); err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return fs.Fs.Mkdir(name, perm)
+}
+
+func (fs *policyFs) MkdirAll(name string, perm os.FileMode) error {
+	if err := fs.checkWrite(name); err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return fs.Fs.MkdirAll(name, perm)
+}
+
+func (fs *policyFs) Open(name string) (afero.File, error) {
+	return fs.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (fs *policyFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	var err error
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		err = fs.checkWrite(name)
+	} else {
+		err = fs.check(name)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	f, err := fs.Fs.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &policyFile{File: f, fs: fs, name: name}, nil
+}
+
+func (fs *policyFs) Remove(name string) error {
+	if err := fs.checkWrite(name); err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return fs.Fs.Remove(name)
+}
+
+func (fs *policyFs) RemoveAll(name string) error {
+	if err := fs.checkWrite(name); err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return fs.Fs.RemoveAll(name)
+}
+
+func (fs *policyFs) Rename(oldName, newName string) error {
+	if err := fs.checkWrite(oldName); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+	if err := fs.checkWrite(newName); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+	return fs.Fs.Rename(oldName, newName)
+}
+
+func (fs *policyFs) Stat(name string) (os.FileInfo, error) {
+	if err := fs.check(name); err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return fs.Fs.Stat(name)
+}
+
+func (fs *policyFs) Chmod(name string, mode os.FileMode) error {
+	if err := fs.checkWrite(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	return fs.Fs.Chmod(name, mode)
+}
+
+func (fs *policyFs) Chown(name string, uid, gid int) error {
+	if err := fs.checkWrite(name); err != nil {
+		return &os.PathError{Op: "chown", Path: name, Err: err}
+	}
+	return fs.Fs.Chown(name, uid, gid)
+}
+
+func (fs *policyFs) Chtimes(name string, atime, mtime time.Time) error {
+	if err := fs.checkWrite(name); err != nil {
+		return &os.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	return fs.Fs.Chtimes(name, atime, mtime)
+}
+
+// policyFile is a file or directory opened by a policyFs. Directory listings omit the entries that the policy
+// doesn't export.
+type policyFile struct {
+	afero.File
+	fs   *policyFs
+	name string
+}
+
+func (f *policyFile) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := f.File.Readdir(count)
+	visible := fis[:0]
+	for _, fi := range fis {
+		if f.fs.check(path.Join(f.name, fi.Name())) == nil {
+			visible = append(visible, fi)
+		}
+	}
+	return visible, err
+}
+
+func (f *policyFile) Readdirnames(n int) ([]string, error) {
+	fis, err := f.Readdir(n)
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+	return names, err
+}
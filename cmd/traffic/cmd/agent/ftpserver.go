@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"sync"
+
+	ftp "github.com/fclairamb/ftpserverlib"
+	"github.com/spf13/afero"
+
+	"github.com/datawire/dlib/dlog"
+	ftpserver "github.com/datawire/go-ftpserver"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// ftpDriver is the ftp.MainDriver of the ftp-server. It serves the exported volumes to anonymous clients. The
+// mountPolicy, when present, is enforced on all clients.
+type ftpDriver struct {
+	ftp.Settings
+	ctx      context.Context
+	basePath string
+	policy   *mountPolicy
+}
+
+type ftpClient struct {
+	afero.Fs
+	ctx context.Context
+}
+
+// ftpPublicHost returns the host that the ftp-server announces for passive transfers. It's empty when the
+// pod IP is an IPv6 address, because the PASV command only supports IPv4.
+func ftpPublicHost(podIP string) string {
+	if iputil.IsIpV6Addr(podIP) {
+		return ""
+	}
+	return podIP
+}
+
+// ftpListen creates a listener for an ftp-server on the next available port.
+func ftpListen(ctx context.Context) (net.Listener, uint16, error) {
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(ctx, "tcp", "0.0.0.0:0")
+	if err != nil {
+		return nil, 0, err
+	}
+	_, ftpPort, err := iputil.SplitToIPPort(l.Addr())
+	if err != nil {
+		_ = l.Close()
+		return nil, 0, err
+	}
+	return l, ftpPort, nil
+}
+
+// ftpServer creates a listener on the next available port, writes that port on the given channel, and then
+// serves the given directory using FTP until the context is cancelled.
+func ftpServer(ctx context.Context, publicHost, basePath string, ftpPortCh chan<- uint16) error {
+	defer close(ftpPortCh)
+	l, ftpPort, err := ftpListen(ctx)
+	if err != nil {
+		return err
+	}
+	ftpPortCh <- ftpPort
+	return serveFTP(ctx, l, publicHost, basePath, nil)
+}
+
+// ftpListener is a net.Listener that records each accepted control connection as an ftp session in the agent
+// metrics. The connections are closed when the context is cancelled, because stopping the ftp-server only closes
+// the listener.
+type ftpListener struct {
+	net.Listener
+	ctx context.Context
+}
+
+type ftpConn struct {
+	net.Conn
+	once sync.Once
+	done func()
+	stop func() bool
+}
+
+func (l *ftpListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &ftpConn{
+		Conn: conn,
+		done: metrics.ftpSessionStarted(),
+		stop: context.AfterFunc(l.ctx, func() { _ = conn.Close() }),
+	}, nil
+}
+
+func (c *ftpConn) Close() error {
+	c.once.Do(func() {
+		c.stop()
+		c.done()
+	})
+	return c.Conn.Close()
+}
+
+// serveFTP serves the given directory using FTP on the given listener until the context is cancelled. The
+// given mountPolicy is enforced unless it's nil.
+func serveFTP(ctx context.Context, l net.Listener, publicHost, basePath string, policy *mountPolicy) error {
+	d := &ftpDriver{
+		ctx:      ctx,
+		basePath: basePath,
+		policy:   policy,
+		Settings: ftp.Settings{
+			Banner:              "Telepresence Traffic Agent",
+			PublicHost:          publicHost,
+			DefaultTransferType: ftp.TransferTypeBinary,
+			EnableHASH:          true,
+			Listener:            &ftpListener{Listener: l, ctx: ctx},
+			ListenAddr:          l.Addr().String(),
+			IdleTimeout:         300,
+		},
+	}
+	dlog.Infof(ctx, "FTP server listening on %s", d.ListenAddr)
+
+	s := ftp.NewFtpServer(d)
+	var err error
+	s.Logger = ftpserver.Logger(ctx)
+	go func() {
+		<-ctx.Done()
+		dlog.Infof(ctx, "Stopping FTP server")
+		if err := s.Stop(); err != nil {
+			dlog.Errorf(ctx, "failed to stop ftp server: %v", err)
+		}
+	}()
+	if err = s.ListenAndServe(); err != nil && ctx.Err() != nil {
+		err = nil // Normal shutdown
+	}
+	return err
+}
+
+func (d *ftpDriver) GetSettings() (*ftp.Settings, error) {
+	return &d.Settings, nil
+}
+
+func (d *ftpDriver) ClientConnected(cc ftp.ClientContext) (string, error) {
+	dlog.Infof(d.ctx, "FTP client connected, id %d, remoteAddr %s", cc.ID(), cc.RemoteAddr())
+	cc.SetDebug(dlog.MaxLogLevel(d.ctx) >= dlog.LogLevelDebug)
+	return "telepresence", nil
+}
+
+func (d *ftpDriver) ClientDisconnected(cc ftp.ClientContext) {
+	dlog.Infof(d.ctx, "FTP client disconnected, id %d, remoteAddr %s", cc.ID(), cc.RemoteAddr())
+}
+
+func (d *ftpDriver) AuthUser(_ ftp.ClientContext, userName, _ string) (ftp.ClientDriver, error) {
+	if userName != "anonymous" {
+		return nil, errors.New("unknown user")
+	}
+	fs := afero.NewBasePathFs(ftpserver.SymLinkResolvingFs(afero.NewOsFs()), d.basePath)
+	if d.policy != nil {
+		fs = newPolicyFs(fs, d.basePath, d.policy)
+	}
+	return &ftpClient{Fs: fs, ctx: d.ctx}, nil
+}
+
+func (d *ftpDriver) GetTLSConfig() (*tls.Config, error) {
+	return nil, errors.New("not enabled")
+}
+
+// GetHandle implements ftp.ClientDriverExtentionFileTransfer.
+func (c *ftpClient) GetHandle(name string, flags int, offset int64) (ftp.FileTransfer, error) {
+	dlog.Debugf(c.ctx, "GetHandle(%s, %#x, %d)", name, flags, offset)
+	f, err := c.OpenFile(name, flags, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if flags == os.O_CREATE|os.O_WRONLY {
+		if err := f.Truncate(offset); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	if offset > 0 {
+		if _, err = f.Seek(offset, 0); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
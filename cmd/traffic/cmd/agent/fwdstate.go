@@ -3,9 +3,12 @@ package agent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
@@ -19,6 +22,9 @@ type fwdState struct {
 	forwarder  forwarder.Interceptor
 	mountPoint string
 	env        map[string]string
+
+	// The meter of the active intercept in the agent metrics
+	meter *interceptMeter
 }
 
 // NewInterceptState creates a InterceptState that performs intercepts by using an Interceptor which indiscriminately
@@ -72,41 +78,91 @@ func (pm *ProviderMux) CreateClientStream(ctx context.Context, sessionID string,
 	return s, err
 }
 
+// activeReview returns the review that makes the given intercept active, or an AGENT_ERROR review if the
+// intercept's mounts cannot be served.
+func (fs *fwdState) activeReview(ctx context.Context, cept *manager.InterceptInfo) *manager.ReviewInterceptRequest {
+	ftpPort, sftpPort, err := fs.mountPortsFor(ctx, cept)
+	if err != nil {
+		dlog.Errorf(ctx, "Setting intercept %q as AGENT_ERROR; %v", cept.Id, err)
+		return &manager.ReviewInterceptRequest{
+			Id:                cept.Id,
+			Disposition:       manager.InterceptDispositionType_AGENT_ERROR,
+			Message:           fmt.Sprintf("Unable to serve mounts: %v", err),
+			MechanismArgsDesc: "all TCP connections",
+		}
+	}
+	return &manager.ReviewInterceptRequest{
+		Id:                cept.Id,
+		Disposition:       manager.InterceptDispositionType_ACTIVE,
+		PodIp:             fs.PodIP(),
+		FtpPort:           int32(ftpPort),
+		SftpPort:          int32(sftpPort),
+		MountPoint:        fs.mountPoint,
+		MechanismArgsDesc: "all TCP connections",
+		Environment:       fs.env,
+	}
+}
+
 func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
 	var myChoice, activeIntercept *manager.InterceptInfo
 
-	// Find the chosen intercept if it still exists
+	// Find the chosen intercept if it still exists. The intercept is found by its ID, because the
+	// client may have updated its spec since it was chosen.
 	if fs.chosenIntercept != nil {
 		for _, cept := range cepts {
-			if cept == fs.chosenIntercept {
+			if cept.Id == fs.chosenIntercept.Id {
 				myChoice = cept
+				fs.chosenIntercept = cept
 				break
 			}
 		}
 
 		if myChoice != nil && myChoice.Disposition == manager.InterceptDispositionType_ACTIVE {
-			// The chosen intercept still exists and is active
+			// The chosen intercept still exists and is active. A paused intercept remains chosen,
+			// but its traffic is forwarded to the application container.
 			activeIntercept = myChoice
 		}
 	} else {
-		// Attach to already ACTIVE intercept if there is one.
+		// Attach to an already ACTIVE or PAUSED intercept if there is one, e.g. after a restart of this agent,
+		// or when this is a new replica. A PAUSED intercept is chosen, but its traffic isn't forwarded.
 		for _, cept := range cepts {
-			if cept.Disposition == manager.InterceptDispositionType_ACTIVE {
-				myChoice = cept
-				fs.chosenIntercept = cept
+			switch cept.Disposition {
+			case manager.InterceptDispositionType_ACTIVE:
 				activeIntercept = cept
-				break
+			case manager.InterceptDispositionType_PAUSED:
+			default:
+				continue
 			}
+			myChoice = cept
+			fs.chosenIntercept = cept
+			break
 		}
 	}
 
+	var labels prometheus.Labels
+	if activeIntercept != nil {
+		_, port := fs.forwarder.Target()
+		labels = interceptLabels(activeIntercept, port)
+	}
+	if fs.meter == nil || !maps.Equal(labels, fs.meter.labels) {
+		if fs.meter != nil {
+			fs.meter.end()
+			fs.meter = nil
+		}
+		if labels != nil {
+			fs.meter = metrics.startIntercept(labels)
+		}
+	}
 	if fs.sessionInfo != nil {
 		// Update forwarding.
-		fs.forwarder.SetStreamProvider(
-			&ProviderMux{
-				AgentProvider:   fs,
-				ManagerProvider: &tunnel.TrafficManagerStreamProvider{Manager: fs.ManagerClient(), AgentSessionID: fs.sessionInfo.SessionId},
-			})
+		var sp tunnel.ClientStreamProvider = &ProviderMux{
+			AgentProvider:   fs,
+			ManagerProvider: &tunnel.TrafficManagerStreamProvider{Manager: fs.ManagerClient(), AgentSessionID: fs.sessionInfo.SessionId},
+		}
+		if fs.meter != nil {
+			sp = &meteredStreamProvider{ClientStreamProvider: sp, meter: fs.meter}
+		}
+		fs.forwarder.SetStreamProvider(sp)
 	}
 	fs.forwarder.SetIntercepting(activeIntercept)
 
@@ -116,20 +172,11 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 		if cept.Disposition == manager.InterceptDispositionType_WAITING {
 			// This intercept is ready to be active
 			switch {
-			case cept == myChoice:
+			case myChoice != nil && cept.Id == myChoice.Id:
 				// We've already chosen this one, but it's not active yet in this
 				// snapshot. Let's go ahead and tell the manager to mark it ACTIVE.
 				dlog.Infof(ctx, "Setting intercept %q as ACTIVE (again?)", cept.Id)
-				reviews = append(reviews, &manager.ReviewInterceptRequest{
-					Id:                cept.Id,
-					Disposition:       manager.InterceptDispositionType_ACTIVE,
-					PodIp:             fs.PodIP(),
-					FtpPort:           int32(fs.FtpPort()),
-					SftpPort:          int32(fs.SftpPort()),
-					MountPoint:        fs.mountPoint,
-					MechanismArgsDesc: "all TCP connections",
-					Environment:       fs.env,
-				})
+				reviews = append(reviews, fs.activeReview(ctx, cept))
 			case fs.chosenIntercept == nil:
 				// We don't have an intercept in play, so choose this one. All
 				// agents will get intercepts in the same order every time, so
@@ -139,24 +186,18 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 				dlog.Infof(ctx, "Setting intercept %q as ACTIVE", cept.Id)
 				fs.chosenIntercept = cept
 				myChoice = cept
-				reviews = append(reviews, &manager.ReviewInterceptRequest{
-					Id:                cept.Id,
-					Disposition:       manager.InterceptDispositionType_ACTIVE,
-					PodIp:             fs.PodIP(),
-					FtpPort:           int32(fs.FtpPort()),
-					SftpPort:          int32(fs.SftpPort()),
-					MountPoint:        fs.mountPoint,
-					MechanismArgsDesc: "all TCP connections",
-					Environment:       fs.env,
-				})
+				reviews = append(reviews, fs.activeReview(ctx, cept))
 			default:
 				// We already have an intercept in play, so reject this one.
 				chosenID := fs.chosenIntercept.Id
 				dlog.Infof(ctx, "Setting intercept %q as AGENT_ERROR; as it conflicts with %q as the current chosen-to-be-ACTIVE intercept", cept.Id, chosenID)
 				var msg string
-				if fs.chosenIntercept.Disposition == manager.InterceptDispositionType_ACTIVE {
+				switch fs.chosenIntercept.Disposition {
+				case manager.InterceptDispositionType_ACTIVE:
 					msg = fmt.Sprintf("Conflicts with the currently-served intercept %q", chosenID)
-				} else {
+				case manager.InterceptDispositionType_PAUSED:
+					msg = fmt.Sprintf("Conflicts with the currently-paused intercept %q", chosenID)
+				default:
 					msg = fmt.Sprintf("Conflicts with the currently-waiting-to-be-served intercept %q", chosenID)
 				}
 				reviews = append(reviews, &manager.ReviewInterceptRequest{
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// handleIngests reviews the given ingests. There's no forwarding involved, so all waiting ingests
+// become active as long as their container exists.
+func (s *state) handleIngests(ctx context.Context, iis []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
+	var rs []*manager.ReviewInterceptRequest
+	for _, ii := range iis {
+		if ii.Disposition != manager.InterceptDispositionType_WAITING {
+			continue
+		}
+		ac := s.appContainer(ii.Spec.ContainerName)
+		if ac == nil {
+			dlog.Infof(ctx, "Setting ingest %q as AGENT_ERROR; container %q not found", ii.Id, ii.Spec.ContainerName)
+			rs = append(rs, &manager.ReviewInterceptRequest{
+				Id:          ii.Id,
+				Disposition: manager.InterceptDispositionType_AGENT_ERROR,
+				Message:     fmt.Sprintf("No container named %q", ii.Spec.ContainerName),
+			})
+			continue
+		}
+		ftpPort, sftpPort, err := s.mountPortsFor(ctx, ii)
+		if err != nil {
+			dlog.Errorf(ctx, "Setting ingest %q as AGENT_ERROR; %v", ii.Id, err)
+			rs = append(rs, &manager.ReviewInterceptRequest{
+				Id:          ii.Id,
+				Disposition: manager.InterceptDispositionType_AGENT_ERROR,
+				Message:     fmt.Sprintf("Unable to serve mounts: %v", err),
+			})
+			continue
+		}
+		dlog.Infof(ctx, "Setting ingest %q as ACTIVE", ii.Id)
+		rs = append(rs, &manager.ReviewInterceptRequest{
+			Id:                ii.Id,
+			Disposition:       manager.InterceptDispositionType_ACTIVE,
+			PodIp:             s.PodIP(),
+			FtpPort:           int32(ftpPort),
+			SftpPort:          int32(sftpPort),
+			MountPoint:        ac.mountPoint,
+			MechanismArgsDesc: "no traffic; environment and mounts only",
+			Environment:       ac.env,
+		})
+	}
+	return rs
+}
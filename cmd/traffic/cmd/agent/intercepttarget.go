@@ -122,8 +122,10 @@ func (cp InterceptTarget) String() string {
 				sb.WriteString(", ")
 			}
 		}
-		sb.WriteString(sv.ServiceName)
-		sb.WriteByte(':')
+		if sv.ServiceName != "" {
+			sb.WriteString(sv.ServiceName)
+			sb.WriteByte(':')
+		}
 		if sv.ServicePortName != "" {
 			sb.WriteString(sv.ServicePortName)
 		} else {
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+const metricsNamespace = "traffic_agent"
+
+// agentMetrics are the Prometheus metrics of the traffic-agent. They are always collected, but only served when
+// the agent is configured with a metrics port.
+type agentMetrics struct {
+	registry         *prometheus.Registry
+	activeIntercepts *prometheus.GaugeVec
+	connections      *prometheus.CounterVec
+	ingressBytes     *prometheus.CounterVec
+	egressBytes      *prometheus.CounterVec
+	ingressWireBytes *prometheus.CounterVec
+	egressWireBytes  *prometheus.CounterVec
+	dialFailures     *prometheus.CounterVec
+	dnsLookups       *prometheus.CounterVec
+	sftpSessions     prometheus.Counter
+	activeSFTP       prometheus.Gauge
+	ftpSessions      prometheus.Counter
+	activeFTP        prometheus.Gauge
+}
+
+// metrics is global because the code paths that update it, such as the DNS lookups and the sftp-server, have no
+// access to the agent state.
+var metrics = newAgentMetrics() //nolint:gochecknoglobals // prometheus collectors are global by nature
+
+func newAgentMetrics() *agentMetrics {
+	interceptLabels := []string{"intercept", "port"}
+	m := &agentMetrics{
+		registry: prometheus.NewRegistry(),
+		activeIntercepts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "active_intercepts",
+			Help:      "Number of active intercepts",
+		}, interceptLabels),
+		connections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "forwarded_connections_total",
+			Help:      "Number of intercepted connections forwarded to clients",
+		}, interceptLabels),
+		ingressBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "forwarded_ingress_bytes_total",
+			Help:      "Number of intercepted bytes received from clients",
+		}, interceptLabels),
+		egressBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "forwarded_egress_bytes_total",
+			Help:      "Number of intercepted bytes sent to clients",
+		}, interceptLabels),
+		ingressWireBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "forwarded_ingress_wire_bytes_total",
+			Help:      "Number of bytes that the intercepted bytes received from clients occupied on the wire after compression",
+		}, interceptLabels),
+		egressWireBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "forwarded_egress_wire_bytes_total",
+			Help:      "Number of bytes that the intercepted bytes sent to clients occupied on the wire after compression",
+		}, interceptLabels),
+		dialFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "dial_failures_total",
+			Help:      "Number of intercepted connections that couldn't be forwarded to the client",
+		}, interceptLabels),
+		dnsLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "dns_lookups_total",
+			Help:      "Number of DNS lookups served on behalf of clients",
+		}, []string{"type"}),
+		sftpSessions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "sftp_sessions_total",
+			Help:      "Number of sftp sessions served",
+		}),
+		activeSFTP: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "active_sftp_sessions",
+			Help:      "Number of currently served sftp sessions",
+		}),
+		ftpSessions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "ftp_sessions_total",
+			Help:      "Number of ftp sessions served",
+		}),
+		activeFTP: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "active_ftp_sessions",
+			Help:      "Number of currently served ftp sessions",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.activeIntercepts,
+		m.connections,
+		m.ingressBytes,
+		m.egressBytes,
+		m.ingressWireBytes,
+		m.egressWireBytes,
+		m.dialFailures,
+		m.dnsLookups,
+		m.sftpSessions,
+		m.activeSFTP,
+		m.ftpSessions,
+		m.activeFTP,
+	)
+	return m
+}
+
+// interceptLabels returns the labels used for metrics that are collected per intercept and container port.
+func interceptLabels(cept *manager.InterceptInfo, port uint16) prometheus.Labels {
+	return prometheus.Labels{"intercept": cept.Spec.Name, "port": strconv.Itoa(int(port))}
+}
+
+// interceptMeter owns the series of one active intercept and port. The series are deleted when the intercept
+// ends, so that the number of series doesn't grow with every intercept that a long-lived agent has seen.
+type interceptMeter struct {
+	sync.RWMutex
+	m      *agentMetrics
+	labels prometheus.Labels
+	ended  bool
+}
+
+// startIntercept records an active intercept with the given labels and returns its meter.
+func (m *agentMetrics) startIntercept(labels prometheus.Labels) *interceptMeter {
+	m.activeIntercepts.With(labels).Set(1)
+	return &interceptMeter{m: m, labels: labels}
+}
+
+// end deletes all series of the intercept. Updates that arrive later, e.g. from streams that outlive the
+// intercept, are discarded.
+func (im *interceptMeter) end() {
+	im.Lock()
+	defer im.Unlock()
+	im.ended = true
+	for _, v := range []*prometheus.MetricVec{
+		im.m.activeIntercepts.MetricVec,
+		im.m.connections.MetricVec,
+		im.m.ingressBytes.MetricVec,
+		im.m.egressBytes.MetricVec,
+		im.m.ingressWireBytes.MetricVec,
+		im.m.egressWireBytes.MetricVec,
+		im.m.dialFailures.MetricVec,
+	} {
+		v.Delete(im.labels)
+	}
+}
+
+// update calls the given function with the labels of the intercept, unless the intercept has ended.
+func (im *interceptMeter) update(f func(prometheus.Labels)) {
+	im.RLock()
+	defer im.RUnlock()
+	if !im.ended {
+		f(im.labels)
+	}
+}
+
+// sftpSessionStarted records the start of an sftp session and returns a function that records its end.
+func (m *agentMetrics) sftpSessionStarted() func() {
+	m.sftpSessions.Inc()
+	m.activeSFTP.Inc()
+	return m.activeSFTP.Dec
+}
+
+// ftpSessionStarted records the start of an ftp session and returns a function that records its end.
+func (m *agentMetrics) ftpSessionStarted() func() {
+	m.ftpSessions.Inc()
+	m.activeFTP.Inc()
+	return m.activeFTP.Dec
+}
+
+// serve serves the metrics on the given port until the context is cancelled.
+func (m *agentMetrics) serve(ctx context.Context, port uint16) error {
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(ctx, "tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	dlog.Infof(ctx, "Prometheus metrics server started on port %d", port)
+	defer dlog.Info(ctx, "Prometheus metrics server stopped")
+	sc := &dhttp.ServerConfig{Handler: promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})}
+	if err = sc.Serve(ctx, l); err != nil && ctx.Err() != nil {
+		err = nil // Normal shutdown
+	}
+	return err
+}
+
+// meteredStreamProvider is a tunnel.ClientStreamProvider that updates the intercept metrics for the streams
+// that it provides.
+type meteredStreamProvider struct {
+	tunnel.ClientStreamProvider
+	meter *interceptMeter
+}
+
+func (p *meteredStreamProvider) CreateClientStream(
+	ctx context.Context,
+	sessionID string,
+	id tunnel.ConnID,
+	roundTripLatency,
+	dialTimeout time.Duration,
+) (tunnel.Stream, error) {
+	s, err := p.ClientStreamProvider.CreateClientStream(ctx, sessionID, id, roundTripLatency, dialTimeout)
+	p.meter.update(func(labels prometheus.Labels) {
+		if err != nil {
+			p.meter.m.dialFailures.With(labels).Inc()
+		} else {
+			p.meter.m.connections.With(labels).Inc()
+		}
+	})
+	return s, err
+}
+
+func (p *meteredStreamProvider) ReportMetrics(ctx context.Context, tm *manager.TunnelMetrics) {
+	p.meter.update(func(labels prometheus.Labels) {
+		m := p.meter.m
+		m.ingressBytes.With(labels).Add(float64(tm.IngressBytes))
+		m.egressBytes.With(labels).Add(float64(tm.EgressBytes))
+		m.ingressWireBytes.With(labels).Add(float64(tm.IngressWireBytes))
+		m.egressWireBytes.With(labels).Add(float64(tm.EgressWireBytes))
+	})
+	p.ClientStreamProvider.ReportMetrics(ctx, tm)
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+type fakeStreamProvider struct {
+	err     error
+	metrics []*manager.TunnelMetrics
+}
+
+func (f *fakeStreamProvider) CreateClientStream(context.Context, string, tunnel.ConnID, time.Duration, time.Duration) (tunnel.Stream, error) {
+	return nil, f.err
+}
+
+func (f *fakeStreamProvider) ReportMetrics(_ context.Context, metrics *manager.TunnelMetrics) {
+	f.metrics = append(f.metrics, metrics)
+}
+
+func TestMeteredStreamProvider(t *testing.T) {
+	m := newAgentMetrics()
+	saved := metrics
+	metrics = m
+	defer func() { metrics = saved }()
+
+	cept := &manager.InterceptInfo{Spec: &manager.InterceptSpec{Name: "echo"}}
+	labels := interceptLabels(cept, 8080)
+	ctx := context.Background()
+
+	meter := m.startIntercept(labels)
+	fake := &fakeStreamProvider{}
+	sp := &meteredStreamProvider{ClientStreamProvider: fake, meter: meter}
+	_, err := sp.CreateClientStream(ctx, "s1", "", 0, 0)
+	require.NoError(t, err)
+	fake.err = errors.New("no route")
+	_, err = sp.CreateClientStream(ctx, "s1", "", 0, 0)
+	require.Error(t, err)
+	sp.ReportMetrics(ctx, &manager.TunnelMetrics{ClientSessionId: "s1", IngressBytes: 100, EgressBytes: 20, IngressWireBytes: 60, EgressWireBytes: 12})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.connections.With(labels)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.dialFailures.With(labels)))
+	assert.Equal(t, 100.0, testutil.ToFloat64(m.ingressBytes.With(labels)))
+	assert.Equal(t, 20.0, testutil.ToFloat64(m.egressBytes.With(labels)))
+	assert.Equal(t, 60.0, testutil.ToFloat64(m.ingressWireBytes.With(labels)))
+	assert.Equal(t, 12.0, testutil.ToFloat64(m.egressWireBytes.With(labels)))
+	assert.Len(t, fake.metrics, 1, "metrics must be reported to the wrapped provider")
+
+	other := interceptLabels(&manager.InterceptInfo{Spec: &manager.InterceptSpec{Name: "other"}}, 8080)
+	otherMeter := m.startIntercept(other)
+	meter.end()
+	require.NoError(t, testutil.CollectAndCompare(m.activeIntercepts, strings.NewReader(`
+# HELP traffic_agent_active_intercepts Number of active intercepts
+# TYPE traffic_agent_active_intercepts gauge
+traffic_agent_active_intercepts{intercept="other",port="8080"} 1
+`)))
+
+	// The series of an ended intercept are deleted, and aren't recreated by streams that outlive it.
+	sp.ReportMetrics(ctx, &manager.TunnelMetrics{ClientSessionId: "s1", IngressBytes: 100})
+	_, _ = sp.CreateClientStream(ctx, "s1", "", 0, 0)
+	assert.Equal(t, 0, testutil.CollectAndCount(m.connections))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.dialFailures))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.ingressBytes))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.egressWireBytes))
+	otherMeter.end()
+	assert.Equal(t, 0, testutil.CollectAndCount(m.activeIntercepts))
+
+	done := m.sftpSessionStarted()
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.activeSFTP))
+	done()
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.activeSFTP))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.sftpSessions))
+}
+
+func TestFTPListener(t *testing.T) {
+	m := newAgentMetrics()
+	saved := metrics
+	metrics = m
+	defer func() { metrics = saved }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nl, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l := &ftpListener{Listener: nl, ctx: ctx}
+	defer l.Close()
+
+	accept := func() (client, server net.Conn) {
+		client, err := net.Dial("tcp", nl.Addr().String())
+		require.NoError(t, err)
+		server, err = l.Accept()
+		require.NoError(t, err)
+		return client, server
+	}
+
+	c1, s1 := accept()
+	defer c1.Close()
+	c2, s2 := accept()
+	defer c2.Close()
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.activeFTP))
+
+	// Closing a connection twice ends its session once.
+	require.NoError(t, s1.Close())
+	_ = s1.Close()
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.activeFTP))
+
+	// Connections that remain open are closed when the context is cancelled.
+	cancel()
+	require.NoError(t, c2.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = c2.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	_ = s2.Close()
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.activeFTP))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.ftpSessions))
+}
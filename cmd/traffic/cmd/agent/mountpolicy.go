@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"path"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// mountPolicy limits what the sftp-server exports to the client of an intercept.
+type mountPolicy struct {
+	readOnly bool
+	include  [][]string
+	exclude  [][]string
+}
+
+// newMountPolicy returns the mountPolicy declared by the given spec, or nil if the spec declares no limitations.
+func newMountPolicy(spec *manager.InterceptSpec) *mountPolicy {
+	if spec == nil || !spec.MountReadOnly && len(spec.MountInclude) == 0 && len(spec.MountExclude) == 0 {
+		return nil
+	}
+	return &mountPolicy{
+		readOnly: spec.MountReadOnly,
+		include:  splitPatterns(spec.MountInclude),
+		exclude:  splitPatterns(spec.MountExclude),
+	}
+}
+
+func splitPatterns(patterns []string) [][]string {
+	if len(patterns) == 0 {
+		return nil
+	}
+	sps := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p = strings.Trim(path.Clean("/"+p), "/"); p != "" {
+			sps = append(sps, strings.Split(p, "/"))
+		}
+	}
+	return sps
+}
+
+func splitPath(p string) []string {
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// matchSegments compares the segments that the pattern and the path have in common, and returns true if they all match.
+func matchSegments(pattern, segs []string) bool {
+	for i := 0; i < len(pattern) && i < len(segs); i++ {
+		if ok, _ := path.Match(pattern[i], segs[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// visible returns true if the given path, which is relative to the root of the exported volumes, is exported. A path
+// is exported unless it, or one of its parent directories, matches an exclude pattern. When include patterns are
+// present, the path, or one of its parent directories, must also match one of them. Directories that lead to paths
+// that might match an include pattern are exported too, so that it's possible to navigate to the included paths.
+func (p *mountPolicy) visible(rel string, isDir bool) bool {
+	if p == nil {
+		return true
+	}
+	segs := splitPath(rel)
+	if len(segs) == 0 {
+		return true
+	}
+	for _, ex := range p.exclude {
+		if len(segs) >= len(ex) && matchSegments(ex, segs) {
+			return false
+		}
+	}
+	if len(p.include) == 0 {
+		return true
+	}
+	for _, in := range p.include {
+		if matchSegments(in, segs) && (len(segs) >= len(in) || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// exportsRelative returns the path relative to the root of the exported volumes of a container, and true, if the
+// given absolute path is located below such a root.
+func exportsRelative(abs string) (string, bool) {
+	rest, ok := strings.CutPrefix(abs, agentconfig.ExportsMountPoint+"/")
+	if !ok {
+		return "", false
+	}
+	// The first segment is the container's directory.
+	_, rel, _ := strings.Cut(rest, "/")
+	return rel, true
+}
@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestMountPolicy_visible(t *testing.T) {
+	assert.Nil(t, newMountPolicy(&manager.InterceptSpec{}))
+
+	p := newMountPolicy(&manager.InterceptSpec{
+		MountInclude: []string{"/var/run/secrets", "etc/*.conf"},
+		MountExclude: []string{"var/run/secrets/*/token"},
+	})
+	tests := []struct {
+		path    string
+		isDir   bool
+		visible bool
+	}{
+		{path: "", isDir: true, visible: true},
+		{path: "var", isDir: true, visible: true},
+		{path: "var/run", isDir: true, visible: true},
+		{path: "var/lib", isDir: true, visible: false},
+		{path: "var/run/secrets/kubernetes.io", isDir: true, visible: true},
+		{path: "var/run/secrets/kubernetes.io/ca.crt", visible: true},
+		{path: "var/run/secrets/kubernetes.io/token", visible: false},
+		{path: "etc", isDir: true, visible: true},
+		{path: "etc/app.conf", visible: true},
+		{path: "etc/app.yaml", visible: false},
+		{path: "data", visible: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.visible, p.visible(tt.path, tt.isDir), tt.path)
+	}
+
+	p = newMountPolicy(&manager.InterceptSpec{MountExclude: []string{"data"}})
+	assert.True(t, p.visible("etc/app.conf", false))
+	assert.False(t, p.visible("data", true))
+	assert.False(t, p.visible("data/big.db", false))
+}
+
+func TestExportsRelative(t *testing.T) {
+	rel, ok := exportsRelative("/tel_app_exports/echo/var/run/secrets")
+	assert.True(t, ok)
+	assert.Equal(t, "var/run/secrets", rel)
+
+	rel, ok = exportsRelative("/tel_app_exports/echo")
+	assert.True(t, ok)
+	assert.Equal(t, "", rel)
+
+	_, ok = exportsRelative("/etc/passwd")
+	assert.False(t, ok)
+}
+
+func sftpClient(t *testing.T, policy *mountPolicy) *sftp.Client {
+	sConn, cConn := net.Pipe()
+	srv := sftp.NewRequestServer(sConn, newSFTPHandlers(policy))
+	go func() { _ = srv.Serve() }()
+	sc, err := sftp.NewClientPipe(cConn, cConn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sc.Close()
+		_ = srv.Close()
+	})
+	return sc
+}
+
+func TestSFTPHandlers_readOnly(t *testing.T) {
+	sc := sftpClient(t, nil)
+	dir := t.TempDir()
+	name := filepath.Join(dir, "file.txt")
+	f, err := sc.Create(name)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	sc = sftpClient(t, newMountPolicy(&manager.InterceptSpec{MountReadOnly: true}))
+	_, err = sc.Create(filepath.Join(dir, "other.txt"))
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.ErrorIs(t, sc.Remove(name), os.ErrPermission)
+	assert.ErrorIs(t, sc.Mkdir(filepath.Join(dir, "sub")), os.ErrPermission)
+
+	rf, err := sc.Open(name)
+	require.NoError(t, err)
+	defer rf.Close()
+	fis, err := sc.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, fis, 1)
+	assert.Equal(t, "file.txt", fis[0].Name())
+
+	if runtime.GOOS == "linux" {
+		vfs, err := sc.StatVFS(dir)
+		require.NoError(t, err)
+		assert.NotZero(t, vfs.Bsize)
+	}
+}
+
+func TestPolicyFs(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name, content string) {
+		abs := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(abs), 0o700))
+		require.NoError(t, os.WriteFile(abs, []byte(content), 0o600))
+	}
+	writeFile("app/etc/config.yaml", "config")
+	writeFile("app/var/run/secrets/token", "token")
+	writeFile("app/var/log/app.log", "log")
+	require.NoError(t, os.Symlink("../var/run/secrets/token", filepath.Join(root, "app", "etc", "token")))
+
+	fs := newPolicyFs(afero.NewBasePathFs(afero.NewOsFs(), root), root, newMountPolicy(&manager.InterceptSpec{
+		MountInclude:  []string{"etc", "var/run"},
+		MountExclude:  []string{"var/run/secrets"},
+		MountReadOnly: true,
+	}))
+
+	_, err := fs.Stat("/app/etc/config.yaml")
+	assert.NoError(t, err)
+	_, err = fs.Stat("/app/var/log/app.log")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = fs.Open("/app/var/run/secrets/token")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = fs.Open("/app/etc/token")
+	assert.ErrorIs(t, err, os.ErrNotExist, "a symlink must not expose an excluded path")
+
+	_, err = fs.OpenFile("/app/etc/config.yaml", os.O_WRONLY|os.O_TRUNC, 0o600)
+	assert.ErrorIs(t, err, os.ErrPermission)
+	_, err = fs.Create("/app/etc/other.yaml")
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.ErrorIs(t, fs.Remove("/app/etc/config.yaml"), os.ErrPermission)
+
+	d, err := fs.Open("/app/var")
+	require.NoError(t, err)
+	names, err := d.Readdirnames(-1)
+	require.NoError(t, err)
+	_ = d.Close()
+	assert.Equal(t, []string{"run"}, names)
+
+	d, err = fs.Open("/app/etc")
+	require.NoError(t, err)
+	names, err = d.Readdirnames(-1)
+	require.NoError(t, err)
+	_ = d.Close()
+	assert.Equal(t, []string{"config.yaml"}, names)
+}
+
+func TestState_mountPortsFor(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := &state{Config: &config{podIP: "127.0.0.1"}, ftpPort: 2121, sftpPort: 2222}
+	plain := &manager.InterceptInfo{Id: "plain", Spec: &manager.InterceptSpec{}}
+	limited := &manager.InterceptInfo{Id: "limited", Spec: &manager.InterceptSpec{MountReadOnly: true}}
+
+	ftpPort, sftpPort, err := s.mountPortsFor(ctx, plain)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(2121), ftpPort)
+	assert.Equal(t, uint16(2222), sftpPort)
+
+	// An intercept with a mount policy gets servers of its own, and keeps them.
+	ftpPort, sftpPort, err = s.mountPortsFor(ctx, limited)
+	require.NoError(t, err)
+	assert.NotContains(t, []uint16{0, 2121}, ftpPort)
+	assert.NotContains(t, []uint16{0, 2222}, sftpPort)
+	ftpAgain, sftpAgain, err := s.mountPortsFor(ctx, limited)
+	require.NoError(t, err)
+	assert.Equal(t, ftpPort, ftpAgain)
+	assert.Equal(t, sftpPort, sftpAgain)
+
+	conn, err := net.Dial("tcp", net.JoinHostPort("localhost", strconv.Itoa(int(sftpPort))))
+	require.NoError(t, err)
+	sc, err := sftp.NewClientPipe(conn, conn)
+	require.NoError(t, err)
+	_, err = sc.Create(filepath.Join(t.TempDir(), "file.txt"))
+	assert.ErrorIs(t, err, os.ErrPermission)
+	_ = sc.Close()
+
+	// The ftp-server greets its clients.
+	conn, err = net.Dial("tcp", net.JoinHostPort("localhost", strconv.Itoa(int(ftpPort))))
+	require.NoError(t, err)
+	greeting, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(greeting, "220 "), greeting)
+	_ = conn.Close()
+
+	// The servers are closed when the intercept is gone.
+	s.closeMountServers(ctx, []*manager.InterceptInfo{plain})
+	assert.Empty(t, s.mountServers.servers)
+	for _, port := range []uint16{ftpPort, sftpPort} {
+		assert.Eventually(t, func() bool {
+			conn, err := net.Dial("tcp", net.JoinHostPort("localhost", strconv.Itoa(int(port))))
+			if err == nil {
+				_ = conn.Close()
+			}
+			return err != nil
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// Nothing is mounted when there are no default servers.
+	s = &state{}
+	ftpPort, sftpPort, err = s.mountPortsFor(ctx, limited)
+	require.NoError(t, err)
+	assert.Zero(t, ftpPort)
+	assert.Zero(t, sftpPort)
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/pkg/sftp"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// mountServers keeps one sftp-server and one ftp-server for each intercept that declares a mount policy, so that
+// the policy is enforced on the connections that the client of that intercept makes, and on no other connections.
+// Intercepts without a mount policy use the default servers.
+type mountServers struct {
+	sync.Mutex
+	servers map[string]*mountServer
+}
+
+type mountServer struct {
+	sftpPort uint16
+	ftpPort  uint16
+	cancel   context.CancelFunc
+}
+
+// mountPortsFor returns the ports of the ftp-server and the sftp-server that serve the given intercept. A port
+// is zero when the corresponding default server isn't running.
+func (s *state) mountPortsFor(ctx context.Context, ii *manager.InterceptInfo) (ftpPort, sftpPort uint16, err error) {
+	policy := newMountPolicy(ii.Spec)
+	if policy == nil || s.FtpPort() == 0 && s.SftpPort() == 0 {
+		return s.FtpPort(), s.SftpPort(), nil
+	}
+	ms := &s.mountServers
+	ms.Lock()
+	defer ms.Unlock()
+	if srv, ok := ms.servers[ii.Id]; ok {
+		return srv.ftpPort, srv.sftpPort, nil
+	}
+
+	// The servers outlive the call that creates them. They're cancelled when the intercept is gone.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	srv := &mountServer{cancel: cancel}
+	if s.SftpPort() != 0 {
+		var l net.Listener
+		if l, srv.sftpPort, err = sftpListen(ctx); err != nil {
+			cancel()
+			return 0, 0, err
+		}
+		go func() {
+			handlers := newSFTPHandlers(policy)
+			err := serveSFTP(ctx, l, func(conn net.Conn) (sftpSession, error) {
+				return sftp.NewRequestServer(conn, handlers), nil
+			})
+			if err != nil {
+				dlog.Error(ctx, err)
+			}
+		}()
+	}
+	if s.FtpPort() != 0 {
+		var l net.Listener
+		if l, srv.ftpPort, err = ftpListen(ctx); err != nil {
+			cancel()
+			return 0, 0, err
+		}
+		go func() {
+			if err := serveFTP(ctx, l, ftpPublicHost(s.PodIP()), agentconfig.ExportsMountPoint, policy); err != nil {
+				dlog.Error(ctx, err)
+			}
+		}()
+	}
+	if ms.servers == nil {
+		ms.servers = make(map[string]*mountServer)
+	}
+	ms.servers[ii.Id] = srv
+	dlog.Debugf(ctx, "Serving mounts of intercept %s with restricted ftp-server on port %d and sftp-server on port %d",
+		ii.Id, srv.ftpPort, srv.sftpPort)
+	return srv.ftpPort, srv.sftpPort, nil
+}
+
+// closeMountServers closes the mount servers of all intercepts that are no longer present in the given list.
+func (s *state) closeMountServers(ctx context.Context, iis []*manager.InterceptInfo) {
+	ms := &s.mountServers
+	ms.Lock()
+	defer ms.Unlock()
+	for id, srv := range ms.servers {
+		found := false
+		for _, ii := range iis {
+			if ii.Id == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dlog.Debugf(ctx, "Closing restricted mount servers of intercept %s", id)
+			srv.cancel()
+			delete(ms.servers, id)
+		}
+	}
+}
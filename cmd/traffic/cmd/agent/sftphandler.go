@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// sftpHandler implements the sftp.Handlers using the local file system, and enforces the mountPolicy of one intercept.
+// Paths that the policy doesn't export are reported as nonexistent, and modifications are denied when the policy is
+// read-only.
+type sftpHandler struct {
+	policy *mountPolicy
+}
+
+func newSFTPHandlers(policy *mountPolicy) sftp.Handlers {
+	h := &sftpHandler{policy: policy}
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
+}
+
+// check returns os.ErrNotExist unless the given path, and the path that it resolves to, are visible.
+func (h *sftpHandler) check(p *mountPolicy, abs string) error {
+	if p == nil {
+		return nil
+	}
+	isVisible := func(abs string) bool {
+		rel, ok := exportsRelative(abs)
+		if !ok {
+			return true
+		}
+		fi, err := os.Stat(abs)
+		return p.visible(rel, err == nil && fi.IsDir())
+	}
+	if !isVisible(abs) {
+		return os.ErrNotExist
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil && resolved != abs && !isVisible(resolved) {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// checkWrite is like check, but also denies all modifications when the policy is read-only.
+func (h *sftpHandler) checkWrite(p *mountPolicy, abs string) error {
+	if p != nil && p.readOnly {
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	return h.check(p, abs)
+}
+
+func (h *sftpHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	if err := h.check(h.policy, r.Filepath); err != nil {
+		return nil, err
+	}
+	return os.Open(r.Filepath)
+}
+
+func (h *sftpHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return h.OpenFile(r)
+}
+
+func (h *sftpHandler) OpenFile(r *sftp.Request) (sftp.WriterAtReaderAt, error) {
+	if err := h.checkWrite(h.policy, r.Filepath); err != nil {
+		return nil, err
+	}
+	pf := r.Pflags()
+	flags := os.O_WRONLY
+	if pf.Read {
+		flags = os.O_RDWR
+	}
+	// O_APPEND is ignored, because it cannot be combined with WriteAt, and the client
+	// provides the offsets anyway.
+	if pf.Creat {
+		flags |= os.O_CREATE
+	}
+	if pf.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pf.Excl {
+		flags |= os.O_EXCL
+	}
+	return os.OpenFile(r.Filepath, flags, 0o644)
+}
+
+func (h *sftpHandler) Filecmd(r *sftp.Request) error {
+	p := h.policy
+	if r.Method == "Symlink" {
+		// The Filepath is the target of the link, and the Target is the link.
+		if err := h.checkWrite(p, r.Target); err != nil {
+			return err
+		}
+		return os.Symlink(r.Filepath, r.Target)
+	}
+	if err := h.checkWrite(p, r.Filepath); err != nil {
+		return err
+	}
+	switch r.Method {
+	case "Setstat":
+		return setstat(r)
+	case "Rename", "PosixRename":
+		if err := h.check(p, r.Target); err != nil {
+			return err
+		}
+		return os.Rename(r.Filepath, r.Target)
+	case "Link":
+		if err := h.check(p, r.Target); err != nil {
+			return err
+		}
+		return os.Link(r.Filepath, r.Target)
+	case "Rmdir", "Remove":
+		return os.Remove(r.Filepath)
+	case "Mkdir":
+		return os.Mkdir(r.Filepath, 0o755)
+	default:
+		return sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *sftpHandler) StatVFS(r *sftp.Request) (*sftp.StatVFS, error) {
+	if err := h.check(h.policy, r.Filepath); err != nil {
+		return nil, err
+	}
+	return statVFS(r.Filepath)
+}
+
+func (h *sftpHandler) PosixRename(r *sftp.Request) error {
+	return h.Filecmd(r)
+}
+
+func setstat(r *sftp.Request) error {
+	af := r.AttrFlags()
+	attrs := r.Attributes()
+	if af.Size {
+		if err := os.Truncate(r.Filepath, int64(attrs.Size)); err != nil {
+			return err
+		}
+	}
+	if af.Permissions {
+		if err := os.Chmod(r.Filepath, attrs.FileMode()); err != nil {
+			return err
+		}
+	}
+	if af.UidGid {
+		if err := os.Chown(r.Filepath, int(attrs.UID), int(attrs.GID)); err != nil {
+			return err
+		}
+	}
+	if af.Acmodtime {
+		if err := os.Chtimes(r.Filepath, time.Unix(int64(attrs.Atime), 0), time.Unix(int64(attrs.Mtime), 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *sftpHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	p := h.policy
+	if err := h.check(p, r.Filepath); err != nil {
+		return nil, err
+	}
+	switch r.Method {
+	case "List":
+		des, err := os.ReadDir(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		fis := make([]os.FileInfo, 0, len(des))
+		for _, de := range des {
+			if h.check(p, filepath.Join(r.Filepath, de.Name())) != nil {
+				continue
+			}
+			if fi, err := de.Info(); err == nil {
+				fis = append(fis, fi)
+			}
+		}
+		return listerAt(fis), nil
+	case "Stat":
+		fi, err := os.Stat(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{fi}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *sftpHandler) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	if err := h.check(h.policy, r.Filepath); err != nil {
+		return nil, err
+	}
+	fi, err := os.Lstat(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	return listerAt{fi}, nil
+}
+
+func (h *sftpHandler) Readlink(p string) (string, error) {
+	if err := h.check(h.policy, p); err != nil {
+		return "", err
+	}
+	return os.Readlink(p)
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(fis []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(fis, l[offset:])
+	if n < len(fis) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -2,15 +2,19 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/blang/semver"
 	"github.com/puzpuzpuz/xsync/v3"
+	"google.golang.org/protobuf/proto"
 	core "k8s.io/api/core/v1"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/agent"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
@@ -22,6 +26,7 @@ type State interface {
 	agent.AgentServer
 	tunnel.ClientStreamProvider
 	AddInterceptState(is InterceptState)
+	AddAppContainer(cn *agentconfig.Container, env map[string]string)
 	AgentState() restapi.AgentState
 	InterceptStates() []InterceptState
 	HandleIntercepts(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.ReviewInterceptRequest
@@ -61,6 +66,9 @@ type state struct {
 	mgrVer      semver.Version
 
 	interceptStates []InterceptState
+	appContainers   []*appContainer
+
+	mountServers mountServers
 	agent.UnimplementedAgentServer
 }
 
@@ -115,20 +123,132 @@ func (s *state) InterceptStates() []InterceptState {
 }
 
 func (s *state) HandleIntercepts(ctx context.Context, iis []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
-	var rs []*manager.ReviewInterceptRequest
+	// Ingests don't intercept any traffic, so they are reviewed separately.
+	var ingests []*manager.InterceptInfo
+	cepts := make([]*manager.InterceptInfo, 0, len(iis))
+	for _, ii := range iis {
+		if ii.Spec.Ingest {
+			ingests = append(ingests, ii)
+		} else {
+			cepts = append(cepts, ii)
+		}
+	}
+	rs := s.handleIngests(ctx, ingests)
+	var crs []*manager.ReviewInterceptRequest
+
+	// matched records the indexes of the ports of each intercept that matched a target, where the
+	// primary port has index zero.
+	matched := make(map[string]map[int]struct{}, len(cepts))
 	for _, ist := range s.interceptStates {
-		ms := make([]*manager.InterceptInfo, 0, len(iis))
-		for _, ii := range iis {
+		ms := make([]*manager.InterceptInfo, 0, len(cepts))
+		for _, ii := range cepts {
 			ic := ist.Target()
-			if ic.MatchForSpec(ii.Spec) {
+			if pii, pi := portIntercept(ii, ic); pii != nil {
 				dlog.Debugf(ctx, "intercept id %s svc=%q, svcPortId=%q matches target protocol=%s, agentPort=%d, containerPort=%d",
-					ii.Id, ii.Spec.ServiceName, ii.Spec.ServicePortIdentifier, ic.Protocol(), ic.AgentPort(), ic.ContainerPort())
-				ms = append(ms, ii)
+					ii.Id, pii.Spec.ServiceName, pii.Spec.ServicePortIdentifier, ic.Protocol(), ic.AgentPort(), ic.ContainerPort())
+				ms = append(ms, pii)
+				if matched[ii.Id] == nil {
+					matched[ii.Id] = make(map[int]struct{})
+				}
+				matched[ii.Id][pi] = struct{}{}
+			}
+		}
+		crs = append(crs, ist.HandleIntercepts(ctx, ms)...)
+	}
+	s.closeMountServers(ctx, iis)
+	return append(rs, s.mergeReviews(ctx, crs, cepts, matched)...)
+}
+
+// portIntercept returns the intercept if its primary port matches the given target. If one of its additional
+// ports matches, then a copy of the intercept is returned, with a spec that declares that port as its only
+// port. The index of the matching port is returned too, where the primary port has index zero. Nil is returned
+// when no port matches.
+func portIntercept(ii *manager.InterceptInfo, ic InterceptTarget) (*manager.InterceptInfo, int) {
+	if ic.MatchForSpec(ii.Spec) {
+		return ii, 0
+	}
+	for i, ap := range ii.Spec.AdditionalPorts {
+		spec := proto.Clone(ii.Spec).(*manager.InterceptSpec)
+		spec.ServiceName = ap.ServiceName
+		spec.ServicePortIdentifier = ap.ServicePortIdentifier
+		spec.ServicePortName = ap.ServicePortName
+		spec.ServicePort = ap.ServicePort
+		spec.Protocol = ap.Protocol
+		spec.TargetPort = ap.TargetPort
+		spec.ContainerName = ap.ContainerName
+		spec.AdditionalPorts = nil
+		if ic.MatchForSpec(spec) {
+			pii := proto.Clone(ii).(*manager.InterceptInfo)
+			pii.Spec = spec
+			return pii, i + 1
+		}
+	}
+	return nil, -1
+}
+
+// mergeReviews ensures that there's only one review for each intercept, because an intercept that covers several
+// ports is reviewed once for each port. A rejection of one port takes precedence over an approval of another, and
+// an intercept is rejected when one of its ports didn't match any target. An approved intercept that spans several
+// containers gets the environment and mounts of all of them.
+func (s *state) mergeReviews(
+	ctx context.Context,
+	rs []*manager.ReviewInterceptRequest,
+	cepts []*manager.InterceptInfo,
+	matched map[string]map[int]struct{},
+) []*manager.ReviewInterceptRequest {
+	merged := make([]*manager.ReviewInterceptRequest, 0, len(rs))
+	idx := make(map[string]int, len(rs))
+	for _, r := range rs {
+		if i, ok := idx[r.Id]; ok {
+			if merged[i].Disposition == manager.InterceptDispositionType_ACTIVE && r.Disposition != manager.InterceptDispositionType_ACTIVE {
+				merged[i] = r
 			}
+			continue
 		}
-		rs = append(rs, ist.HandleIntercepts(ctx, ms)...)
+		idx[r.Id] = len(merged)
+		merged = append(merged, r)
+	}
+	for _, ii := range cepts {
+		i, ok := idx[ii.Id]
+		if !ok || merged[i].Disposition != manager.InterceptDispositionType_ACTIVE {
+			continue
+		}
+		if pid := unmatchedPort(ii.Spec, matched[ii.Id]); pid != "" {
+			dlog.Errorf(ctx, "Setting intercept %q as AGENT_ERROR; port %s doesn't match any intercept target", ii.Id, pid)
+			merged[i] = &manager.ReviewInterceptRequest{
+				Id:                ii.Id,
+				Disposition:       manager.InterceptDispositionType_AGENT_ERROR,
+				Message:           fmt.Sprintf("Port %s is not interceptable by this agent", pid),
+				MechanismArgsDesc: merged[i].MechanismArgsDesc,
+			}
+			continue
+		}
+		if names := containerNames(ii.Spec); len(names) > 1 {
+			s.spanContainers(merged[i], names)
+		}
+	}
+	return merged
+}
+
+// unmatchedPort returns the service port identifier of the first port of the given spec that isn't among the
+// given matched port indexes, or an empty string when all ports matched.
+func unmatchedPort(spec *manager.InterceptSpec, matched map[int]struct{}) string {
+	if _, ok := matched[0]; !ok {
+		return portIdentifier(spec.ServicePortIdentifier, spec.ServicePort)
+	}
+	for i, ap := range spec.AdditionalPorts {
+		if _, ok := matched[i+1]; !ok {
+			return portIdentifier(ap.ServicePortIdentifier, ap.ServicePort)
+		}
+	}
+	return ""
+}
+
+func portIdentifier(id string, port int32) string {
+	if id == "" {
+		id = strconv.Itoa(int(port))
 	}
-	return rs
+	return id
 }
 
 func (s *simpleState) HandleIntercepts(ctx context.Context, iis []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
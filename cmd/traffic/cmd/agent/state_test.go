@@ -4,11 +4,14 @@ import (
 	"context"
 	"net"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	core "k8s.io/api/core/v1"
 
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
@@ -143,9 +146,233 @@ func TestState_HandleIntercepts(t *testing.T) {
 	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
 	a.Equal("Conflicts with the currently-served intercept \"intercept-01\"", reviews[0].Message)
 
+	// Handle retains the active intercept when its spec is updated
+
+	updated := proto.Clone(cepts[0]).(*rpc.InterceptInfo)
+	updated.Spec.TargetPort = 8081
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{updated, cepts[1]})
+	a.Len(reviews, 1)
+	a.Equal(cepts[1].Id, reviews[0].Id)
+	a.Equal(cepts[0].Id, f.InterceptId())
+
+	// Handle forwards to the app container while the active intercept is paused, but retains it as chosen
+
+	updated.Disposition = rpc.InterceptDispositionType_PAUSED
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{updated, cepts[1]})
+	a.Equal("", f.InterceptId())
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+	a.Equal("Conflicts with the currently-paused intercept \"intercept-01\"", reviews[0].Message)
+
+	// A restarted agent, or a new replica, attaches to the paused intercept without forwarding its traffic,
+	// and rejects waiting intercepts of other clients
+
+	rf, restarted := makeFS(t, ctx)
+	reviews = restarted.HandleIntercepts(ctx, []*rpc.InterceptInfo{updated, cepts[1]})
+	a.Equal("", rf.InterceptId())
+	a.Len(reviews, 1)
+	a.Equal(cepts[1].Id, reviews[0].Id)
+	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+	a.Equal("Conflicts with the currently-paused intercept \"intercept-01\"", reviews[0].Message)
+
+	// Handle resumes the intercept
+
+	updated.Disposition = rpc.InterceptDispositionType_ACTIVE
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{updated})
+	a.Len(reviews, 0)
+	a.Equal(cepts[0].Id, f.InterceptId())
+
 	// Handle resets state on an empty intercept list again
 
 	reviews = s.HandleIntercepts(ctx, nil)
 	a.Len(reviews, 0)
 	a.Equal("", f.InterceptId())
 }
+
+func TestState_HandleIngests(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	f, s := makeFS(t, ctx)
+	s.AddAppContainer(&agentconfig.Container{Name: "test-echo", MountPoint: "/tel_app_mounts/test-echo"}, map[string]string{"FOO": "bar"})
+
+	ingest := func(id, containerName string) *rpc.InterceptInfo {
+		return &rpc.InterceptInfo{
+			Spec: &rpc.InterceptSpec{
+				Name:          id,
+				Client:        "user@host1",
+				Agent:         "agentName",
+				Mechanism:     "tcp",
+				Namespace:     namespace,
+				Ingest:        true,
+				ContainerName: containerName,
+			},
+			Id:          id,
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		}
+	}
+
+	// Ingests are activated without affecting the forwarder, and they don't conflict with each other
+
+	reviews := s.HandleIntercepts(ctx, []*rpc.InterceptInfo{ingest("ingest-01", "test-echo"), ingest("ingest-02", "")})
+	a.Len(reviews, 2)
+	a.Equal("", f.InterceptId())
+	for _, r := range reviews {
+		a.Equal(rpc.InterceptDispositionType_ACTIVE, r.Disposition)
+		a.Equal("/tel_app_exports/test-echo", r.MountPoint)
+		a.Equal(map[string]string{"FOO": "bar"}, r.Environment)
+	}
+
+	// An ingest of a container that the agent doesn't know about is rejected
+
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{ingest("ingest-03", "sidecar")})
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+	a.Equal("No container named \"sidecar\"", reviews[0].Message)
+
+	// An ingest doesn't prevent an intercept from becoming active
+
+	cept := &rpc.InterceptInfo{
+		Spec: &rpc.InterceptSpec{
+			Name:                  "cept1Name",
+			Client:                "user@host1",
+			Agent:                 "agentName",
+			Mechanism:             "tcp",
+			Namespace:             namespace,
+			ServiceName:           serviceName,
+			ServicePortIdentifier: "http",
+			TargetPort:            8080,
+		},
+		Id:          "intercept-01",
+		Disposition: rpc.InterceptDispositionType_WAITING,
+	}
+	active := ingest("ingest-01", "test-echo")
+	active.Disposition = rpc.InterceptDispositionType_ACTIVE
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{active, cept})
+	a.Len(reviews, 1)
+	a.Equal(cept.Id, reviews[0].Id)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
+}
+
+func TestState_HandleMultiContainerIntercept(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	c, err := agent.LoadConfig(ctx)
+	require.NoError(t, err)
+	s := agent.NewSimpleState(c)
+
+	addContainer := func(name, envPrefix, portName string, port uint16) forwarder.Interceptor {
+		lAddr, err := net.ResolveTCPAddr("tcp", ":0")
+		require.NoError(t, err)
+		f := forwarder.NewInterceptor(lAddr, appHost, port)
+		addrCh := make(chan net.Addr, 1)
+		go func() {
+			if err := f.Serve(ctx, addrCh); err != nil {
+				dlog.Error(ctx, err)
+			}
+		}()
+		<-addrCh
+		cn := &agentconfig.Container{
+			Name:       name,
+			EnvPrefix:  envPrefix,
+			MountPoint: "/tel_app_mounts/" + name,
+			Intercepts: []*agentconfig.Intercept{{
+				ContainerPortName: portName,
+				ServiceName:       name,
+				ServicePortName:   portName,
+				ServicePort:       port,
+				Protocol:          core.ProtocolTCP,
+				ContainerPort:     port,
+			}},
+		}
+		env := map[string]string{"PORT": strconv.Itoa(int(port)), agentconfig.EnvInterceptContainer: name}
+		s.AddAppContainer(cn, env)
+		s.AddInterceptState(s.NewInterceptState(f, agent.NewInterceptTarget(cn.Intercepts), "/tel_app_exports/"+name, env))
+		return f
+	}
+	apiFwd := addContainer("api", "A_", "http", 8080)
+	workerFwd := addContainer("worker", "B_", "metrics", 9090)
+
+	cept := &rpc.InterceptInfo{
+		Spec: &rpc.InterceptSpec{
+			Name:                  "multi",
+			Client:                "user@host1",
+			Agent:                 "agentName",
+			Mechanism:             "tcp",
+			Namespace:             namespace,
+			ServiceName:           "api",
+			ServicePortIdentifier: "http",
+			TargetPort:            8080,
+			ContainerName:         "api",
+			AdditionalPorts: []*rpc.InterceptPort{{
+				ServiceName:           "worker",
+				ServicePortIdentifier: "metrics",
+				ServicePortName:       "metrics",
+				ServicePort:           9090,
+				Protocol:              "TCP",
+				TargetPort:            9091,
+				ContainerName:         "worker",
+			}},
+		},
+		Id:          "intercept-01",
+		Disposition: rpc.InterceptDispositionType_WAITING,
+	}
+
+	// The intercept is reviewed once, with the environment of both containers and the root of all exports
+
+	reviews := s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	require.Len(t, reviews, 1)
+	r := reviews[0]
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, r.Disposition)
+	a.Equal(agentconfig.ExportsMountPoint, r.MountPoint)
+	a.Equal(map[string]string{
+		"PORT":                     "8080",
+		"TELEPRESENCE_CONTAINER":   "api",
+		"A_PORT":                   "8080",
+		"A_TELEPRESENCE_CONTAINER": "api",
+		"B_PORT":                   "9090",
+		"B_TELEPRESENCE_CONTAINER": "worker",
+	}, r.Environment)
+
+	// Both ports are intercepted once the intercept is active
+
+	cept.Disposition = rpc.InterceptDispositionType_ACTIVE
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	a.Len(reviews, 0)
+	a.Equal(cept.Id, apiFwd.InterceptId())
+	a.Equal(cept.Id, workerFwd.InterceptId())
+
+	// An intercept that conflicts on one of the ports is rejected
+
+	other := proto.Clone(cept).(*rpc.InterceptInfo)
+	other.Id = "intercept-02"
+	other.Spec.AdditionalPorts = nil
+	other.Spec.ServiceName = "worker"
+	other.Spec.ServicePortIdentifier = "metrics"
+	other.Spec.ContainerName = "worker"
+	other.Disposition = rpc.InterceptDispositionType_WAITING
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept, other})
+	require.Len(t, reviews, 1)
+	a.Equal(other.Id, reviews[0].Id)
+	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+
+	// Handle resets state on an empty intercept list
+
+	reviews = s.HandleIntercepts(ctx, nil)
+	a.Len(reviews, 0)
+	a.Equal("", apiFwd.InterceptId())
+	a.Equal("", workerFwd.InterceptId())
+
+	// An intercept with a port that matches no target is rejected, although its primary port matches
+
+	unknown := proto.Clone(cept).(*rpc.InterceptInfo)
+	unknown.Id = "intercept-03"
+	unknown.Spec.AdditionalPorts[0].ServicePortIdentifier = "grpc"
+	unknown.Spec.AdditionalPorts[0].ServicePortName = "grpc"
+	unknown.Spec.AdditionalPorts[0].ServicePort = 9095
+	unknown.Disposition = rpc.InterceptDispositionType_WAITING
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{unknown})
+	require.Len(t, reviews, 1)
+	a.Equal(unknown.Id, reviews[0].Id)
+	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+	a.Contains(reviews[0].Message, "Port grpc")
+}
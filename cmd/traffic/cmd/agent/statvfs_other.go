@@ -0,0 +1,11 @@
+//go:build !linux
+
+package agent
+
+import (
+	"github.com/pkg/sftp"
+)
+
+func statVFS(string) (*sftp.StatVFS, error) {
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
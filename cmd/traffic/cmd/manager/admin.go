@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	authn "k8s.io/api/authentication/v1"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+)
+
+// authorizeAdmin verifies that the bearer token found in the "authorization" metadata of the incoming
+// context belongs to an identity that has the given access. The token must be bound to the
+// agentmap.AdminTokenAudience, so that credentials that are valid for the API server are never accepted.
+//
+// The token belongs to a service account that is shared by all operators or admins, so the Kubernetes identity
+// of the caller must also be present in the metadata, and it must have the given access too. That identity is
+// asserted by the caller, and can only be trusted as far as the token is, but it means that an identity that
+// is allowed to create tokens for the service account can't act on behalf of one that lacks the access. The
+// returned name, which identifies both the caller and the service account, is used when auditing the call.
+func authorizeAdmin(ctx context.Context, access managerutil.Access) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	if as := md.Get("authorization"); len(as) > 0 {
+		token, _ = strings.CutPrefix(as[0], "Bearer ")
+	}
+	sa, err := AuthorizeFunc(ctx, token, []string{agentmap.AdminTokenAudience}, access)
+	if err != nil {
+		return "", err
+	}
+	caller := &authn.UserInfo{Groups: md.Get(agentmap.CallerGroupsKey)}
+	if vs := md.Get(agentmap.CallerUserKey); len(vs) > 0 {
+		caller.Username = vs[0]
+	}
+	if vs := md.Get(agentmap.CallerUIDKey); len(vs) > 0 {
+		caller.UID = vs[0]
+	}
+	if caller.Username == "" {
+		return "", status.Error(codes.Unauthenticated, "the Kubernetes identity of the caller was not provided")
+	}
+	if err = AuthorizeUserFunc(ctx, caller, access); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (using %s)", caller.Username, sa), nil
+}
+
+func (s *service) GetAdminSessions(ctx context.Context, _ *empty.Empty) (*rpc.AdminSessionsResponse, error) {
+	if _, err := authorizeAdmin(ctx, managerutil.AccessOperator); err != nil {
+		return nil, err
+	}
+	clients := s.state.GetAllClients()
+	metrics := s.state.GetAllSessionConsumptionMetrics()
+	sessions := make([]*rpc.AdminSession, 0, len(clients))
+	for id, ci := range clients {
+		ci.ApiKey = ""
+		as := &rpc.AdminSession{SessionId: id, Client: ci}
+		if m, ok := metrics[id]; ok && m != nil {
+			as.ConnectDuration = durationpb.New(m.ConnectDuration())
+			as.LastUpdate = timestamppb.New(m.LastUpdate())
+			as.FromClientBytes = m.FromClientBytes.GetValue()
+			as.ToClientBytes = m.ToClientBytes.GetValue()
+		}
+		sessions = append(sessions, as)
+	}
+	slices.SortFunc(sessions, func(a, b *rpc.AdminSession) int {
+		if c := cmp.Compare(a.Client.Name, b.Client.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SessionId, b.SessionId)
+	})
+	return &rpc.AdminSessionsResponse{Sessions: sessions}, nil
+}
+
+func (s *service) GetAdminIntercepts(ctx context.Context, _ *empty.Empty) (*rpc.InterceptInfoSnapshot, error) {
+	if _, err := authorizeAdmin(ctx, managerutil.AccessOperator); err != nil {
+		return nil, err
+	}
+	return &rpc.InterceptInfoSnapshot{Intercepts: state.InterceptsSnapshot(s.state, nil)}, nil
+}
+
+func (s *service) GetAdminAgents(ctx context.Context, _ *empty.Empty) (*rpc.AgentInfoSnapshot, error) {
+	if _, err := authorizeAdmin(ctx, managerutil.AccessOperator); err != nil {
+		return nil, err
+	}
+	return &rpc.AgentInfoSnapshot{Agents: state.AgentsSnapshot(s.state, nil)}, nil
+}
+
+func (s *service) AdminRemoveIntercept(ctx context.Context, req *rpc.AdminRemoveRequest) (*empty.Empty, error) {
+	user, err := authorizeAdmin(ctx, managerutil.AccessAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, state.ForceRemoveIntercept(ctx, s.state, req.Id, user)
+}
+
+func (s *service) AdminRemoveSession(ctx context.Context, req *rpc.AdminRemoveRequest) (*empty.Empty, error) {
+	user, err := authorizeAdmin(ctx, managerutil.AccessAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, state.ForceRemoveSession(ctx, s.state, req.Id, user)
+}
@@ -0,0 +1,146 @@
+package manager_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+	authn "k8s.io/api/authentication/v1"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	testdata "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/test"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/version"
+)
+
+func TestAdmin(t *testing.T) {
+	dlog.SetFallbackLogger(dlog.WrapTB(t, false))
+	ctx := dlog.NewTestContext(t, true)
+
+	// "reader" may list things, "admin" may also remove them.
+	saved := manager.AuthorizeFunc
+	manager.AuthorizeFunc = func(_ context.Context, token string, audiences []string, access managerutil.Access) (string, error) {
+		switch {
+		case len(audiences) != 1 || audiences[0] != agentmap.AdminTokenAudience:
+			return "", status.Error(codes.Unauthenticated, "wrong audience")
+		case token == "admin", token == "reader" && access == managerutil.AccessOperator:
+			return token, nil
+		case token == "reader":
+			return "", status.Error(codes.PermissionDenied, "forbidden")
+		default:
+			return "", status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+	}
+	t.Cleanup(func() { manager.AuthorizeFunc = saved })
+
+	// The caller "alice" may list things, "bob" may also remove them, and "mallory" may do neither.
+	savedUser := manager.AuthorizeUserFunc
+	manager.AuthorizeUserFunc = func(_ context.Context, user *authn.UserInfo, access managerutil.Access) error {
+		if user.Username == "bob" || user.Username == "alice" && access == managerutil.AccessOperator {
+			return nil
+		}
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+	t.Cleanup(func() { manager.AuthorizeUserFunc = savedUser })
+
+	testClients := testdata.GetTestClients(t)
+	testAgents := testdata.GetTestAgents(t)
+	version.Version, version.Structured = version.Init("0.0.0-testing", "TELEPRESENCE_VERSION")
+
+	conn := getTestClientConn(ctx, t)
+	defer conn.Close()
+	client := rpc.NewManagerClient(conn)
+
+	aliceSess, err := client.ArriveAsClient(ctx, testClients["alice"])
+	require.NoError(t, err)
+	helloSess, err := client.ArriveAsAgent(ctx, testAgents["hello"])
+	require.NoError(t, err)
+	defer func() { _, _ = client.Depart(ctx, helloSess) }()
+
+	spec := &rpc.InterceptSpec{
+		Name:       "first",
+		Namespace:  "default",
+		Client:     testClients["alice"].Name,
+		Agent:      testAgents["hello"].Name,
+		Mechanism:  "tcp",
+		TargetHost: "asdf",
+		TargetPort: 9876,
+	}
+	ii, err := client.CreateIntercept(ctx, &rpc.CreateInterceptRequest{
+		Session:       aliceSess,
+		InterceptSpec: spec,
+		ApiKey:        "secret",
+	})
+	require.NoError(t, err)
+
+	withCaller := func(token, caller string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token, agentmap.CallerUserKey, caller)
+	}
+	withToken := func(token string) context.Context {
+		if token == "admin" {
+			return withCaller(token, "bob")
+		}
+		return withCaller(token, "alice")
+	}
+
+	_, err = client.GetAdminSessions(ctx, &empty.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// The caller's identity is required, and it must have the access too.
+	_, err = client.GetAdminSessions(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer reader"), &empty.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetAdminSessions(withCaller("reader", "mallory"), &empty.Empty{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.AdminRemoveIntercept(withCaller("admin", "alice"), &rpc.AdminRemoveRequest{Id: ii.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	sessions, err := client.GetAdminSessions(withToken("reader"), &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, sessions.Sessions, 1)
+	assert.Equal(t, aliceSess.SessionId, sessions.Sessions[0].SessionId)
+	assert.Equal(t, testClients["alice"].Name, sessions.Sessions[0].Client.Name)
+	assert.Empty(t, sessions.Sessions[0].Client.ApiKey)
+
+	intercepts, err := client.GetAdminIntercepts(withToken("reader"), &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, intercepts.Intercepts, 1)
+	assert.Equal(t, ii.Id, intercepts.Intercepts[0].Id)
+	assert.Empty(t, intercepts.Intercepts[0].ApiKey)
+
+	agents, err := client.GetAdminAgents(withToken("reader"), &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, agents.Agents, 1)
+	assert.Equal(t, testAgents["hello"].PodName, agents.Agents[0].PodName)
+
+	_, err = client.AdminRemoveIntercept(withToken("reader"), &rpc.AdminRemoveRequest{Id: ii.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.AdminRemoveIntercept(withToken("admin"), &rpc.AdminRemoveRequest{Id: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.AdminRemoveIntercept(withToken("admin"), &rpc.AdminRemoveRequest{Id: ii.Id})
+	require.NoError(t, err)
+	intercepts, err = client.GetAdminIntercepts(withToken("admin"), &empty.Empty{})
+	require.NoError(t, err)
+	assert.Empty(t, intercepts.Intercepts)
+
+	_, err = client.AdminRemoveSession(withToken("reader"), &rpc.AdminRemoveRequest{Id: aliceSess.SessionId})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.AdminRemoveSession(withToken("admin"), &rpc.AdminRemoveRequest{Id: aliceSess.SessionId})
+	require.NoError(t, err)
+	sessions, err = client.GetAdminSessions(withToken("admin"), &empty.Empty{})
+	require.NoError(t, err)
+	assert.Empty(t, sessions.Sessions)
+
+	_, err = client.AdminRemoveSession(withToken("admin"), &rpc.AdminRemoveRequest{Id: aliceSess.SessionId})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -2,9 +2,14 @@ package manager
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 )
@@ -78,5 +83,162 @@ func validateIntercept(spec *rpc.InterceptSpec) string {
 		return "mechanism must not be empty"
 	}
 
+	return validateFaults(spec.Faults)
+}
+
+// validateFault returns an error message if the given fault is invalid, or an empty string if it is valid.
+func validateFault(fi *rpc.FaultInjection) string {
+	switch fi.Type {
+	case rpc.FaultInjection_ABORT:
+		if fi.HttpStatus < 100 || fi.HttpStatus > 599 {
+			return fmt.Sprintf("%d is not a valid HTTP status", fi.HttpStatus)
+		}
+	case rpc.FaultInjection_DELAY:
+		if fi.Delay.AsDuration() <= 0 {
+			return "delay must be a positive duration"
+		}
+	case rpc.FaultInjection_RESET:
+	default:
+		return fmt.Sprintf("type %s is not valid, must be one of ABORT, DELAY, or RESET", fi.Type)
+	}
+	if fi.Percentage <= 0 || fi.Percentage > 100 {
+		return fmt.Sprintf("percentage %g must be greater than 0 and max 100", fi.Percentage)
+	}
+	return ""
+}
+
+// validateFaults returns an error message if any of the given faults is invalid, or an empty string if all of
+// them are valid.
+func validateFaults(faults []*rpc.FaultInjection) string {
+	for i, fi := range faults {
+		if msg := validateFault(fi); msg != "" {
+			return fmt.Sprintf("fault %d: %s", i+1, msg)
+		}
+	}
+	return ""
+}
+
+// limitInterceptTTL ensures that the ttl of the given spec doesn't exceed the given maximum. A spec without
+// a ttl gets the maximum. A zero maximum means that there is no maximum.
+func limitInterceptTTL(spec *rpc.InterceptSpec, maxTTL time.Duration) string {
+	ttl := spec.Ttl.AsDuration()
+	switch {
+	case ttl < 0:
+		return fmt.Sprintf("ttl %s must not be negative", ttl)
+	case maxTTL <= 0:
+	case ttl == 0:
+		spec.Ttl = durationpb.New(maxTTL)
+	case ttl > maxTTL:
+		return fmt.Sprintf("ttl %s exceeds the maximum of %s that is allowed for intercepts in namespace %s", ttl, maxTTL, spec.Namespace)
+	}
+	return ""
+}
+
+func validateInterceptUpdate(spec *rpc.InterceptSpec, update *rpc.InterceptSpecUpdate) string {
+	switch {
+	case update.TargetHost == nil && update.TargetPort == nil && update.MechanismArgs == nil && len(update.TargetPorts) == 0:
+		return "spec update must change the target host, the target ports, or the mechanism arguments"
+	case spec.Ingest:
+		return fmt.Sprintf("ingest %q has no target and no mechanism", spec.Name)
+	case update.TargetHost != nil && *update.TargetHost == "":
+		return "target host must not be empty"
+	case update.TargetPort != nil && (*update.TargetPort <= 0 || *update.TargetPort > math.MaxUint16):
+		return fmt.Sprintf("target port %d is out of range", *update.TargetPort)
+	case update.MechanismArgs != nil && len(update.MechanismArgs.Args) > 0 && spec.Mechanism == "tcp":
+		return "the tcp mechanism takes no arguments"
+	}
+	for id, tp := range update.TargetPorts {
+		switch pi := portIndex(spec, id); {
+		case pi < 0:
+			return fmt.Sprintf("intercept %q has no port %q", spec.Name, id)
+		case pi == 0 && update.TargetPort != nil:
+			return fmt.Sprintf("target port of port %q is given twice", id)
+		case tp <= 0 || tp > math.MaxUint16:
+			return fmt.Sprintf("target port %d is out of range", tp)
+		}
+	}
+	updated := proto.Clone(spec).(*rpc.InterceptSpec)
+	applyInterceptUpdate(updated, update)
+	tps := map[int32]struct{}{updated.TargetPort: {}}
+	for _, ap := range updated.AdditionalPorts {
+		if _, ok := tps[ap.TargetPort]; ok {
+			return fmt.Sprintf("target port %d is used by more than one port of intercept %q", ap.TargetPort, spec.Name)
+		}
+		tps[ap.TargetPort] = struct{}{}
+	}
 	return ""
 }
+
+// applyInterceptUpdate applies the given, validated, update to the given spec.
+func applyInterceptUpdate(spec *rpc.InterceptSpec, update *rpc.InterceptSpecUpdate) {
+	if update.TargetHost != nil {
+		spec.TargetHost = *update.TargetHost
+	}
+	if update.TargetPort != nil {
+		spec.TargetPort = *update.TargetPort
+	}
+	for id, tp := range update.TargetPorts {
+		switch pi := portIndex(spec, id); {
+		case pi == 0:
+			spec.TargetPort = tp
+		case pi > 0:
+			spec.AdditionalPorts[pi-1].TargetPort = tp
+		}
+	}
+	if update.MechanismArgs != nil {
+		spec.MechanismArgs = update.MechanismArgs.Args
+	}
+}
+
+// portIndex returns the index of the port of the given spec that the given service port identifier refers to,
+// where the primary port has index zero, or -1 when no port matches.
+func portIndex(spec *rpc.InterceptSpec, id string) int {
+	matches := func(spi, name string, port int32) bool {
+		return id == spi || id == name || id == strconv.Itoa(int(port))
+	}
+	if matches(spec.ServicePortIdentifier, spec.ServicePortName, spec.ServicePort) {
+		return 0
+	}
+	for i, ap := range spec.AdditionalPorts {
+		if matches(ap.ServicePortIdentifier, ap.ServicePortName, ap.ServicePort) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func validatePausedUpdate(intercept *rpc.InterceptInfo, paused bool) string {
+	if intercept.Spec.Ingest {
+		return fmt.Sprintf("ingest %q intercepts no traffic and cannot be paused or resumed", intercept.Spec.Name)
+	}
+	switch intercept.Disposition {
+	case rpc.InterceptDispositionType_ACTIVE, rpc.InterceptDispositionType_PAUSED:
+		return ""
+	}
+	action := "resumed"
+	if paused {
+		action = "paused"
+	}
+	return fmt.Sprintf("intercept %q is %s and cannot be %s", intercept.Spec.Name, intercept.Disposition, action)
+}
+
+// validateHandover returns the ID of the session of the connected client with the given name, or a message that
+// explains why the intercept cannot be handed over to that client.
+func validateHandover(client *rpc.ClientInfo, to string, clients map[string]*rpc.ClientInfo) (string, string) {
+	if to == client.Name {
+		return "", "an intercept cannot be handed over to its own client"
+	}
+	sessionID := ""
+	for id, other := range clients {
+		if other.Name == to {
+			if sessionID != "" {
+				return "", fmt.Sprintf("more than one client named %q is connected to the traffic-manager", to)
+			}
+			sessionID = id
+		}
+	}
+	if sessionID == "" {
+		return "", fmt.Sprintf("no client named %q is connected to the traffic-manager", to)
+	}
+	return sessionID, ""
+}
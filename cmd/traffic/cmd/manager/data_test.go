@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestValidateInterceptUpdate_targetPorts(t *testing.T) {
+	spec := &rpc.InterceptSpec{
+		Name:                  "echo",
+		Mechanism:             "tcp",
+		ServicePortIdentifier: "http",
+		ServicePortName:       "http",
+		ServicePort:           80,
+		TargetPort:            8080,
+		AdditionalPorts: []*rpc.InterceptPort{{
+			ServicePortIdentifier: "metrics",
+			ServicePortName:       "metrics",
+			ServicePort:           9090,
+			TargetPort:            9091,
+		}},
+	}
+	port := int32(8081)
+	tests := []struct {
+		name    string
+		update  *rpc.InterceptSpecUpdate
+		primary int32
+		metrics int32
+		wantErr string
+	}{
+		{
+			name:    "additional port by name",
+			update:  &rpc.InterceptSpecUpdate{TargetPorts: map[string]int32{"metrics": 9092}},
+			primary: 8080,
+			metrics: 9092,
+		},
+		{
+			name:    "both ports by number",
+			update:  &rpc.InterceptSpecUpdate{TargetPorts: map[string]int32{"80": 8081, "9090": 9092}},
+			primary: 8081,
+			metrics: 9092,
+		},
+		{
+			name:    "unknown port",
+			update:  &rpc.InterceptSpecUpdate{TargetPorts: map[string]int32{"grpc": 9092}},
+			wantErr: `has no port "grpc"`,
+		},
+		{
+			name:    "primary port given twice",
+			update:  &rpc.InterceptSpecUpdate{TargetPort: &port, TargetPorts: map[string]int32{"http": 8082}},
+			wantErr: "given twice",
+		},
+		{
+			name:    "same target for two ports",
+			update:  &rpc.InterceptSpecUpdate{TargetPorts: map[string]int32{"metrics": 8080}},
+			wantErr: "used by more than one port",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := validateInterceptUpdate(spec, tt.update)
+			if tt.wantErr != "" {
+				assert.Contains(t, msg, tt.wantErr)
+				return
+			}
+			assert.Empty(t, msg)
+			updated := proto.Clone(spec).(*rpc.InterceptSpec)
+			applyInterceptUpdate(updated, tt.update)
+			assert.Equal(t, tt.primary, updated.TargetPort)
+			assert.Equal(t, tt.metrics, updated.AdditionalPorts[0].TargetPort)
+		})
+	}
+}
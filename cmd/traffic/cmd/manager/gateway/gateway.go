@@ -0,0 +1,203 @@
+// Package gateway provides a REST/JSON gateway to a subset of the traffic-manager's Manager API. It is intended
+// for tools, such as portals and chat bots, that want to view and administer intercepts, agents, and clients
+// without generating gRPC clients.
+package gateway
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+)
+
+// Prefix is the path prefix of all gateway endpoints.
+const Prefix = "/api/v1"
+
+//go:embed openapi.json
+var openAPI []byte
+
+// AuthorizeFunc authenticates the given bearer token and verifies that its user has the given access. When
+// audiences are given, the token must be valid for one of them. The name of the user is returned.
+type AuthorizeFunc func(ctx context.Context, token string, audiences []string, access managerutil.Access) (string, error)
+
+// Client is the JSON representation of a connected client. The client's API key is never included.
+type Client struct {
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	InstallID string `json:"installId,omitempty"`
+	Product   string `json:"product,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+type gateway struct {
+	state     state.State
+	authorize AuthorizeFunc
+	audiences []string
+}
+
+type endpoint struct {
+	method string
+	access managerutil.Access
+	serve  func(r *http.Request, user string) (any, error)
+	gw     *gateway
+}
+
+// NewHandler returns the handler of the gateway. The given AuthorizeFunc is used for all endpoints except
+// the one serving the OpenAPI document. Read-only endpoints require managerutil.AccessOperator, and endpoints
+// that remove things require managerutil.AccessAdmin, just like the admin calls of the gRPC API. Tokens must be
+// valid for one of the given audiences, or for agentmap.AdminTokenAudience when no audiences are given, so that
+// tokens meant for the API server are never accepted.
+func NewHandler(st state.State, authorize AuthorizeFunc, audiences []string) http.Handler {
+	if len(audiences) == 0 {
+		audiences = []string{agentmap.AdminTokenAudience}
+	}
+	g := &gateway{state: st, authorize: authorize, audiences: audiences}
+	mux := http.NewServeMux()
+	mux.HandleFunc(Prefix+"/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(r.Context(), w, status.Errorf(codes.Unimplemented, "method %s is not allowed", r.Method))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPI)
+	})
+	mux.Handle(Prefix+"/agents", g.endpoint(http.MethodGet, managerutil.AccessOperator, g.agents))
+	mux.Handle(Prefix+"/intercepts", g.endpoint(http.MethodGet, managerutil.AccessOperator, g.intercepts))
+	mux.Handle(Prefix+"/intercepts/", g.endpoint(http.MethodDelete, managerutil.AccessAdmin, g.removeIntercept))
+	mux.Handle(Prefix+"/clients", g.endpoint(http.MethodGet, managerutil.AccessOperator, g.clients))
+	mux.Handle(Prefix+"/sessions/", g.endpoint(http.MethodDelete, managerutil.AccessAdmin, g.expireSession))
+	return mux
+}
+
+func (g *gateway) endpoint(method string, access managerutil.Access, serve func(*http.Request, string) (any, error)) *endpoint {
+	return &endpoint{method: method, access: access, serve: serve, gw: g}
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != e.method {
+		w.Header().Set("Allow", e.method)
+		writeError(ctx, w, status.Errorf(codes.Unimplemented, "method %s is not allowed", r.Method))
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		writeError(ctx, w, status.Error(codes.Unauthenticated, "no bearer token provided"))
+		return
+	}
+	user, err := e.gw.authorize(ctx, strings.TrimSpace(token), e.gw.audiences, e.access)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	result, err := e.serve(r, user)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var data []byte
+	if pm, ok := result.(proto.Message); ok {
+		data, err = protojson.Marshal(pm)
+	} else {
+		data, err = json.Marshal(result)
+	}
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// agents returns a snapshot of the agents, optionally limited to the namespaces given with the namespace
+// query parameter. The environments of the agents are never included, because they often contain secrets.
+func (g *gateway) agents(r *http.Request, _ string) (any, error) {
+	return &rpc.AgentInfoSnapshot{Agents: state.AgentsSnapshot(g.state, r.URL.Query()["namespace"])}, nil
+}
+
+// intercepts returns a snapshot of the intercepts, optionally limited to the namespaces given with the
+// namespace query parameter. The API keys and environments of the intercepts are never included.
+func (g *gateway) intercepts(r *http.Request, _ string) (any, error) {
+	return &rpc.InterceptInfoSnapshot{Intercepts: state.InterceptsSnapshot(g.state, r.URL.Query()["namespace"])}, nil
+}
+
+// clients returns the connected clients.
+func (g *gateway) clients(_ *http.Request, _ string) (any, error) {
+	ciMap := g.state.GetAllClients()
+	clients := make([]*Client, 0, len(ciMap))
+	for id, ci := range ciMap {
+		clients = append(clients, &Client{
+			SessionID: id,
+			Name:      ci.Name,
+			Namespace: ci.Namespace,
+			InstallID: ci.InstallId,
+			Product:   ci.Product,
+			Version:   ci.Version,
+		})
+	}
+	slices.SortFunc(clients, func(a, b *Client) int {
+		if c := cmp.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SessionID, b.SessionID)
+	})
+	return map[string][]*Client{"clients": clients}, nil
+}
+
+// removeIntercept removes the intercept with the ID that follows the "intercepts/" path element.
+func (g *gateway) removeIntercept(r *http.Request, user string) (any, error) {
+	id := strings.TrimPrefix(r.URL.Path, Prefix+"/intercepts/")
+	return nil, state.ForceRemoveIntercept(r.Context(), g.state, id, user)
+}
+
+// expireSession removes the session with the ID that follows the "sessions/" path element, along with all its
+// intercepts.
+func (g *gateway) expireSession(r *http.Request, user string) (any, error) {
+	id := strings.TrimPrefix(r.URL.Path, Prefix+"/sessions/")
+	return nil, state.ForceRemoveSession(r.Context(), g.state, id, user)
+}
+
+// writeError writes the given error as a JSON object with a code and a message. The HTTP status is derived
+// from the gRPC status code of the error.
+func writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	var hs int
+	switch st.Code() {
+	case codes.InvalidArgument:
+		hs = http.StatusBadRequest
+	case codes.Unauthenticated:
+		hs = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		hs = http.StatusForbidden
+	case codes.NotFound:
+		hs = http.StatusNotFound
+	case codes.Unimplemented:
+		hs = http.StatusMethodNotAllowed
+	default:
+		hs = http.StatusInternalServerError
+		dlog.Errorf(ctx, "REST gateway request failed: %v", err)
+	}
+	data, _ := json.Marshal(map[string]string{"code": st.Code().String(), "message": st.Message()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(hs)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,189 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+)
+
+// fakeAuthorize grants operator access to the token "reader" and all access to the token "admin". Both tokens
+// are bound to agentmap.AdminTokenAudience. The token "kube-admin" is bound to the API server only.
+func fakeAuthorize(_ context.Context, token string, audiences []string, access managerutil.Access) (string, error) {
+	tokenAudience := agentmap.AdminTokenAudience
+	if token == "kube-admin" {
+		tokenAudience = "https://kubernetes.default.svc"
+	}
+	if len(audiences) > 0 && !slices.Contains(audiences, tokenAudience) {
+		return "", status.Error(codes.Unauthenticated, "the bearer token has the wrong audience")
+	}
+	switch token {
+	case "kube-admin":
+		return "kube-admin", nil
+	case "admin":
+		return "admin", nil
+	case "reader":
+		if access == managerutil.AccessOperator {
+			return "reader", nil
+		}
+		return "", status.Error(codes.PermissionDenied, "no admin access")
+	default:
+		return "", status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+}
+
+func TestGateway(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{ManagerNamespace: "ambassador"})
+	st := state.NewState(ctx)
+	now := time.Now()
+	clientID := st.AddClient(&rpc.ClientInfo{Name: "alice@host", Namespace: "default", ApiKey: "secret"}, now)
+	st.AddAgent(&rpc.AgentInfo{
+		Name:        "echo",
+		Namespace:   "default",
+		PodName:     "echo-1",
+		PodIp:       "10.0.0.1",
+		Environment: map[string]string{"DB_PASSWORD": "secret"},
+	}, now)
+	st.AddAgent(&rpc.AgentInfo{Name: "hello", Namespace: "other", PodName: "hello-1", PodIp: "10.0.0.2"}, now)
+	_, ii, err := st.AddIntercept(ctx, clientID, "cluster", &rpc.CreateInterceptRequest{
+		InterceptSpec: &rpc.InterceptSpec{Name: "echo", Client: "alice@host", Agent: "echo", Namespace: "default"},
+		ApiKey:        "secret",
+	})
+	require.NoError(t, err)
+	st.UpdateIntercept(ii.Id, func(ii *rpc.InterceptInfo) {
+		ii.Environment = map[string]string{"DB_PASSWORD": "secret"}
+	})
+
+	srv := httptest.NewServer(NewHandler(st, fakeAuthorize, nil))
+	defer srv.Close()
+
+	do := func(method, path, token string) (int, []byte) {
+		t.Helper()
+		rq, err := http.NewRequestWithContext(ctx, method, srv.URL+Prefix+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			rq.Header.Set("Authorization", "Bearer "+token)
+		}
+		rs, err := http.DefaultClient.Do(rq)
+		require.NoError(t, err)
+		defer rs.Body.Close()
+		data, err := io.ReadAll(rs.Body)
+		require.NoError(t, err)
+		return rs.StatusCode, data
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		code, data := do(http.MethodGet, "/openapi.json", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, json.Valid(data))
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		code, data := do(http.MethodGet, "/agents", "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Contains(t, string(data), `"code":"Unauthenticated"`)
+		code, _ = do(http.MethodGet, "/agents", "bogus")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		code, data := do(http.MethodGet, "/agents", "kube-admin")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Contains(t, string(data), "wrong audience")
+
+		custom := httptest.NewServer(NewHandler(st, fakeAuthorize, []string{"https://kubernetes.default.svc"}))
+		defer custom.Close()
+		rq, err := http.NewRequestWithContext(ctx, http.MethodGet, custom.URL+Prefix+"/agents", nil)
+		require.NoError(t, err)
+		rq.Header.Set("Authorization", "Bearer kube-admin")
+		rs, err := http.DefaultClient.Do(rq)
+		require.NoError(t, err)
+		_ = rs.Body.Close()
+		assert.Equal(t, http.StatusOK, rs.StatusCode)
+	})
+
+	t.Run("agents", func(t *testing.T) {
+		code, data := do(http.MethodGet, "/agents?namespace=default", "reader")
+		require.Equal(t, http.StatusOK, code)
+		var snap rpc.AgentInfoSnapshot
+		require.NoError(t, protojson.Unmarshal(data, &snap))
+		require.Len(t, snap.Agents, 1)
+		assert.Equal(t, "echo", snap.Agents[0].Name)
+		assert.Empty(t, snap.Agents[0].Environment)
+		assert.NotContains(t, string(data), "DB_PASSWORD")
+
+		code, data = do(http.MethodGet, "/agents", "reader")
+		require.Equal(t, http.StatusOK, code)
+		require.NoError(t, protojson.Unmarshal(data, &snap))
+		assert.Len(t, snap.Agents, 2)
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		code, data := do(http.MethodGet, "/clients", "reader")
+		require.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, string(data), "secret")
+		var cs struct {
+			Clients []*Client `json:"clients"`
+		}
+		require.NoError(t, json.Unmarshal(data, &cs))
+		require.Len(t, cs.Clients, 1)
+		assert.Equal(t, clientID, cs.Clients[0].SessionID)
+		assert.Equal(t, "alice@host", cs.Clients[0].Name)
+	})
+
+	t.Run("intercepts", func(t *testing.T) {
+		code, data := do(http.MethodGet, "/intercepts?namespace=default", "reader")
+		require.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, string(data), "secret")
+		assert.NotContains(t, string(data), "DB_PASSWORD")
+		var snap rpc.InterceptInfoSnapshot
+		require.NoError(t, protojson.Unmarshal(data, &snap))
+		require.Len(t, snap.Intercepts, 1)
+		assert.Equal(t, ii.Id, snap.Intercepts[0].Id)
+
+		code, data = do(http.MethodGet, "/intercepts?namespace=other", "reader")
+		require.Equal(t, http.StatusOK, code)
+		require.NoError(t, protojson.Unmarshal(data, &snap))
+		assert.Empty(t, snap.Intercepts)
+	})
+
+	t.Run("remove intercept", func(t *testing.T) {
+		code, _ := do(http.MethodGet, "/intercepts/"+ii.Id, "admin")
+		assert.Equal(t, http.StatusMethodNotAllowed, code)
+		code, _ = do(http.MethodDelete, "/intercepts/"+ii.Id, "reader")
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = do(http.MethodDelete, "/intercepts/"+ii.Id, "admin")
+		assert.Equal(t, http.StatusNoContent, code)
+		_, ok := st.GetIntercept(ii.Id)
+		assert.False(t, ok)
+		code, _ = do(http.MethodDelete, "/intercepts/"+ii.Id, "admin")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("expire session", func(t *testing.T) {
+		code, _ := do(http.MethodDelete, "/sessions/"+clientID, "reader")
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = do(http.MethodDelete, "/sessions/"+clientID, "admin")
+		assert.Equal(t, http.StatusNoContent, code)
+		assert.Nil(t, st.GetClient(clientID))
+		code, _ = do(http.MethodDelete, "/sessions/"+clientID, "admin")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	core "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -23,8 +25,10 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/gateway"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/version"
@@ -34,6 +38,8 @@ var (
 	DisplayName                   = "OSS Traffic Manager"               //nolint:gochecknoglobals // extension point
 	NewServiceFunc                = NewService                          //nolint:gochecknoglobals // extension point
 	WithAgentImageRetrieverFunc   = managerutil.WithAgentImageRetriever //nolint:gochecknoglobals // extension point
+	AuthorizeFunc                 = managerutil.Authorize               //nolint:gochecknoglobals // extension point
+	AuthorizeUserFunc             = managerutil.AuthorizeUser           //nolint:gochecknoglobals // extension point
 	IncrementInterceptCounterFunc = func(metric *prometheus.CounterVec, client, installId string, spec *rpc.InterceptSpec) {
 		if metric != nil {
 			labels := prometheus.Labels{
@@ -101,6 +107,8 @@ func MainWithEnv(ctx context.Context) error {
 
 	g.Go("prometheus", mgr.servePrometheus)
 
+	g.Go("gateway", mgr.serveGateway)
+
 	g.Go("agent-injector", func(ctx context.Context) error {
 		if managerutil.GetAgentImageRetriever(ctx) == nil {
 			return nil
@@ -152,6 +160,16 @@ func newGaugeVecFunc(n, h string, labels []string) *prometheus.GaugeVec {
 	return gaugeVec
 }
 
+func newHistogramVecFunc(n, h string, buckets []float64, labels []string) *prometheus.HistogramVec {
+	histogramVec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    n,
+		Help:    h,
+		Buckets: buckets,
+	}, labels)
+	prometheus.MustRegister(histogramVec)
+	return histogramVec
+}
+
 func IncrementCounter(metric *prometheus.CounterVec, client, installId string) {
 	if metric != nil {
 		metric.With(prometheus.Labels{"client": client, "install_id": installId}).Inc()
@@ -205,6 +223,28 @@ func (s *service) servePrometheus(ctx context.Context) error {
 			"Flag to indicate when an intercept is active. 1 for active, 0 for not active.", append(labels, "workload")),
 	)
 
+	tunnelLabels := state.TunnelMetricsLabels
+	s.state.SetMetrics(&state.Metrics{
+		Tunnels:            newCounterVecFunc("tunnel_total", "The total number of tunnels by client and agent", tunnelLabels),
+		ActiveTunnels:      newGaugeVecFunc("tunnel_active_count", "Number of active tunnels by client and agent", tunnelLabels),
+		TunnelIngressBytes: newCounterVecFunc("tunnel_client_ingress_bytes", "Number of bytes tunneled from clients by client and agent", tunnelLabels),
+		TunnelEgressBytes:  newCounterVecFunc("tunnel_client_egress_bytes", "Number of bytes tunneled to clients by client and agent", tunnelLabels),
+		TunnelIngressWireBytes: newCounterVecFunc("tunnel_client_ingress_wire_bytes",
+			"Number of bytes that the bytes tunneled from clients occupied on the wire after compression", tunnelLabels),
+		TunnelEgressWireBytes: newCounterVecFunc("tunnel_client_egress_wire_bytes",
+			"Number of bytes that the bytes tunneled to clients occupied on the wire after compression", tunnelLabels),
+		DNSLookupDuration: newHistogramVecFunc("dns_lookup_duration_seconds",
+			"Time for the traffic agents to respond to a DNS lookup", prometheus.DefBuckets, []string{"type", "result"}),
+		AgentArrivalDuration: newHistogramVecFunc("agent_arrival_duration_seconds",
+			"Time that an intercept waits for its traffic agent to arrive", prometheus.ExponentialBuckets(0.5, 2, 10), []string{"namespace", "result"}),
+		ExpiredSessions: newCounterVecFunc("session_expired_count", "The total number of sessions that expired", []string{"session_type"}),
+	})
+	mutator.SetMetrics(&mutator.Metrics{
+		RequestDuration: newHistogramVecFunc("agent_injector_request_duration_seconds",
+			"Time to serve an agent injector webhook request", prometheus.DefBuckets, []string{"path", "code"}),
+		Errors: newCounterVecFunc("agent_injector_error_count", "The total number of failed agent injector webhook requests", []string{"path", "reason"}),
+	})
+
 	s.state.SetAllClientSessionsFinalizer(func(client *rpc.ClientInfo) {
 		SetGauge(s.state.GetConnectActiveStatus(), client.Name, client.InstallId, nil, 0)
 	})
@@ -221,6 +261,28 @@ func (s *service) servePrometheus(ctx context.Context) error {
 	return sc.ListenAndServe(ctx, fmt.Sprintf("%s:%d", env.ServerHost, env.PrometheusPort))
 }
 
+// serveGateway serves the REST/JSON gateway if env.GatewayPort != 0. The gateway receives bearer tokens, so it
+// uses TLS when env.GatewayTLSDir contains a certificate and a key. Without TLS, it only listens on the loopback
+// interface, where it can be reached using "kubectl port-forward".
+func (s *service) serveGateway(ctx context.Context) error {
+	env := managerutil.GetEnv(ctx)
+	if env.GatewayPort == 0 {
+		dlog.Info(ctx, "REST gateway not started")
+		return nil
+	}
+	sc := &dhttp.ServerConfig{
+		Handler: gateway.NewHandler(s.state, AuthorizeFunc, env.GatewayAudiences),
+	}
+	defer dlog.Info(ctx, "REST gateway stopped")
+	if env.GatewayTLSDir != "" {
+		dlog.Infof(ctx, "REST gateway started on port: %d, using TLS", env.GatewayPort)
+		return sc.ListenAndServeTLS(ctx, fmt.Sprintf("%s:%d", env.ServerHost, env.GatewayPort),
+			filepath.Join(env.GatewayTLSDir, core.TLSCertKey), filepath.Join(env.GatewayTLSDir, core.TLSPrivateKeyKey))
+	}
+	dlog.Infof(ctx, "REST gateway started on port: %d, listening on localhost only because no TLS certificate was given", env.GatewayPort)
+	return sc.ListenAndServe(ctx, fmt.Sprintf("127.0.0.1:%d", env.GatewayPort))
+}
+
 func (s *service) serveHTTP(ctx context.Context) error {
 	env := managerutil.GetEnv(ctx)
 	host := env.ServerHost
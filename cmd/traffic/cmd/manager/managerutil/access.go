@@ -0,0 +1,113 @@
+package managerutil
+
+import (
+	"context"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+)
+
+// Access is the level of access that a Kubernetes identity has to the traffic-manager.
+type Access int
+
+// Access levels are verified using custom verbs on the traffic-manager deployment. No Kubernetes API uses
+// these verbs, so granting them gives no other access to the cluster.
+const (
+	// AccessOperator is the access needed to list the sessions, intercepts, and agents of all clients,
+	// i.e. the verb "operate".
+	AccessOperator Access = iota
+
+	// AccessAdmin is the access needed to remove intercepts and sessions of any client, i.e. the verb
+	// "administer".
+	AccessAdmin
+)
+
+func (a Access) String() string {
+	if a == AccessAdmin {
+		return "admin"
+	}
+	return "operator"
+}
+
+// resourceAttributes returns the attributes of the SubjectAccessReview that verifies the access.
+func (a Access) resourceAttributes(namespace string) *authz.ResourceAttributes {
+	verb := "operate"
+	if a == AccessAdmin {
+		verb = "administer"
+	}
+	return &authz.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      verb,
+		Group:     "apps",
+		Resource:  "deployments",
+		Name:      agentmap.ManagerAppName,
+	}
+}
+
+// Authorize authenticates the given Kubernetes bearer token using a TokenReview and then verifies that the
+// authenticated user has the given access using a SubjectAccessReview. When audiences are given, the token
+// must be valid for at least one of them. The name of the authenticated user is returned. The error is a gRPC
+// status error with code Unauthenticated or PermissionDenied when the token cannot be authenticated or when
+// the access is denied.
+func Authorize(ctx context.Context, token string, audiences []string, access Access) (string, error) {
+	if token == "" {
+		return "", status.Error(codes.Unauthenticated, "no bearer token provided")
+	}
+	ki := k8sapi.GetK8sInterface(ctx)
+	tr, err := ki.AuthenticationV1().TokenReviews().Create(ctx, &authn.TokenReview{
+		Spec: authn.TokenReviewSpec{Token: token, Audiences: audiences},
+	}, meta.CreateOptions{})
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "unable to review token: %v", err)
+	}
+	if !tr.Status.Authenticated {
+		msg := tr.Status.Error
+		if msg == "" {
+			msg = "invalid bearer token"
+		}
+		return "", status.Error(codes.Unauthenticated, msg)
+	}
+	if len(audiences) > 0 && !slices.ContainsFunc(tr.Status.Audiences, func(a string) bool { return slices.Contains(audiences, a) }) {
+		return "", status.Error(codes.Unauthenticated, "the bearer token has the wrong audience")
+	}
+
+	user := &tr.Status.User
+	if err = AuthorizeUser(ctx, user, access); err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+// AuthorizeUser verifies that the given user has the given access using a SubjectAccessReview. The error is a
+// gRPC status error with code PermissionDenied when the access is denied.
+func AuthorizeUser(ctx context.Context, user *authn.UserInfo, access Access) error {
+	extra := make(map[string]authz.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authz.ExtraValue(v)
+	}
+	sar, err := k8sapi.GetK8sInterface(ctx).AuthorizationV1().SubjectAccessReviews().Create(ctx, &authz.SubjectAccessReview{
+		Spec: authz.SubjectAccessReviewSpec{
+			ResourceAttributes: access.resourceAttributes(GetEnv(ctx).ManagerNamespace),
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	}, meta.CreateOptions{})
+	if err != nil {
+		return status.Errorf(codes.Internal, "unable to review access: %v", err)
+	}
+	if !sar.Status.Allowed {
+		dlog.Debugf(ctx, "%s access denied for %s: %s", access, user.Username, sar.Status.Reason)
+		return status.Errorf(codes.PermissionDenied, "user %q has no %s access to the traffic-manager", user.Username, access)
+	}
+	return nil
+}
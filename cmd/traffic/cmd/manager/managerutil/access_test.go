@@ -0,0 +1,80 @@
+package managerutil_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func TestAuthorize(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	cs.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+		switch tr.Spec.Token {
+		case "alice-token":
+			tr.Status = authn.TokenReviewStatus{Authenticated: true, User: authn.UserInfo{Username: "alice"}}
+		case "bob-token":
+			tr.Status = authn.TokenReviewStatus{Authenticated: true, User: authn.UserInfo{Username: "bob", Groups: []string{"admins"}}}
+		case "bound-token":
+			tr.Status = authn.TokenReviewStatus{
+				Authenticated: true,
+				User:          authn.UserInfo{Username: "system:serviceaccount:ambassador:traffic-manager-operator"},
+				Audiences:     []string{"telepresence-traffic-manager"},
+			}
+		}
+		return true, tr, nil
+	})
+	var reviewed *authz.ResourceAttributes
+	cs.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		reviewed = sar.Spec.ResourceAttributes
+		sar.Status.Allowed = reviewed.Verb == "operate" || len(sar.Spec.Groups) > 0 && sar.Spec.Groups[0] == "admins"
+		return true, sar, nil
+	})
+
+	ctx := dlog.NewTestContext(t, false)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{ManagerNamespace: "ambassador"})
+	ctx = k8sapi.WithK8sInterface(ctx, cs)
+
+	user, err := managerutil.Authorize(ctx, "alice-token", nil, managerutil.AccessOperator)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, "ambassador", reviewed.Namespace)
+	assert.Equal(t, "deployments", reviewed.Resource)
+	assert.Equal(t, "traffic-manager", reviewed.Name)
+	assert.Equal(t, "operate", reviewed.Verb)
+
+	_, err = managerutil.Authorize(ctx, "alice-token", nil, managerutil.AccessAdmin)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "administer", reviewed.Verb)
+
+	user, err = managerutil.Authorize(ctx, "bob-token", nil, managerutil.AccessAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user)
+
+	audiences := []string{"telepresence-traffic-manager"}
+	user, err = managerutil.Authorize(ctx, "bound-token", audiences, managerutil.AccessOperator)
+	require.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:ambassador:traffic-manager-operator", user)
+
+	_, err = managerutil.Authorize(ctx, "alice-token", audiences, managerutil.AccessOperator)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = managerutil.Authorize(ctx, "bogus", nil, managerutil.AccessOperator)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = managerutil.Authorize(ctx, "", nil, managerutil.AccessOperator)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
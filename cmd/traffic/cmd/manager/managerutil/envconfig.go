@@ -3,6 +3,7 @@ package managerutil
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"reflect"
 	"strconv"
@@ -34,12 +35,18 @@ type Env struct {
 	ServerHost          string        `env:"SERVER_HOST,              parser=string,      default="`
 	ServerPort          uint16        `env:"SERVER_PORT,              parser=port-number"`
 	PrometheusPort      uint16        `env:"PROMETHEUS_PORT,          parser=port-number, default=0"`
+	GatewayPort         uint16        `env:"GATEWAY_PORT,             parser=port-number, default=0"`
+	GatewayTLSDir       string        `env:"GATEWAY_TLS_DIR,          parser=string,      default="`
+	GatewayAudiences    []string      `env:"GATEWAY_TOKEN_AUDIENCES,  parser=split-trim,  default="`
 	MutatorWebhookPort  uint16        `env:"MUTATOR_WEBHOOK_PORT,     parser=port-number, default=0"`
 	ManagerNamespace    string        `env:"MANAGER_NAMESPACE,        parser=string,      default="`
 	ManagedNamespaces   []string      `env:"MANAGED_NAMESPACES,       parser=split-trim,  default="`
 	APIPort             uint16        `env:"AGENT_REST_API_PORT,      parser=port-number, default=0"`
 	AgentArrivalTimeout time.Duration `env:"AGENT_ARRIVAL_TIMEOUT,    parser=time.ParseDuration"`
 
+	InterceptMaxTTL          time.Duration            `env:"INTERCEPT_MAX_TTL,           parser=time.ParseDuration, default=0"`
+	InterceptNamespaceMaxTTL map[string]time.Duration `env:"INTERCEPT_NAMESPACE_MAX_TTL, parser=split-durations,    default="`
+
 	TracingGrpcPort uint16            `env:"TRACING_GRPC_PORT,     parser=port-number,default=0"`
 	MaxReceiveSize  resource.Quantity `env:"GRPC_MAX_RECEIVE_SIZE, parser=quantity"`
 
@@ -55,6 +62,7 @@ type Env struct {
 	AgentAppProtocolStrategy k8sapi.AppProtocolStrategy  `env:"AGENT_APP_PROTO_STRATEGY, parser=app-proto-strategy"`
 	AgentLogLevel            string                      `env:"AGENT_LOG_LEVEL,          parser=logLevel,       defaultFrom=LogLevel"`
 	AgentPort                uint16                      `env:"AGENT_PORT,               parser=port-number"`
+	AgentMetricsPort         uint16                      `env:"AGENT_METRICS_PORT,       parser=port-number,    default=0"`
 	AgentResources           *core.ResourceRequirements  `env:"AGENT_RESOURCES,          parser=json-resources, default="`
 	AgentInitResources       *core.ResourceRequirements  `env:"AGENT_INIT_RESOURCES,     parser=json-resources, default="`
 	AgentInjectorName        string                      `env:"AGENT_INJECTOR_NAME,      parser=string"`
@@ -73,6 +81,7 @@ func (e *Env) GeneratorConfig(qualifiedAgentImage string) (agentmap.GeneratorCon
 		AgentPort:           e.AgentPort,
 		APIPort:             e.APIPort,
 		TracingPort:         e.TracingGrpcPort,
+		MetricsPort:         e.AgentMetricsPort,
 		ManagerPort:         e.ServerPort,
 		QualifiedAgentImage: qualifiedAgentImage,
 		ManagerNamespace:    e.ManagerNamespace,
@@ -84,6 +93,15 @@ func (e *Env) GeneratorConfig(qualifiedAgentImage string) (agentmap.GeneratorCon
 	}, nil
 }
 
+// InterceptMaxTTLFor returns the maximum time to live for intercepts in the given namespace. Zero means that
+// there is no maximum.
+func (e *Env) InterceptMaxTTLFor(namespace string) time.Duration {
+	if ttl, ok := e.InterceptNamespaceMaxTTL[namespace]; ok {
+		return ttl
+	}
+	return e.InterceptMaxTTL
+}
+
 func (e *Env) QualifiedAgentImage() string {
 	img := e.AgentImage
 	if img == "" {
@@ -174,6 +192,30 @@ func fieldTypeHandlers() map[reflect.Type]envconfig.FieldTypeHandler {
 		},
 		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.([]*net.IPNet))) },
 	}
+	fhs[reflect.TypeOf(map[string]time.Duration{})] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"split-durations": func(str string) (any, error) {
+				ss := strings.Fields(str)
+				if len(ss) == 0 {
+					return nil, nil
+				}
+				ds := make(map[string]time.Duration, len(ss))
+				for _, s := range ss {
+					k, v, ok := strings.Cut(s, "=")
+					if !ok {
+						return nil, fmt.Errorf("%q is not in the form <name>=<duration>", s)
+					}
+					d, err := time.ParseDuration(v)
+					if err != nil {
+						return nil, err
+					}
+					ds[k] = d
+				}
+				return ds, nil
+			},
+		},
+		Setter: func(dst reflect.Value, src interface{}) { dst.Set(reflect.ValueOf(src.(map[string]time.Duration))) },
+	}
 	fhs[reflect.TypeOf([]core.LocalObjectReference{})] = envconfig.FieldTypeHandler{
 		Parsers: map[string]func(string) (any, error){
 			"json-local-refs": func(js string) (any, error) {
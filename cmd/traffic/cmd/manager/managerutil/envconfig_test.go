@@ -81,6 +81,24 @@ func TestEnvconfig(t *testing.T) {
 				e.ClientRoutingNeverProxySubnets = []*net.IPNet{a, b}
 			},
 		},
+		"agent metrics": {
+			Input: map[string]string{
+				"AGENT_METRICS_PORT": "9102",
+			},
+			Output: func(e *managerutil.Env) {
+				e.AgentMetricsPort = 9102
+			},
+		},
+		"intercept max ttl": {
+			Input: map[string]string{
+				"INTERCEPT_MAX_TTL":           "2h",
+				"INTERCEPT_NAMESPACE_MAX_TTL": "staging=30m dev=0s ",
+			},
+			Output: func(e *managerutil.Env) {
+				e.InterceptMaxTTL = 2 * time.Hour
+				e.InterceptNamespaceMaxTTL = map[string]time.Duration{"staging": 30 * time.Minute, "dev": 0}
+			},
+		},
 	}
 
 	for tcName, tc := range testcases {
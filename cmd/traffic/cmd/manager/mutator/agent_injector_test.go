@@ -214,6 +214,37 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 		},
 	}
 
+	podPortless := core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:            podName("portless"),
+			Namespace:       "some-ns",
+			Annotations:     map[string]string{InjectAnnotation: "enabled"},
+			Labels:          map[string]string{"service": "named-port"},
+			OwnerReferences: podOwner("portless"),
+		},
+		Spec: core.PodSpec{
+			Containers: []core.Container{
+				{
+					Name: "log-shipper",
+					VolumeMounts: []core.VolumeMount{
+						{
+							Name:      "logs",
+							MountPath: "/var/log/app",
+						},
+					},
+				},
+				{
+					Name: "some-container",
+					Ports: []core.ContainerPort{
+						{
+							Name: "http", ContainerPort: 8888,
+						},
+					},
+				},
+			},
+		},
+	}
+
 	podMultiPort := core.Pod{
 		ObjectMeta: meta.ObjectMeta{
 			Name:            podName("multi-port"),
@@ -323,6 +354,51 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 	unnamedNumericPortUID := makeUID()
 	multiPortUID := makeUID()
 
+	podNoService := core.Pod{
+		ObjectMeta: podObjectMeta("no-service", "app"),
+		Spec: core.PodSpec{
+			Containers: []core.Container{
+				{
+					Name: "some-container",
+					Ports: []core.ContainerPort{
+						{
+							Name: "metrics", ContainerPort: 9090,
+						},
+						{
+							ContainerPort: 8080, Protocol: core.ProtocolUDP,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	podAnnotatedContainerPort := core.Pod{
+		ObjectMeta: podObjectMeta("named-port", "service"),
+		Spec: core.PodSpec{
+			Containers: []core.Container{
+				{
+					Name: "some-container",
+					Ports: []core.ContainerPort{
+						{
+							Name: "http", ContainerPort: 8888,
+						},
+						{
+							Name: "metrics", ContainerPort: 9090,
+						},
+						{
+							Name: "debug", ContainerPort: 5005,
+						},
+					},
+				},
+			},
+		},
+	}
+	podAnnotatedContainerPort.Annotations = map[string]string{
+		InjectAnnotation:                  "enabled",
+		agentmap.ContainerPortsAnnotation: "metrics",
+	}
+
 	clientset := fake.NewSimpleClientset(
 		&core.Service{
 			TypeMeta: meta.TypeMeta{
@@ -429,8 +505,10 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 		deployment(&podNumericPort),
 		deployment(&podUnnamedNumericPort),
 		deployment(&podNamedAndNumericPort),
+		deployment(&podPortless),
 		deployment(&podMultiPort),
 		deployment(&podMultiSplitPort),
+		deployment(&podNoService),
 	)
 	tests := []struct {
 		name           string
@@ -621,6 +699,45 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"Container without ports",
+			&podPortless,
+			&agentconfig.Sidecar{
+				AgentName:    "portless",
+				AgentImage:   "docker.io/datawire/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "portless",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8888,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+					{
+						Name:       "log-shipper",
+						EnvPrefix:  "B_",
+						MountPoint: "/tel_app_mounts/log-shipper",
+						Mounts:     []string{"/var/log/app"},
+					},
+				},
+			},
+			"",
+		},
 		{
 			"Multi-port container and service",
 			&podMultiPort,
@@ -718,6 +835,105 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"No service",
+			&podNoService,
+			&agentconfig.Sidecar{
+				AgentName:    "no-service",
+				AgentImage:   "docker.io/datawire/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "no-service",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "metrics",
+								ServicePortName:   "metrics",
+								ServicePort:       9090,
+								TargetPortNumeric: true,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     9090,
+							},
+							{
+								ServicePort:       8080,
+								TargetPortNumeric: true,
+								Protocol:          core.ProtocolUDP,
+								AgentPort:         9901,
+								ContainerPort:     8080,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Annotated container port",
+			&podAnnotatedContainerPort,
+			&agentconfig.Sidecar{
+				AgentName:    "named-port",
+				AgentImage:   "docker.io/datawire/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "named-port",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8888,
+							},
+							{
+								ContainerPortName: "metrics",
+								ServicePortName:   "metrics",
+								ServicePort:       9090,
+								TargetPortNumeric: true,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9901,
+								ContainerPort:     9090,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Error Precondition: Invalid container ports annotation",
+			&core.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      podName("no-service"),
+					Namespace: "some-ns",
+					Annotations: map[string]string{
+						InjectAnnotation:                  "enabled",
+						agentmap.ContainerPortsAnnotation: "not_a_port",
+					},
+					Labels:          map[string]string{"app": "no-service"},
+					OwnerReferences: podOwner("no-service"),
+				},
+				Spec: podNoService.Spec,
+			},
+			nil,
+			"invalid " + agentmap.ContainerPortsAnnotation + " annotation",
+		},
 	}
 	for _, test := range tests {
 		test := test // pin it
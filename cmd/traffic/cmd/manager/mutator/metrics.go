@@ -0,0 +1,37 @@
+package mutator
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are the Prometheus series of the agent-injector webhook. A nil vector is never updated.
+type Metrics struct {
+	// RequestDuration observes the time it takes to serve a webhook request. Labels: path, code.
+	RequestDuration *prometheus.HistogramVec
+
+	// Errors counts the webhook requests that failed. Labels: path, reason.
+	Errors *prometheus.CounterVec
+}
+
+var metrics atomic.Pointer[Metrics] //nolint:gochecknoglobals // set once when the Prometheus server starts
+
+// SetMetrics sets the Prometheus series that the agent-injector webhook updates.
+func SetMetrics(m *Metrics) {
+	metrics.Store(m)
+}
+
+func observeRequest(path string, code int, start time.Time) {
+	if m := metrics.Load(); m != nil && m.RequestDuration != nil {
+		m.RequestDuration.With(prometheus.Labels{"path": path, "code": strconv.Itoa(code)}).Observe(time.Since(start).Seconds())
+	}
+}
+
+func countError(path, reason string) {
+	if m := metrics.Load(); m != nil && m.Errors != nil {
+		m.Errors.With(prometheus.Labels{"path": path, "reason": reason}).Inc()
+	}
+}
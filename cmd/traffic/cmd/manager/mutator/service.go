@@ -119,9 +119,12 @@ func ServeMutator(ctx context.Context) error {
 	mux.HandleFunc("/traffic-agent", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		dlog.Debug(ctx, "Received webhook request...")
+		start := time.Now()
 		bytes, statusCode, err := serveMutatingFunc(ctx, r, ai.Inject)
+		observeRequest(r.URL.Path, statusCode, start)
 		if err != nil {
 			dlog.Errorf(ctx, "error handling webhook request: %v", err)
+			countError(r.URL.Path, "request")
 			w.WriteHeader(statusCode)
 			bytes = []byte(err.Error())
 		} else {
@@ -294,6 +297,7 @@ func serveMutatingFunc(ctx context.Context, r *http.Request, mf mutatorFunc) ([]
 		// If the handler returned an error, still allow the object creation, and incorporate
 		// the error message into the response
 		dlog.Errorf(ctx, "mutating function error: %v", err)
+		countError(r.URL.Path, "injection")
 		response.Allowed = false
 		response.Result = &meta.Status{
 			Message: err.Error(),
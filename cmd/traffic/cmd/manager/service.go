@@ -52,6 +52,7 @@ type Service interface {
 	runSessionGCLoop(context.Context) error
 	serveHTTP(context.Context) error
 	servePrometheus(context.Context) error
+	serveGateway(context.Context) error
 }
 
 type service struct {
@@ -484,6 +485,7 @@ func (s *service) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_W
 				switch info.Disposition {
 				case rpc.InterceptDispositionType_WAITING,
 					rpc.InterceptDispositionType_ACTIVE,
+					rpc.InterceptDispositionType_PAUSED,
 					rpc.InterceptDispositionType_AGENT_ERROR:
 					// agent-owned state: include the intercept
 					dlog.Debugf(ctx, "Intercept %s.%s valid. Disposition: %s", info.Spec.Agent, info.Spec.Namespace, info.Disposition)
@@ -564,6 +566,9 @@ func (s *service) CreateIntercept(ctx context.Context, ciReq *rpc.CreateIntercep
 	if val := validateIntercept(spec); val != "" {
 		return nil, status.Errorf(codes.InvalidArgument, val)
 	}
+	if val := limitInterceptTTL(spec, managerutil.GetEnv(ctx).InterceptMaxTTLFor(spec.Namespace)); val != "" {
+		return nil, status.Error(codes.InvalidArgument, val)
+	}
 
 	if ciReq.InterceptSpec.Replace {
 		_, err := s.state.PrepareIntercept(ctx, ciReq, agentconfig.ReplacePolicyActive)
@@ -614,12 +619,124 @@ func (s *service) MakeInterceptID(_ context.Context, sessionID string, name stri
 		if s.state.GetClient(sessionID) == nil {
 			return "", status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
 		}
-		return sessionID + ":" + name, nil
+		if id, ok := s.state.ClientInterceptID(sessionID, name); ok {
+			return id, nil
+		}
+		return "", status.Errorf(codes.NotFound, "Intercept named %q not found", name)
 	}
 }
 
-func (s *service) UpdateIntercept(context.Context, *rpc.UpdateInterceptRequest) (*rpc.InterceptInfo, error) { //nolint:gocognit
-	return nil, status.Error(codes.Unimplemented, "")
+// UpdateIntercept lets a client change the target and mechanism arguments of an existing intercept, or pause and
+// resume it. A spec update retains the intercept's disposition, so connections that are in progress when the update
+// happens aren't dropped. A paused intercept retains its environment and mounts, but its agent forwards the traffic
+// to the application container until the intercept is resumed. An intercept that is offered to another client remains
+// with its current client until the other client accepts the handover.
+func (s *service) UpdateIntercept(ctx context.Context, uiReq *rpc.UpdateInterceptRequest) (*rpc.InterceptInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, uiReq.GetSession())
+	sessionID := uiReq.GetSession().GetSessionId()
+	name := uiReq.Name
+	dlog.Debugf(ctx, "UpdateIntercept called: %s", name)
+
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	if uiReq.PreviewDomainAction != nil {
+		return nil, status.Error(codes.Unimplemented, "preview domains are not supported by this traffic-manager")
+	}
+
+	interceptID, ok := s.state.ClientInterceptID(sessionID, name)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Intercept named %q not found", name)
+	}
+	intercept, ok := s.state.GetIntercept(interceptID)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Intercept named %q not found", name)
+	}
+	update := uiReq.SpecUpdate
+	paused := uiReq.Paused
+	handoverTo := uiReq.HandoverTo
+	if update == nil && paused == nil && handoverTo == nil {
+		return nil, status.Error(codes.InvalidArgument, "update must change the intercept spec, pause or resume the intercept, or hand it over")
+	}
+	if update != nil {
+		if val := validateInterceptUpdate(intercept.Spec, update); val != "" {
+			return nil, status.Error(codes.InvalidArgument, val)
+		}
+	}
+	if paused != nil {
+		if val := validatePausedUpdate(intercept, *paused); val != "" {
+			return nil, status.Error(codes.FailedPrecondition, val)
+		}
+	}
+	handoverSessionID := ""
+	if handoverTo != nil && *handoverTo != "" {
+		var val string
+		if handoverSessionID, val = validateHandover(client, *handoverTo, s.state.GetAllClients()); val != "" {
+			return nil, status.Error(codes.FailedPrecondition, val)
+		}
+	}
+
+	intercept = s.state.UpdateIntercept(interceptID, func(intercept *rpc.InterceptInfo) {
+		if update != nil {
+			applyInterceptUpdate(intercept.Spec, update)
+		}
+		if paused != nil {
+			switch {
+			case *paused && intercept.Disposition == rpc.InterceptDispositionType_ACTIVE:
+				intercept.Disposition = rpc.InterceptDispositionType_PAUSED
+			case !*paused && intercept.Disposition == rpc.InterceptDispositionType_PAUSED:
+				intercept.Disposition = rpc.InterceptDispositionType_ACTIVE
+			}
+		}
+		if handoverTo != nil {
+			intercept.HandoverTo = *handoverTo
+			intercept.HandoverSessionId = handoverSessionID
+		}
+	})
+	if intercept == nil {
+		return nil, status.Errorf(codes.NotFound, "Intercept named %q not found", name)
+	}
+	tracing.RecordInterceptInfo(trace.SpanFromContext(ctx), intercept)
+	return intercept, nil
+}
+
+// AcceptInterceptHandover lets a client take over an intercept that another client has handed over to it.
+func (s *service) AcceptInterceptHandover(ctx context.Context, ahReq *rpc.AcceptInterceptHandoverRequest) (*rpc.InterceptInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, ahReq.GetSession())
+	sessionID := ahReq.GetSession().GetSessionId()
+	name := ahReq.Name
+	dlog.Debugf(ctx, "AcceptInterceptHandover called: %s", name)
+
+	var apply func(*rpc.InterceptSpec)
+	if update := ahReq.SpecUpdate; update != nil {
+		if update.MechanismArgs != nil {
+			return nil, status.Error(codes.InvalidArgument, "mechanism arguments cannot be changed when a handover is accepted")
+		}
+		if len(update.TargetPorts) > 0 {
+			return nil, status.Error(codes.InvalidArgument, "individual ports cannot be retargeted when a handover is accepted")
+		}
+		if val := validateInterceptUpdate(&rpc.InterceptSpec{}, update); val != "" {
+			return nil, status.Error(codes.InvalidArgument, val)
+		}
+		apply = func(spec *rpc.InterceptSpec) {
+			if update.TargetHost != nil {
+				spec.TargetHost = *update.TargetHost
+			}
+			if update.TargetPort != nil {
+				spec.TargetPort = *update.TargetPort
+			}
+		}
+	}
+	prev, intercept, err := s.state.AcceptInterceptHandover(sessionID, name, ahReq.From, apply)
+	if err != nil {
+		return nil, err
+	}
+	dlog.Infof(ctx, "Intercept %s handed over from %s to %s", intercept.Id, prev.Spec.Client, intercept.Spec.Client)
+	SetGauge(s.state.GetInterceptActiveStatus(), prev.Spec.Client, prev.ClientSession.GetInstallId(), &name, 0)
+	SetGauge(s.state.GetInterceptActiveStatus(), intercept.Spec.Client, intercept.ClientSession.GetInstallId(), &name, 1)
+	tracing.RecordInterceptInfo(trace.SpanFromContext(ctx), intercept)
+	return intercept, nil
 }
 
 // RemoveIntercept lets a client remove an intercept.
@@ -637,7 +754,9 @@ func (s *service) RemoveIntercept(ctx context.Context, riReq *rpc.RemoveIntercep
 
 	SetGauge(s.state.GetInterceptActiveStatus(), client.Name, client.InstallId, &name, 0)
 
-	s.state.RemoveIntercept(ctx, sessionID+":"+name)
+	if interceptID, ok := s.state.ClientInterceptID(sessionID, name); ok {
+		s.state.RemoveIntercept(ctx, interceptID)
+	}
 	return &empty.Empty{}, nil
 }
 
@@ -714,12 +833,7 @@ func (s *service) removeExcludedEnvVars(ctx context.Context, envVars map[string]
 }
 
 func (s *service) Tunnel(server rpc.Manager_TunnelServer) error {
-	ctx := server.Context()
-	stream, err := tunnel.NewServerStream(ctx, server)
-	if err != nil {
-		return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
-	}
-	return s.state.Tunnel(ctx, stream)
+	return tunnel.AcceptStreams(server.Context(), server, s.state.Tunnel)
 }
 
 func (s *service) WatchDial(session *rpc.SessionInfo, stream rpc.Manager_WatchDialServer) error {
@@ -835,8 +949,11 @@ func (s *service) WatchClusterInfo(session *rpc.SessionInfo, stream rpc.Manager_
 
 const agentSessionTTL = 15 * time.Second
 
-// expire removes stale sessions.
+// expire removes stale sessions and expired intercepts.
 func (s *service) expire(ctx context.Context) {
 	now := s.clock.Now()
 	s.state.ExpireSessions(ctx, now.Add(-managerutil.GetEnv(ctx).ClientConnectionTTL), now.Add(-agentSessionTTL))
+	for _, ii := range s.state.ExpireIntercepts(ctx, now) {
+		SetGauge(s.state.GetInterceptActiveStatus(), ii.Spec.Client, ii.ClientSession.GetInstallId(), &ii.Spec.Name, 0)
+	}
 }
@@ -8,7 +8,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/proto"
 	empty "google.golang.org/protobuf/types/known/emptypb"
@@ -247,6 +249,72 @@ func TestConnect(t *testing.T) {
 	a.Nil(second)
 	t.Logf("=> intercept info: %s", dumps(second))
 
+	// Alice changes the target port of the intercept
+
+	targetPort := int32(9877)
+	updated, err := client.UpdateIntercept(ctx, &rpc.UpdateInterceptRequest{
+		Session:    aliceSess2,
+		Name:       spec.Name,
+		SpecUpdate: &rpc.InterceptSpecUpdate{TargetPort: &targetPort},
+	})
+	a.NoError(err)
+	a.Equal(targetPort, updated.Spec.TargetPort)
+	a.Equal("asdf", updated.Spec.TargetHost)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, updated.Disposition)
+
+	aSnapI, err = aliceWI.Recv()
+	a.NoError(err)
+	a.Len(aSnapI.Intercepts, 1)
+	a.Equal(targetPort, aSnapI.Intercepts[0].Spec.TargetPort)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, aSnapI.Intercepts[0].Disposition)
+
+	hSnapI, err = helloWI.Recv()
+	a.NoError(err)
+	a.Len(hSnapI.Intercepts, 1)
+	a.Equal(targetPort, hSnapI.Intercepts[0].Spec.TargetPort)
+
+	// Alice pauses the intercept and resumes it again
+
+	for _, paused := range []bool{true, false} {
+		expected := rpc.InterceptDispositionType_ACTIVE
+		if paused {
+			expected = rpc.InterceptDispositionType_PAUSED
+		}
+		updated, err = client.UpdateIntercept(ctx, &rpc.UpdateInterceptRequest{
+			Session: aliceSess2,
+			Name:    spec.Name,
+			Paused:  &paused,
+		})
+		a.NoError(err)
+		a.Equal(expected, updated.Disposition)
+
+		aSnapI, err = aliceWI.Recv()
+		a.NoError(err)
+		a.Len(aSnapI.Intercepts, 1)
+		a.Equal(expected, aSnapI.Intercepts[0].Disposition)
+
+		hSnapI, err = helloWI.Recv()
+		a.NoError(err)
+		a.Len(hSnapI.Intercepts, 1, "the agent must retain a paused intercept")
+		a.Equal(expected, hSnapI.Intercepts[0].Disposition)
+	}
+
+	// Invalid updates yield errors
+
+	_, err = client.UpdateIntercept(ctx, &rpc.UpdateInterceptRequest{
+		Session:    aliceSess2,
+		Name:       spec.Name,
+		SpecUpdate: &rpc.InterceptSpecUpdate{MechanismArgs: &rpc.MechanismArgs{Args: []string{"--http-header=x=y"}}},
+	})
+	a.Equal(codes.InvalidArgument, status.Code(err))
+
+	_, err = client.UpdateIntercept(ctx, &rpc.UpdateInterceptRequest{
+		Session:    aliceSess2,
+		Name:       "unknown",
+		SpecUpdate: &rpc.InterceptSpecUpdate{TargetPort: &targetPort},
+	})
+	a.Equal(codes.NotFound, status.Code(err))
+
 	// Alice removes the intercept
 
 	_, err = client.RemoveIntercept(ctx, &rpc.RemoveInterceptRequest2{
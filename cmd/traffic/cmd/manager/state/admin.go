@@ -0,0 +1,83 @@
+package state
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// The functions in this file serve the privileged views and actions that the traffic-manager offers to
+// operators, both through its admin gRPC calls and through its REST gateway.
+
+// AgentsSnapshot returns the agents in the given namespaces, or in all namespaces when none are given, sorted
+// by namespace, name, and pod name. The environments of the agents are omitted, because they often contain
+// secrets.
+func AgentsSnapshot(s State, namespaces []string) []*rpc.AgentInfo {
+	var agents []*rpc.AgentInfo
+	for _, a := range s.GetAllAgents() {
+		if len(namespaces) == 0 || slices.Contains(namespaces, a.Namespace) {
+			a.Environment = nil
+			agents = append(agents, a)
+		}
+	}
+	slices.SortFunc(agents, func(a, b *rpc.AgentInfo) int {
+		if c := cmp.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.PodName, b.PodName)
+	})
+	return agents
+}
+
+// InterceptsSnapshot returns the intercepts in the given namespaces, or in all namespaces when none are given,
+// sorted by namespace and ID. The API keys and environments of the intercepts are omitted.
+func InterceptsSnapshot(s State, namespaces []string) []*rpc.InterceptInfo {
+	ciMap := s.LoadMatchingIntercepts(func(_ string, ii *rpc.InterceptInfo) bool {
+		return len(namespaces) == 0 || slices.Contains(namespaces, ii.Spec.Namespace)
+	})
+	cepts := make([]*rpc.InterceptInfo, 0, len(ciMap))
+	for _, ii := range ciMap {
+		ii.ApiKey = ""
+		ii.Environment = nil
+		cepts = append(cepts, ii)
+	}
+	slices.SortFunc(cepts, func(a, b *rpc.InterceptInfo) int {
+		if c := cmp.Compare(a.Spec.Namespace, b.Spec.Namespace); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return cepts
+}
+
+// ForceRemoveIntercept removes the given intercept on behalf of the given user, regardless of what client owns
+// it. A NotFound status error is returned when the intercept doesn't exist.
+func ForceRemoveIntercept(ctx context.Context, s State, interceptID, user string) error {
+	if _, ok := s.GetIntercept(interceptID); !ok {
+		return status.Errorf(codes.NotFound, "intercept %q not found", interceptID)
+	}
+	dlog.Infof(ctx, "Intercept %s removed by %s", interceptID, user)
+	s.RemoveIntercept(ctx, interceptID)
+	return nil
+}
+
+// ForceRemoveSession removes the given session and all its intercepts on behalf of the given user. A client
+// that is still running will notice that its session is gone and arrive again with a new session. A NotFound
+// status error is returned when the session doesn't exist.
+func ForceRemoveSession(ctx context.Context, s State, sessionID, user string) error {
+	if s.GetSession(sessionID) == nil {
+		return status.Errorf(codes.NotFound, "session %q not found", sessionID)
+	}
+	dlog.Infof(ctx, "Session %s removed by %s", sessionID, user)
+	s.RemoveSession(ctx, sessionID)
+	return nil
+}
@@ -71,6 +71,8 @@ func (s *state) AddSessionConsumptionMetrics(metrics *manager.TunnelMetrics) {
 		cm := cs.consumptionMetrics
 		cm.FromClientBytes.Increment(metrics.IngressBytes)
 		cm.ToClientBytes.Increment(metrics.EgressBytes)
+		cm.FromClientBytes.IncrementCompressed(metrics.IngressWireBytes)
+		cm.ToClientBytes.IncrementCompressed(metrics.EgressWireBytes)
 	}
 }
 
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
@@ -20,7 +22,15 @@ const RcodeNoAgents = 3841
 
 // AgentsLookupDNS will send the given request to all agents currently intercepted by the client identified with
 // the clientSessionID, it will then wait for results to arrive, collect those results, and return the result.
-func (s *state) AgentsLookupDNS(ctx context.Context, clientSessionID string, request *rpc.DNSRequest) (dnsproxy.RRs, int, error) {
+func (s *state) AgentsLookupDNS(ctx context.Context, clientSessionID string, request *rpc.DNSRequest) (rrs dnsproxy.RRs, rCode int, err error) {
+	if m := s.GetMetrics(); m != nil {
+		defer func(start time.Time) {
+			observeDuration(m.DNSLookupDuration, prometheus.Labels{
+				"type":   dnsTypeLabel(request),
+				"result": dnsLookupResult(rCode, err),
+			}, start)
+		}(time.Now())
+	}
 	rs := s.agentsLookup(ctx, clientSessionID, request)
 	if len(rs) == 0 {
 		return nil, RcodeNoAgents, nil
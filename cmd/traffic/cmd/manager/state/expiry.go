@@ -0,0 +1,46 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// maxExpiryWarning is the longest time before its expiry that an intercept is flagged as expiring.
+const maxExpiryWarning = 5 * time.Minute
+
+// expiryWarning returns how long before its expiry that an intercept with the given ttl is flagged
+// as expiring.
+func expiryWarning(ttl time.Duration) time.Duration {
+	if w := ttl / 2; w < maxExpiryWarning {
+		return w
+	}
+	return maxExpiryWarning
+}
+
+// ExpireIntercepts removes the intercepts that have expired at the given moment, and flags the
+// intercepts that are about to expire, so that their clients can warn their users. The removed
+// intercepts are returned.
+func (s *state) ExpireIntercepts(ctx context.Context, now time.Time) []*rpc.InterceptInfo {
+	expiring := s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.ExpiresAt != nil && ii.Disposition != rpc.InterceptDispositionType_REMOVED
+	})
+	var expired []*rpc.InterceptInfo
+	for id, ii := range expiring {
+		expiresAt := ii.ExpiresAt.AsTime()
+		switch {
+		case !now.Before(expiresAt):
+			dlog.Infof(ctx, "Intercept %s expired after %s", id, ii.Spec.Ttl.AsDuration())
+			s.self.RemoveIntercept(ctx, id)
+			expired = append(expired, ii)
+		case !ii.Expiring && expiresAt.Sub(now) <= expiryWarning(ii.Spec.Ttl.AsDuration()):
+			dlog.Debugf(ctx, "Intercept %s expires at %s", id, expiresAt.Format(time.RFC3339))
+			s.UpdateIntercept(id, func(ii *rpc.InterceptInfo) {
+				ii.Expiring = true
+			})
+		}
+	}
+	return expired
+}
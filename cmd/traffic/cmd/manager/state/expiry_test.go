@@ -0,0 +1,78 @@
+package state
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	testdata "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/test"
+)
+
+func (s *suiteState) TestExpireIntercepts() {
+	// given
+	now := time.Now()
+	alice := testdata.GetTestClients(s.T())["alice"]
+	aliceID := s.state.AddClient(alice, now)
+	addIntercept := func(name string, ttl time.Duration) string {
+		id := aliceID + ":" + name
+		ii := &manager.InterceptInfo{
+			Id:            id,
+			Spec:          &manager.InterceptSpec{Name: name, Client: alice.Name, Namespace: "default"},
+			ClientSession: &manager.SessionInfo{SessionId: aliceID},
+			Disposition:   manager.InterceptDispositionType_ACTIVE,
+		}
+		if ttl > 0 {
+			ii.Spec.Ttl = durationpb.New(ttl)
+			ii.ExpiresAt = timestamppb.New(now.Add(ttl))
+		}
+		s.state.intercepts.Store(id, ii)
+		return id
+	}
+	forever := addIntercept("forever", 0)
+	short := addIntercept("short", 4*time.Minute)
+	long := addIntercept("long", time.Hour)
+
+	// when nothing is close to expiry
+	expired := s.state.ExpireIntercepts(s.ctx, now)
+
+	// then
+	assert.Empty(s.T(), expired)
+	for _, id := range []string{forever, short, long} {
+		ii, ok := s.state.GetIntercept(id)
+		require.True(s.T(), ok)
+		assert.False(s.T(), ii.Expiring)
+	}
+
+	// when the short intercept is halfway through its ttl
+	expired = s.state.ExpireIntercepts(s.ctx, now.Add(2*time.Minute))
+
+	// then
+	assert.Empty(s.T(), expired)
+	ii, _ := s.state.GetIntercept(short)
+	assert.True(s.T(), ii.Expiring)
+	ii, _ = s.state.GetIntercept(long)
+	assert.False(s.T(), ii.Expiring)
+
+	// when the short intercept has expired
+	expired = s.state.ExpireIntercepts(s.ctx, now.Add(4*time.Minute))
+
+	// then
+	require.Len(s.T(), expired, 1)
+	assert.Equal(s.T(), short, expired[0].Id)
+	_, ok := s.state.GetIntercept(short)
+	assert.False(s.T(), ok)
+
+	// when the long intercept is within the warning period
+	expired = s.state.ExpireIntercepts(s.ctx, now.Add(time.Hour-maxExpiryWarning))
+
+	// then
+	assert.Empty(s.T(), expired)
+	ii, _ = s.state.GetIntercept(long)
+	assert.True(s.T(), ii.Expiring)
+	_, ok = s.state.GetIntercept(forever)
+	assert.True(s.T(), ok)
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// ClientInterceptID returns the ID of the intercept with the given name that is owned by the client with the
+// given session ID.
+func (s *state) ClientInterceptID(sessionID, name string) (string, bool) {
+	id := sessionID + ":" + name
+	if ii, ok := s.intercepts.Load(id); ok && ii.Disposition != rpc.InterceptDispositionType_REMOVED {
+		return id, true
+	}
+	return "", false
+}
+
+// AcceptInterceptHandover rebinds the intercept with the given name that has been offered to the session with the
+// given ID. The from argument is the name of the offering client, and can be empty unless more than one client has
+// offered an intercept with the given name. The given apply function, if not nil, is called with the spec of the
+// intercept so that its target can be changed. The intercept retains its disposition and agent, but gets a new ID
+// that is derived from the given session ID, so that the offering client can create a new intercept with the same
+// name. The intercept, as it was before it was rebound, is returned together with the rebound intercept.
+func (s *state) AcceptInterceptHandover(sessionID, name, from string, apply func(*rpc.InterceptSpec)) (*rpc.InterceptInfo, *rpc.InterceptInfo, error) {
+	// Lock, so that no intercept is added using the new ID while the intercept is rebound.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client := s.GetClient(sessionID)
+	if client == nil {
+		return nil, nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	newID, exists := s.ClientInterceptID(sessionID, name)
+	if exists {
+		return nil, nil, status.Errorf(codes.AlreadyExists, "Intercept named %q already exists", name)
+	}
+	newID = sessionID + ":" + name
+	offered := func(ii *rpc.InterceptInfo) bool {
+		return ii.Spec.Name == name && ii.HandoverSessionId == sessionID && (from == "" || ii.Spec.Client == from) &&
+			ii.Disposition != rpc.InterceptDispositionType_REMOVED
+	}
+	offers := s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool { return offered(ii) })
+	switch len(offers) {
+	case 0:
+		return nil, nil, status.Errorf(codes.NotFound, "No intercept named %q has been handed over to %s", name, client.Name)
+	case 1:
+	default:
+		return nil, nil, status.Errorf(codes.FailedPrecondition,
+			"More than one intercept named %q has been handed over to %s. Please specify the client that handed it over", name, client.Name)
+	}
+	var id string
+	var cur *rpc.InterceptInfo
+	for id, cur = range offers {
+		break
+	}
+	if client.Namespace != "" && cur.Spec.Namespace != client.Namespace {
+		return nil, nil, status.Errorf(codes.FailedPrecondition,
+			"Intercept %q is in namespace %s, but %s is connected to namespace %s", name, cur.Spec.Namespace, client.Name, client.Namespace)
+	}
+	installID := client.InstallId
+	for {
+		newInfo := proto.Clone(cur).(*rpc.InterceptInfo)
+		newInfo.Id = newID
+		newInfo.ClientSession = &rpc.SessionInfo{
+			SessionId: sessionID,
+			ClusterId: cur.ClientSession.ClusterId,
+			InstallId: &installID,
+		}
+		newInfo.Spec.Client = client.Name
+		newInfo.HandoverTo = ""
+		newInfo.HandoverSessionId = ""
+		if apply != nil {
+			apply(newInfo.Spec)
+		}
+		newInfo.ModifiedAt = timestamppb.Now()
+
+		// Claim the offered intercept, so that a concurrent withdrawal of the offer is detected, and then
+		// move it to its new ID along with its finalizers.
+		if s.intercepts.CompareAndSwap(id, cur, newInfo) {
+			s.intercepts.Store(newID, newInfo)
+			s.intercepts.Delete(id)
+			if is, ok := s.interceptStates.LoadAndDelete(id); ok {
+				is.Lock()
+				is.interceptID = newID
+				is.Unlock()
+				s.interceptStates.Store(newID, is)
+			}
+			return cur, newInfo, nil
+		}
+		var ok bool
+		if cur, ok = s.intercepts.Load(id); !ok || !offered(cur) {
+			return nil, nil, status.Errorf(codes.NotFound, "The handover of intercept %q to %s was withdrawn", name, client.Name)
+		}
+	}
+}
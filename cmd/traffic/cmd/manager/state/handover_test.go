@@ -0,0 +1,83 @@
+package state
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	testdata "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/test"
+)
+
+func (s *suiteState) TestAcceptInterceptHandover() {
+	// given
+	now := time.Now()
+	testClients := testdata.GetTestClients(s.T())
+	alice := testClients["alice"]
+	bob := testClients["bob"]
+	aliceID := s.state.AddClient(alice, now)
+	bobID := s.state.AddClient(bob, now)
+	interceptID := aliceID + ":echo"
+	s.state.intercepts.Store(interceptID, &manager.InterceptInfo{
+		Id:            interceptID,
+		Spec:          &manager.InterceptSpec{Name: "echo", Client: alice.Name, Namespace: "default", TargetHost: "127.0.0.1", TargetPort: 8080},
+		ClientSession: &manager.SessionInfo{SessionId: aliceID, ClusterId: "cluster"},
+		Disposition:   manager.InterceptDispositionType_ACTIVE,
+	})
+	retarget := func(spec *manager.InterceptSpec) { spec.TargetPort = 8081 }
+
+	// when the intercept hasn't been offered
+	_, _, err := s.state.AcceptInterceptHandover(bobID, "echo", "", retarget)
+
+	// then
+	assert.Equal(s.T(), codes.NotFound, status.Code(err))
+
+	// when the intercept has been offered
+	s.state.UpdateIntercept(interceptID, func(ii *manager.InterceptInfo) {
+		ii.HandoverTo = bob.Name
+		ii.HandoverSessionId = bobID
+	})
+
+	// when another session of a client with the same name accepts
+	impostorID := s.state.AddClient(bob, now)
+	_, _, err = s.state.AcceptInterceptHandover(impostorID, "echo", "", retarget)
+
+	// then
+	assert.Equal(s.T(), codes.NotFound, status.Code(err), "the offer is bound to the session of the client")
+
+	// when the offered session accepts
+	prev, ii, err := s.state.AcceptInterceptHandover(bobID, "echo", alice.Name, retarget)
+
+	// then
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), aliceID, prev.ClientSession.SessionId)
+	assert.Equal(s.T(), bobID+":echo", ii.Id, "the intercept must be rekeyed to the accepting session")
+	assert.Equal(s.T(), bobID, ii.ClientSession.SessionId)
+	assert.Equal(s.T(), "cluster", ii.ClientSession.ClusterId)
+	assert.Equal(s.T(), bob.Name, ii.Spec.Client)
+	assert.Equal(s.T(), int32(8081), ii.Spec.TargetPort)
+	assert.Empty(s.T(), ii.HandoverTo)
+	assert.Empty(s.T(), ii.HandoverSessionId)
+	assert.Equal(s.T(), manager.InterceptDispositionType_ACTIVE, ii.Disposition)
+
+	id, ok := s.state.ClientInterceptID(bobID, "echo")
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), ii.Id, id)
+	_, ok = s.state.ClientInterceptID(aliceID, "echo")
+	assert.False(s.T(), ok, "the intercept must no longer be found using the previous client's session")
+	_, ok = s.state.GetIntercept(interceptID)
+	assert.False(s.T(), ok, "the intercept must no longer be stored using its previous ID")
+	_, _, err = s.state.AddIntercept(s.ctx, aliceID, "cluster", &manager.CreateInterceptRequest{
+		InterceptSpec: &manager.InterceptSpec{Name: "echo", Client: alice.Name, Namespace: "default", Agent: "echo"},
+	})
+	assert.NoError(s.T(), err, "the previous client must be able to create a new intercept with the same name")
+
+	// when the handover is accepted again
+	_, _, err = s.state.AcceptInterceptHandover(bobID, "echo", "", nil)
+
+	// then
+	assert.Equal(s.T(), codes.AlreadyExists, status.Code(err))
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -85,7 +87,14 @@ func (s *state) PrepareIntercept(
 		return interceptError(err)
 	}
 	ac := sce.AgentConfig()
-	_, ic, err := findIntercept(ac, spec)
+	var cn *agentconfig.Container
+	var ic *agentconfig.Intercept
+	var aps []*managerrpc.InterceptPort
+	if spec.Ingest {
+		cn, err = findIngestContainer(wl.GetPodTemplate(), ac, spec)
+	} else if cn, ic, err = findIntercept(ac, spec); err == nil {
+		aps, err = findAdditionalPorts(ac, spec, ic)
+	}
 	if err != nil {
 		return interceptError(err)
 	}
@@ -97,6 +106,14 @@ func (s *state) PrepareIntercept(
 		}
 		return interceptError(err)
 	}
+	if spec.Ingest {
+		return &managerrpc.PreparedIntercept{
+			Namespace:     spec.Namespace,
+			AgentImage:    ac.AgentImage,
+			WorkloadKind:  ac.WorkloadKind,
+			ContainerName: cn.Name,
+		}, nil
+	}
 	return &managerrpc.PreparedIntercept{
 		Namespace:       spec.Namespace,
 		ServiceUid:      string(ic.ServiceUID),
@@ -105,6 +122,9 @@ func (s *state) PrepareIntercept(
 		ServicePort:     int32(ic.ServicePort),
 		AgentImage:      ac.AgentImage,
 		WorkloadKind:    ac.WorkloadKind,
+		ContainerName:   cn.Name,
+		AdditionalPorts: aps,
+		AgentPort:       int32(ic.AgentPort),
 	}, nil
 }
 
@@ -273,6 +293,10 @@ func (s *state) loadAgentConfig(
 			doUpdate = true
 		}
 		for _, cn := range ac.Containers {
+			// The replace policy only concerns containers with intercepts, and ingests never replace.
+			if len(cn.Intercepts) == 0 || spec.Ingest {
+				continue
+			}
 			if cn.Replace != replacePolicy {
 				span.AddEvent("container-replace-changed")
 				if (cn.Replace == agentconfig.ReplacePolicyActive && replacePolicy == agentconfig.ReplacePolicyInactive) ||
@@ -441,7 +465,16 @@ func watchFailedInjectionEvents(ctx context.Context, name, namespace string) (<-
 	return ec, nil
 }
 
-func (s *state) waitForAgent(ctx context.Context, name, namespace string, failedCreateCh <-chan *events.Event) error {
+func (s *state) waitForAgent(ctx context.Context, name, namespace string, failedCreateCh <-chan *events.Event) (err error) {
+	if m := s.GetMetrics(); m != nil {
+		defer func(start time.Time) {
+			result := "arrived"
+			if err != nil {
+				result = "failed"
+			}
+			observeDuration(m.AgentArrivalDuration, prometheus.Labels{"namespace": namespace, "result": result}, start)
+		}(time.Now())
+	}
 	snapshotCh := s.WatchAgents(ctx, nil)
 	failedContainerRx := regexp.MustCompile(`restarting failed container (\S+) in pod ([0-9A-Za-z_-]+)_` + namespace)
 	// fes collects events from the failedCreatedCh and is included in the error message in case
@@ -564,10 +597,40 @@ func unmarshalConfigMapEntry(y string, name, namespace string) (agentconfig.Side
 }
 
 // findIntercept finds the intercept configuration that matches the given InterceptSpec's service/service port.
+// Intercepts of container ports that have no service are only considered when the spec doesn't name a service
+// and no service intercept matches.
 func findIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (foundCN *agentconfig.Container, foundIC *agentconfig.Intercept, err error) {
+	foundCN, foundIC, err = findServiceIntercept(ac, spec)
+	if err == nil && foundIC == nil && spec.ServiceName == "" {
+		foundCN, foundIC, err = findContainerPortIntercept(ac, spec)
+	}
+	if err != nil || foundIC != nil {
+		return foundCN, foundIC, err
+	}
+
+	spi := agentconfig.PortIdentifier(spec.ServicePortIdentifier)
+	ss := ""
+	if spec.ServiceName != "" {
+		if spi != "" {
+			ss = fmt.Sprintf(" matching service %s, port %s", spec.ServiceName, spi)
+		} else {
+			ss = fmt.Sprintf(" matching service %s", spec.ServiceName)
+		}
+	} else if spi != "" {
+		ss = fmt.Sprintf(" matching port %s", spi)
+	}
+	return nil, nil, errcat.User.Newf("%s %s.%s has no interceptable port%s", ac.WorkloadKind, ac.WorkloadName, ac.Namespace, ss)
+}
+
+// findServiceIntercept finds the service intercept configuration that matches the given InterceptSpec's
+// service/service port. No error is returned when no intercept matches.
+func findServiceIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (foundCN *agentconfig.Container, foundIC *agentconfig.Intercept, err error) {
 	spi := agentconfig.PortIdentifier(spec.ServicePortIdentifier)
 	for _, cn := range ac.Containers {
 		for _, ic := range cn.Intercepts {
+			if ic.ServiceName == "" {
+				continue
+			}
 			if !(spec.ServiceName == "" || spec.ServiceName == ic.ServiceName) {
 				continue
 			}
@@ -601,21 +664,106 @@ func findIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (fou
 			return nil, nil, errcat.User.New(msg)
 		}
 	}
-	if foundIC != nil {
-		return foundCN, foundIC, nil
+	return foundCN, foundIC, nil
+}
+
+// findContainerPortIntercept finds the intercept configuration of a container port that has no service, and
+// that matches the given InterceptSpec's port. No error is returned when no intercept matches.
+func findContainerPortIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (foundCN *agentconfig.Container, foundIC *agentconfig.Intercept, err error) {
+	spi := agentconfig.PortIdentifier(spec.ServicePortIdentifier)
+	for _, cn := range ac.Containers {
+		for _, ic := range cn.Intercepts {
+			if ic.ServiceName != "" {
+				continue
+			}
+			if !(spi == "" || agentconfig.IsInterceptFor(spi, ic)) {
+				continue
+			}
+			if foundIC == nil {
+				foundCN = cn
+				foundIC = ic
+				continue
+			}
+			var msg string
+			if spi == "" {
+				msg = fmt.Sprintf("%s %s.%s has multiple interceptable container ports.\n"+
+					"Please specify the container port you want to intercept by passing the --port=<local:containerPortName> flag.",
+					ac.WorkloadKind, ac.WorkloadName, ac.Namespace)
+			} else {
+				msg = fmt.Sprintf("%s %s.%s has multiple interceptable container ports matching %s.\n"+
+					"Please specify the container port using its name.",
+					ac.WorkloadKind, ac.WorkloadName, ac.Namespace, spi)
+			}
+			return nil, nil, errcat.User.New(msg)
+		}
 	}
+	return foundCN, foundIC, nil
+}
 
-	ss := ""
-	if spec.ServiceName != "" {
-		if spi != "" {
-			ss = fmt.Sprintf(" matching service %s, port %s", spec.ServiceName, spi)
-		} else {
-			ss = fmt.Sprintf(" matching service %s", spec.ServiceName)
+// findAdditionalPorts resolves the additional ports of the given spec. The intercept of each port must be
+// distinct from the intercept of the primary port and from the intercepts of the other additional ports.
+func findAdditionalPorts(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec, primary *agentconfig.Intercept) ([]*managerrpc.InterceptPort, error) {
+	if len(spec.AdditionalPorts) == 0 {
+		return nil, nil
+	}
+	found := []*agentconfig.Intercept{primary}
+	aps := make([]*managerrpc.InterceptPort, len(spec.AdditionalPorts))
+	for i, ap := range spec.AdditionalPorts {
+		cn, ic, err := findIntercept(ac, &managerrpc.InterceptSpec{
+			ServiceName:           ap.ServiceName,
+			ServicePortIdentifier: ap.ServicePortIdentifier,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(found, ic) {
+			return nil, errcat.User.Newf("%s %s.%s: port %s of service %s is intercepted more than once",
+				ac.WorkloadKind, ac.WorkloadName, ac.Namespace, ap.ServicePortIdentifier, ic.ServiceName)
+		}
+		found = append(found, ic)
+		aps[i] = &managerrpc.InterceptPort{
+			ServicePortIdentifier: ap.ServicePortIdentifier,
+			ServiceName:           ic.ServiceName,
+			TargetPort:            ap.TargetPort,
+			ServicePortName:       ic.ServicePortName,
+			ServicePort:           int32(ic.ServicePort),
+			Protocol:              string(ic.Protocol),
+			ContainerName:         cn.Name,
+			AgentPort:             int32(ic.AgentPort),
 		}
-	} else if spi != "" {
-		ss = fmt.Sprintf(" matching port %s", spi)
 	}
-	return nil, nil, errcat.User.Newf("%s %s.%s has no interceptable port%s", ac.WorkloadKind, ac.WorkloadName, ac.Namespace, ss)
+	return aps, nil
+}
+
+// findIngestContainer returns the agent config of the container that the given ingest spec targets. The
+// container is resolved from the pod template of the workload, using its first container when the spec
+// doesn't name a container.
+func findIngestContainer(pod *core.PodTemplateSpec, ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (*agentconfig.Container, error) {
+	var names []string
+	for _, cn := range pod.Spec.Containers {
+		if cn.Name != agentconfig.ContainerName {
+			names = append(names, cn.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, errcat.User.Newf("%s %s.%s has no containers that can be ingested", ac.WorkloadKind, ac.WorkloadName, ac.Namespace)
+	}
+	name := spec.ContainerName
+	switch {
+	case name == "":
+		name = names[0]
+	case !slices.Contains(names, name):
+		return nil, errcat.User.Newf("%s %s.%s has no container named %q that can be ingested. Available containers are: %s",
+			ac.WorkloadKind, ac.WorkloadName, ac.Namespace, name, strings.Join(names, ", "))
+	}
+	for _, cn := range ac.Containers {
+		if cn.Name == name {
+			return cn, nil
+		}
+	}
+	// The agent config was generated by an older traffic-manager that only declared containers with ports.
+	return nil, errcat.User.Newf("the traffic-agent of %s %s.%s doesn't know container %q, please use "+
+		"'telepresence uninstall --agent %s' and then ingest again", ac.WorkloadKind, ac.WorkloadName, ac.Namespace, name, ac.WorkloadName)
 }
 
 type InterceptFinalizer func(ctx context.Context, interceptInfo *managerrpc.InterceptInfo) error
@@ -0,0 +1,187 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	core "k8s.io/api/core/v1"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func TestFindIngestContainer(t *testing.T) {
+	pod := &core.PodTemplateSpec{
+		Spec: core.PodSpec{
+			Containers: []core.Container{{Name: "worker"}, {Name: "app"}, {Name: "sidecar"}},
+		},
+	}
+	ac := &agentconfig.Sidecar{
+		WorkloadKind: "Deployment",
+		WorkloadName: "echo",
+		Namespace:    "default",
+		Containers: []*agentconfig.Container{
+			{Name: "app", Intercepts: []*agentconfig.Intercept{{ServicePort: 80}}},
+			{Name: "worker"},
+			{Name: "sidecar"},
+		},
+	}
+
+	// The first container of the pod is used by default, even when it has no ports.
+	cn, err := findIngestContainer(pod, ac, &manager.InterceptSpec{Ingest: true})
+	require.NoError(t, err)
+	assert.Equal(t, "worker", cn.Name)
+
+	cn, err = findIngestContainer(pod, ac, &manager.InterceptSpec{Ingest: true, ContainerName: "sidecar"})
+	require.NoError(t, err)
+	assert.Equal(t, "sidecar", cn.Name)
+
+	_, err = findIngestContainer(pod, ac, &manager.InterceptSpec{Ingest: true, ContainerName: "db"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Available containers are: worker, app, sidecar")
+
+	// A config generated by an older traffic-manager lacks the containers without ports.
+	ac.Containers = ac.Containers[:1]
+	_, err = findIngestContainer(pod, ac, &manager.InterceptSpec{Ingest: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "telepresence uninstall --agent echo")
+
+	_, err = findIngestContainer(&core.PodTemplateSpec{}, ac, &manager.InterceptSpec{Ingest: true})
+	require.Error(t, err)
+}
+
+func TestInterceptsConflict_ingest(t *testing.T) {
+	cept := &manager.InterceptSpec{Namespace: "default", Agent: "echo"}
+	ingest := &manager.InterceptSpec{Namespace: "default", Agent: "echo", Ingest: true}
+	assert.True(t, interceptsConflict(cept, cept))
+	assert.False(t, interceptsConflict(cept, ingest))
+	assert.False(t, interceptsConflict(ingest, ingest))
+}
+
+func TestFindAdditionalPorts(t *testing.T) {
+	api := &agentconfig.Intercept{ServiceName: "api", ServicePortName: "http", ServicePort: 80, Protocol: core.ProtocolTCP}
+	worker := &agentconfig.Intercept{ServiceName: "worker", ServicePortName: "metrics", ServicePort: 9090, Protocol: core.ProtocolTCP}
+	ac := &agentconfig.Sidecar{
+		WorkloadKind: "Deployment",
+		WorkloadName: "echo",
+		Namespace:    "default",
+		Containers: []*agentconfig.Container{
+			{Name: "api", Intercepts: []*agentconfig.Intercept{api}},
+			{Name: "worker", Intercepts: []*agentconfig.Intercept{worker}},
+		},
+	}
+
+	aps, err := findAdditionalPorts(ac, &manager.InterceptSpec{
+		AdditionalPorts: []*manager.InterceptPort{{ServicePortIdentifier: "metrics", TargetPort: 9091}},
+	}, api)
+	require.NoError(t, err)
+	require.Len(t, aps, 1)
+	assert.Equal(t, "worker", aps[0].ContainerName)
+	assert.Equal(t, "worker", aps[0].ServiceName)
+	assert.Equal(t, int32(9090), aps[0].ServicePort)
+	assert.Equal(t, int32(9091), aps[0].TargetPort)
+
+	_, err = findAdditionalPorts(ac, &manager.InterceptSpec{
+		AdditionalPorts: []*manager.InterceptPort{{ServicePortIdentifier: "http"}},
+	}, api)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "intercepted more than once")
+}
+
+func TestInterceptsConflict_additionalPorts(t *testing.T) {
+	a := &manager.InterceptSpec{
+		Namespace:       "default",
+		Agent:           "echo",
+		ServicePort:     80,
+		AdditionalPorts: []*manager.InterceptPort{{ServicePort: 9090, Protocol: "TCP"}},
+	}
+	b := &manager.InterceptSpec{Namespace: "default", Agent: "echo", ServicePort: 9090, Protocol: "TCP"}
+	c := &manager.InterceptSpec{Namespace: "default", Agent: "echo", ServicePort: 8080}
+	assert.True(t, interceptsConflict(a, b))
+	assert.True(t, interceptsConflict(b, a))
+	assert.False(t, interceptsConflict(a, c))
+}
+
+func TestInterceptsConflict_agentPort(t *testing.T) {
+	// A service port 9090 that targets container port 8080, and container port 9090 intercepted without a service.
+	svc := &manager.InterceptSpec{
+		Namespace:     "default",
+		Agent:         "echo",
+		ServicePort:   9090,
+		Protocol:      "TCP",
+		ContainerName: "app",
+		AgentPort:     9900,
+	}
+	cp := &manager.InterceptSpec{
+		Namespace:     "default",
+		Agent:         "echo",
+		ServicePort:   9090,
+		Protocol:      "TCP",
+		ContainerName: "app",
+		AgentPort:     9901,
+	}
+	assert.False(t, interceptsConflict(svc, cp))
+
+	// Another service port that targets the same container port.
+	other := &manager.InterceptSpec{
+		Namespace:     "default",
+		Agent:         "echo",
+		ServicePort:   80,
+		Protocol:      "TCP",
+		ContainerName: "app",
+		AgentPort:     9900,
+	}
+	assert.True(t, interceptsConflict(svc, other))
+
+	// The same agent port number of another container.
+	sidecar := proto.Clone(other).(*manager.InterceptSpec)
+	sidecar.ContainerName = "sidecar"
+	assert.False(t, interceptsConflict(svc, sidecar))
+}
+
+func TestFindIntercept_containerPort(t *testing.T) {
+	http := &agentconfig.Intercept{ServiceName: "echo", ServicePortName: "http", ServicePort: 80, Protocol: core.ProtocolTCP}
+	metrics := &agentconfig.Intercept{
+		ServicePortName:   "metrics",
+		ServicePort:       9090,
+		ContainerPortName: "metrics",
+		ContainerPort:     9090,
+		TargetPortNumeric: true,
+		Protocol:          core.ProtocolTCP,
+	}
+	debug := &agentconfig.Intercept{ServicePort: 5005, ContainerPort: 5005, TargetPortNumeric: true, Protocol: core.ProtocolTCP}
+	ac := &agentconfig.Sidecar{
+		WorkloadKind: "Deployment",
+		WorkloadName: "echo",
+		Namespace:    "default",
+		Containers: []*agentconfig.Container{
+			{Name: "app", Intercepts: []*agentconfig.Intercept{http, metrics, debug}},
+		},
+	}
+
+	// Service intercepts take precedence when no port is given.
+	_, ic, err := findIntercept(ac, &manager.InterceptSpec{})
+	require.NoError(t, err)
+	assert.Same(t, http, ic)
+
+	_, ic, err = findIntercept(ac, &manager.InterceptSpec{ServicePortIdentifier: "metrics"})
+	require.NoError(t, err)
+	assert.Same(t, metrics, ic)
+
+	_, ic, err = findIntercept(ac, &manager.InterceptSpec{ServicePortIdentifier: "5005"})
+	require.NoError(t, err)
+	assert.Same(t, debug, ic)
+
+	// Container port intercepts are never found when a service is given.
+	_, _, err = findIntercept(ac, &manager.InterceptSpec{ServiceName: "echo", ServicePortIdentifier: "metrics"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no interceptable port matching service echo, port metrics")
+
+	// Container port intercepts are ambiguous when there is no service intercept and no port is given.
+	ac.Containers[0].Intercepts = []*agentconfig.Intercept{metrics, debug}
+	_, _, err = findIntercept(ac, &manager.InterceptSpec{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has multiple interceptable container ports")
+}
@@ -0,0 +1,147 @@
+package state
+
+import (
+	"time"
+
+	dns2 "github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// Metrics are the labelled Prometheus series that the state updates in addition to the connect and intercept
+// series. A nil vector is never updated.
+type Metrics struct {
+	// Tunnels counts the tunnels per client and agent. Labels: client, install_id, agent, namespace.
+	Tunnels *prometheus.CounterVec
+
+	// ActiveTunnels is the number of active tunnels per client and agent. Labels: client, install_id, agent, namespace.
+	ActiveTunnels *prometheus.GaugeVec
+
+	// TunnelIngressBytes counts the bytes sent from clients per client and agent. The bytes are added when the
+	// tunnel ends. Labels: client, install_id, agent, namespace.
+	TunnelIngressBytes *prometheus.CounterVec
+
+	// TunnelEgressBytes counts the bytes sent to clients per client and agent. The bytes are added when the
+	// tunnel ends. Labels: client, install_id, agent, namespace.
+	TunnelEgressBytes *prometheus.CounterVec
+
+	// TunnelIngressWireBytes counts the bytes that TunnelIngressBytes occupied on the wire, i.e. after compression
+	// of the tunnel payloads. Labels: client, install_id, agent, namespace.
+	TunnelIngressWireBytes *prometheus.CounterVec
+
+	// TunnelEgressWireBytes counts the bytes that TunnelEgressBytes occupied on the wire. Labels: client,
+	// install_id, agent, namespace.
+	TunnelEgressWireBytes *prometheus.CounterVec
+
+	// DNSLookupDuration observes the time it takes for the agents to respond to a DNS lookup. Labels: type, result.
+	DNSLookupDuration *prometheus.HistogramVec
+
+	// AgentArrivalDuration observes the time that an intercept waits for its agent to arrive. Labels: namespace,
+	// result.
+	AgentArrivalDuration *prometheus.HistogramVec
+
+	// ExpiredSessions counts the sessions that were removed because they didn't send a heartbeat in time.
+	// Labels: session_type.
+	ExpiredSessions *prometheus.CounterVec
+}
+
+// TunnelMetricsLabels are the labels of the tunnel series in Metrics.
+var TunnelMetricsLabels = []string{"client", "install_id", "agent", "namespace"} //nolint:gochecknoglobals // constant
+
+func (s *state) SetMetrics(metrics *Metrics) {
+	s.metrics.Store(metrics)
+}
+
+func (s *state) GetMetrics() *Metrics {
+	return s.metrics.Load()
+}
+
+func counterAdd(cv *prometheus.CounterVec, labels prometheus.Labels, v float64) {
+	if cv != nil {
+		cv.With(labels).Add(v)
+	}
+}
+
+func gaugeAdd(gv *prometheus.GaugeVec, labels prometheus.Labels, v float64) {
+	if gv != nil {
+		gv.With(labels).Add(v)
+	}
+}
+
+func observeDuration(hv *prometheus.HistogramVec, labels prometheus.Labels, start time.Time) {
+	if hv != nil {
+		hv.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+// tunnelLabels returns the labels of a tunnel between the given sessions. One of them is normally a client
+// session and the other an agent session. The agent labels are empty when the traffic-manager itself is the
+// other end of the tunnel.
+func (s *state) tunnelLabels(sessionIDs ...string) prometheus.Labels {
+	labels := prometheus.Labels{"client": "", "install_id": "", "agent": "", "namespace": ""}
+	for _, id := range sessionIDs {
+		if id == "" {
+			continue
+		}
+		if client, ok := s.clients.Load(id); ok {
+			labels["client"] = client.Name
+			labels["install_id"] = client.InstallId
+		} else if agent, ok := s.agents.Load(id); ok {
+			labels["agent"] = agent.Name
+			labels["namespace"] = agent.Namespace
+		}
+	}
+	return labels
+}
+
+// meterTunnel records the start of a tunnel between the given sessions. It returns the consumption metrics
+// that the tunnel must use, and a function that records the end of the tunnel. The bytes counted by the returned
+// consumption metrics are also counted by the given consumption metrics.
+func (s *state) meterTunnel(scm *SessionConsumptionMetrics, sessionIDs ...string) (*SessionConsumptionMetrics, func()) {
+	m := s.GetMetrics()
+	if m == nil {
+		return scm, func() {}
+	}
+	labels := s.tunnelLabels(sessionIDs...)
+	counterAdd(m.Tunnels, labels, 1)
+	gaugeAdd(m.ActiveTunnels, labels, 1)
+	var fromClient, toClient *tunnel.CounterProbe
+	if scm != nil {
+		fromClient, toClient = scm.FromClientBytes, scm.ToClientBytes
+	}
+	tcm := SessionConsumptionMetrics{FromClientBytes: fromClient.NewChild(), ToClientBytes: toClient.NewChild()}
+	return &tcm, func() {
+		gaugeAdd(m.ActiveTunnels, labels, -1)
+		counterAdd(m.TunnelIngressBytes, labels, float64(tcm.FromClientBytes.GetValue()))
+		counterAdd(m.TunnelEgressBytes, labels, float64(tcm.ToClientBytes.GetValue()))
+		counterAdd(m.TunnelIngressWireBytes, labels, float64(tcm.FromClientBytes.GetCompressedValue()))
+		counterAdd(m.TunnelEgressWireBytes, labels, float64(tcm.ToClientBytes.GetCompressedValue()))
+	}
+}
+
+// sessionType returns the value of the session_type label for the given session.
+func sessionType(sess SessionState) string {
+	if _, ok := sess.(*clientSessionState); ok {
+		return "client"
+	}
+	return "agent"
+}
+
+// dnsLookupResult returns the value of the result label for a DNS lookup.
+func dnsLookupResult(rCode int, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case rCode == RcodeNoAgents:
+		return "no_agents"
+	default:
+		return "ok"
+	}
+}
+
+// dnsTypeLabel returns the value of the type label for a DNS lookup.
+func dnsTypeLabel(request *rpc.DNSRequest) string {
+	return dns2.TypeToString[uint16(request.Type)]
+}
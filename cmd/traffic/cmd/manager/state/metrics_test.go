@@ -0,0 +1,87 @@
+package state
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func newTestMetrics() *Metrics {
+	return &Metrics{
+		Tunnels:                prometheus.NewCounterVec(prometheus.CounterOpts{Name: "tunnel_total"}, TunnelMetricsLabels),
+		ActiveTunnels:          prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "tunnel_active_count"}, TunnelMetricsLabels),
+		TunnelIngressBytes:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "tunnel_client_ingress_bytes"}, TunnelMetricsLabels),
+		TunnelEgressBytes:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "tunnel_client_egress_bytes"}, TunnelMetricsLabels),
+		TunnelIngressWireBytes: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "tunnel_client_ingress_wire_bytes"}, TunnelMetricsLabels),
+		TunnelEgressWireBytes:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "tunnel_client_egress_wire_bytes"}, TunnelMetricsLabels),
+		ExpiredSessions:        prometheus.NewCounterVec(prometheus.CounterOpts{Name: "session_expired_count"}, []string{"session_type"}),
+	}
+}
+
+func (s *suiteState) TestMeterTunnel() {
+	// given
+	now := time.Now()
+	clientID := s.state.AddClient(&manager.ClientInfo{Name: "my-client", InstallId: "1234"}, now)
+	agentID := s.state.AddAgent(&manager.AgentInfo{Name: "echo", Namespace: "default"}, now)
+	scm := s.state.GetSessionConsumptionMetrics(clientID)
+
+	// when metrics are disabled
+	tcm, done := s.state.meterTunnel(scm, clientID, agentID)
+	done()
+
+	// then
+	assert.Same(s.T(), scm, tcm)
+
+	// when
+	m := newTestMetrics()
+	s.state.SetMetrics(m)
+	tcm, done = s.state.meterTunnel(scm, agentID, clientID)
+	labels := prometheus.Labels{"client": "my-client", "install_id": "1234", "agent": "echo", "namespace": "default"}
+	assert.Equal(s.T(), 1.0, testutil.ToFloat64(m.ActiveTunnels.With(labels)))
+	tcm.FromClientBytes.Increment(100)
+	tcm.ToClientBytes.Increment(42)
+	tcm.FromClientBytes.IncrementCompressed(30)
+	tcm.ToClientBytes.IncrementCompressed(42)
+	done()
+
+	// then
+	assert.Equal(s.T(), 1.0, testutil.ToFloat64(m.Tunnels.With(labels)))
+	assert.Equal(s.T(), 0.0, testutil.ToFloat64(m.ActiveTunnels.With(labels)))
+	assert.Equal(s.T(), 100.0, testutil.ToFloat64(m.TunnelIngressBytes.With(labels)))
+	assert.Equal(s.T(), 42.0, testutil.ToFloat64(m.TunnelEgressBytes.With(labels)))
+	assert.Equal(s.T(), 30.0, testutil.ToFloat64(m.TunnelIngressWireBytes.With(labels)))
+	assert.Equal(s.T(), 42.0, testutil.ToFloat64(m.TunnelEgressWireBytes.With(labels)))
+	assert.Equal(s.T(), uint64(100), scm.FromClientBytes.GetValue(), "bytes must be added to the session")
+	assert.Equal(s.T(), uint64(42), scm.ToClientBytes.GetValue(), "bytes must be added to the session")
+
+	// when the traffic-manager is the other end of the tunnel
+	_, done = s.state.meterTunnel(scm, clientID)
+	done()
+
+	// then
+	labels["agent"] = ""
+	labels["namespace"] = ""
+	assert.Equal(s.T(), 1.0, testutil.ToFloat64(m.Tunnels.With(labels)))
+}
+
+func (s *suiteState) TestExpireSessionsMetrics() {
+	// given
+	now := time.Now()
+	m := newTestMetrics()
+	s.state.SetMetrics(m)
+	s.state.sessions.Store("session-1", newClientSessionState(s.ctx, now.Add(-time.Hour)))
+	s.state.sessions.Store("session-2", newAgentSessionState(s.ctx, now.Add(-time.Hour)))
+	s.state.sessions.Store("session-3", newAgentSessionState(s.ctx, now))
+
+	// when
+	s.state.ExpireSessions(s.ctx, now.Add(-time.Minute), now.Add(-time.Minute))
+
+	// then
+	assert.Equal(s.T(), 1, s.state.sessions.Size())
+	assert.Equal(s.T(), 1.0, testutil.ToFloat64(m.ExpiredSessions.With(prometheus.Labels{"session_type": "client"})))
+	assert.Equal(s.T(), 1.0, testutil.ToFloat64(m.ExpiredSessions.With(prometheus.Labels{"session_type": "agent"})))
+}
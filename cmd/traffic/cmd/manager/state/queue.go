@@ -0,0 +1,173 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// interceptsConflict returns true if the given specs intercept the same port of the same workload,
+// in which case the traffic-agent can only serve one of them at a time. Ingests never conflict
+// because they don't intercept any traffic.
+func interceptsConflict(a, b *rpc.InterceptSpec) bool {
+	if a.Ingest || b.Ingest || a.Namespace != b.Namespace || a.Agent != b.Agent {
+		return false
+	}
+	bps := interceptedPorts(b)
+	for _, ap := range interceptedPorts(a) {
+		if slices.Contains(bps, ap) {
+			return true
+		}
+	}
+	return false
+}
+
+// interceptedPort identifies a port that the traffic-agent intercepts. Different service ports, and
+// container ports that are intercepted without a service, may share the same container port, so the
+// port is identified by its container and agent port. The service port is used instead when the
+// agent port is unknown, which is the case for intercepts created by older clients.
+type interceptedPort struct {
+	container   string
+	agentPort   int32
+	servicePort int32
+	protocol    string
+}
+
+func newInterceptedPort(container string, agentPort, servicePort int32, protocol string) interceptedPort {
+	if protocol == "" {
+		protocol = string(core.ProtocolTCP)
+	}
+	if agentPort != 0 {
+		return interceptedPort{container: container, agentPort: agentPort, protocol: protocol}
+	}
+	return interceptedPort{servicePort: servicePort, protocol: protocol}
+}
+
+// interceptedPorts returns the ports that the given spec intercepts.
+func interceptedPorts(spec *rpc.InterceptSpec) []interceptedPort {
+	ips := make([]interceptedPort, 1+len(spec.AdditionalPorts))
+	ips[0] = newInterceptedPort(spec.ContainerName, spec.AgentPort, spec.ServicePort, spec.Protocol)
+	for i, ap := range spec.AdditionalPorts {
+		ips[i+1] = newInterceptedPort(ap.ContainerName, ap.AgentPort, ap.ServicePort, ap.Protocol)
+	}
+	return ips
+}
+
+// holdsAgent returns true if the given intercept is, or is about to be, served by the traffic-agent.
+func holdsAgent(ii *rpc.InterceptInfo) bool {
+	switch ii.Disposition {
+	case rpc.InterceptDispositionType_WAITING, rpc.InterceptDispositionType_ACTIVE, rpc.InterceptDispositionType_PAUSED:
+		return true
+	}
+	return false
+}
+
+// releasesAgent returns true if an intercept that changes from the given old to the given new disposition
+// stops holding the traffic-agent, e.g. because the agent rejected it or went away.
+func releasesAgent(oldDisposition, newDisposition rpc.InterceptDispositionType) bool {
+	return holdsAgent(&rpc.InterceptInfo{Disposition: oldDisposition}) && !holdsAgent(&rpc.InterceptInfo{Disposition: newDisposition})
+}
+
+// interceptQueue returns the intercept that holds the traffic-agent for the given spec, if any, and the
+// intercepts that are queued behind it, ordered by their position in the queue. It must be called with
+// queueMu locked.
+func (s *state) interceptQueue(spec *rpc.InterceptSpec) (holder *rpc.InterceptInfo, queue []*rpc.InterceptInfo) {
+	for _, ii := range s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool {
+		return interceptsConflict(ii.Spec, spec)
+	}) {
+		switch {
+		case ii.Disposition == rpc.InterceptDispositionType_QUEUED:
+			queue = append(queue, ii)
+		case holdsAgent(ii):
+			holder = ii
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i].QueuePosition < queue[j].QueuePosition
+	})
+	return holder, queue
+}
+
+// enqueueIfConflicting puts the given intercept, which doesn't hold the traffic-agent, last in its queue if another intercept
+// holds the traffic-agent or if other intercepts are queued already. It must be called with queueMu locked.
+func (s *state) enqueueIfConflicting(cept *rpc.InterceptInfo) {
+	holder, queue := s.interceptQueue(cept.Spec)
+	if holder == nil && len(queue) == 0 {
+		return
+	}
+	cept.Disposition = rpc.InterceptDispositionType_QUEUED
+	cept.QueuePosition = int32(len(queue) + 1)
+	cept.Message = queuedMessage(holder, cept.QueuePosition)
+
+	// The time to live starts when the intercept leaves the queue.
+	cept.ExpiresAt = nil
+}
+
+// advanceInterceptQueue activates the first intercept in the queue for the given spec, unless another
+// intercept holds the traffic-agent, and updates the positions of the remaining queued intercepts. An
+// activated intercept that fails right away, e.g. because its agent is gone, doesn't block the queue.
+func (s *state) advanceInterceptQueue(ctx context.Context, spec *rpc.InterceptSpec) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	holder, queue := s.interceptQueue(spec)
+	for holder == nil && len(queue) > 0 {
+		holder = s.updateIntercept(queue[0].Id, func(ii *rpc.InterceptInfo) {
+			ii.QueuePosition = 0
+			ii.Disposition = rpc.InterceptDispositionType_WAITING
+			ii.Message = "Waiting for Agent approval"
+			if ttl := ii.Spec.Ttl.AsDuration(); ttl > 0 {
+				ii.ExpiresAt = timestamppb.New(time.Now().Add(ttl))
+			}
+			if errCode, errMsg := s.checkAgentsForIntercept(ii); errCode != 0 {
+				ii.Disposition = errCode
+				ii.Message = errMsg
+			}
+		})
+		if holder != nil {
+			dlog.Infof(ctx, "Intercept %s left the queue and is now %s", holder.Id, holder.Disposition)
+			if !holdsAgent(holder) {
+				holder = nil
+			}
+		}
+		queue = queue[1:]
+	}
+	for i, q := range queue {
+		pos := int32(i + 1)
+		if q.QueuePosition != pos {
+			s.updateIntercept(q.Id, func(ii *rpc.InterceptInfo) {
+				ii.QueuePosition = pos
+				ii.Message = queuedMessage(holder, pos)
+			})
+		}
+	}
+}
+
+// storeRegainedIntercept stores an intercept that regained its traffic-agent. An intercept that was created
+// with a queue is put last in its queue if another intercept took over the agent in the meantime. Other
+// intercepts are left waiting, so that the agent rejects them if they conflict, just like when they're created.
+func (s *state) storeRegainedIntercept(ctx context.Context, cept *rpc.InterceptInfo) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	if cept.Spec.Queue {
+		s.enqueueIfConflicting(cept)
+	}
+	if cept.Disposition == rpc.InterceptDispositionType_QUEUED {
+		dlog.Infof(ctx, "Intercept %s regained its agent and was queued at position %d", cept.Id, cept.QueuePosition)
+	}
+	s.intercepts.Store(cept.Id, cept)
+}
+
+func queuedMessage(holder *rpc.InterceptInfo, pos int32) string {
+	if holder == nil || pos > 1 {
+		return fmt.Sprintf("Queued at position %d", pos)
+	}
+	return fmt.Sprintf("Queued behind intercept %q of %s", holder.Spec.Name, holder.Spec.Client)
+}
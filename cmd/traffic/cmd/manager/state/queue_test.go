@@ -0,0 +1,164 @@
+package state
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func (s *suiteState) TestInterceptQueue() {
+	// given
+	now := time.Now()
+	s.state.AddAgent(&manager.AgentInfo{
+		Name:       "echo",
+		Namespace:  "default",
+		Mechanisms: []*manager.AgentInfo_Mechanism{{Name: "tcp"}},
+	}, now)
+	addIntercept := func(client string, port int32, queue bool) *manager.InterceptInfo {
+		sessionID := s.state.AddClient(&manager.ClientInfo{Name: client, InstallId: client}, now)
+		_, ii, err := s.state.AddIntercept(s.ctx, sessionID, "cluster", &manager.CreateInterceptRequest{
+			InterceptSpec: &manager.InterceptSpec{
+				Name:        "echo",
+				Client:      client,
+				Agent:       "echo",
+				Namespace:   "default",
+				Mechanism:   "tcp",
+				ServicePort: port,
+				Protocol:    "TCP",
+				Queue:       queue,
+				Ttl:         durationpb.New(time.Hour),
+			},
+		})
+		require.NoError(s.T(), err)
+		return ii
+	}
+	disposition := func(ii *manager.InterceptInfo) (manager.InterceptDispositionType, int32) {
+		cur, ok := s.state.GetIntercept(ii.Id)
+		require.True(s.T(), ok)
+		return cur.Disposition, cur.QueuePosition
+	}
+
+	// when three clients intercept the same port, the latter two using a queue
+	alice := addIntercept("alice", 80, false)
+	bob := addIntercept("bob", 80, true)
+	carol := addIntercept("carol", 80, true)
+
+	// then
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, alice.Disposition)
+	assert.Equal(s.T(), manager.InterceptDispositionType_QUEUED, bob.Disposition)
+	assert.Equal(s.T(), int32(1), bob.QueuePosition)
+	assert.Contains(s.T(), bob.Message, `"echo" of alice`)
+	assert.Nil(s.T(), bob.ExpiresAt, "the ttl must not start while queued")
+	assert.Equal(s.T(), manager.InterceptDispositionType_QUEUED, carol.Disposition)
+	assert.Equal(s.T(), int32(2), carol.QueuePosition)
+
+	// when an intercept of another port is created
+	other := addIntercept("dave", 8080, true)
+
+	// then it isn't affected by the queue
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, other.Disposition)
+
+	// when the active intercept ends
+	s.state.RemoveIntercept(s.ctx, alice.Id)
+
+	// then the first in the queue is activated, and the second moves up
+	d, pos := disposition(bob)
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, d)
+	assert.Equal(s.T(), int32(0), pos)
+	bobNow, _ := s.state.GetIntercept(bob.Id)
+	assert.NotNil(s.T(), bobNow.ExpiresAt)
+	d, pos = disposition(carol)
+	assert.Equal(s.T(), manager.InterceptDispositionType_QUEUED, d)
+	assert.Equal(s.T(), int32(1), pos)
+
+	// when a queued intercept is removed, and then the active one
+	erin := addIntercept("erin", 80, true)
+	_, pos = disposition(erin)
+	assert.Equal(s.T(), int32(2), pos)
+	s.state.RemoveIntercept(s.ctx, carol.Id)
+	_, pos = disposition(erin)
+	assert.Equal(s.T(), int32(1), pos)
+	s.state.RemoveIntercept(s.ctx, bob.Id)
+
+	// then
+	d, _ = disposition(erin)
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, d)
+}
+
+func (s *suiteState) TestInterceptQueue_holderFails() {
+	// given
+	now := time.Now()
+	agent := &manager.AgentInfo{
+		Name:       "echo",
+		Namespace:  "default",
+		Mechanisms: []*manager.AgentInfo_Mechanism{{Name: "tcp"}},
+	}
+	s.state.AddAgent(agent, now)
+	addIntercept := func(client string, queue bool) *manager.InterceptInfo {
+		sessionID := s.state.AddClient(&manager.ClientInfo{Name: client, InstallId: client}, now)
+		_, ii, err := s.state.AddIntercept(s.ctx, sessionID, "cluster", &manager.CreateInterceptRequest{
+			InterceptSpec: &manager.InterceptSpec{
+				Name:        "echo",
+				Client:      client,
+				Agent:       "echo",
+				Namespace:   "default",
+				Mechanism:   "tcp",
+				ServicePort: 80,
+				Protocol:    "TCP",
+				Queue:       queue,
+			},
+		})
+		require.NoError(s.T(), err)
+		return ii
+	}
+	disposition := func(ii *manager.InterceptInfo) (manager.InterceptDispositionType, int32) {
+		cur, ok := s.state.GetIntercept(ii.Id)
+		require.True(s.T(), ok)
+		return cur.Disposition, cur.QueuePosition
+	}
+	alice := addIntercept("alice", false)
+	bob := addIntercept("bob", true)
+	carol := addIntercept("carol", true)
+
+	// when the agent rejects the active intercept
+	s.state.UpdateIntercept(alice.Id, func(ii *manager.InterceptInfo) {
+		ii.Disposition = manager.InterceptDispositionType_AGENT_ERROR
+	})
+
+	// then the queue advances although the intercept wasn't removed
+	d, _ := disposition(bob)
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, d)
+	d, pos := disposition(carol)
+	assert.Equal(s.T(), manager.InterceptDispositionType_QUEUED, d)
+	assert.Equal(s.T(), int32(1), pos)
+
+	// when the agent of the activated intercept goes away, and the rejected intercept loses it too
+	s.state.UpdateIntercept(bob.Id, func(ii *manager.InterceptInfo) {
+		ii.Disposition = manager.InterceptDispositionType_NO_AGENT
+	})
+	s.state.UpdateIntercept(alice.Id, func(ii *manager.InterceptInfo) {
+		ii.Disposition = manager.InterceptDispositionType_NO_AGENT
+	})
+
+	// then the queue advances again
+	d, _ = disposition(carol)
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, d)
+
+	// when the intercepts that lost their agent regain it
+	s.state.AddAgent(agent, now)
+
+	// then the one that opted in to the queue is queued behind the intercept that took over the agent,
+	// and the other one waits for the agent to review it, just like when it was created
+	d, pos = disposition(alice)
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, d)
+	assert.Equal(s.T(), int32(0), pos)
+	d, pos = disposition(bob)
+	assert.Equal(s.T(), manager.InterceptDispositionType_QUEUED, d)
+	assert.Equal(s.T(), int32(1), pos)
+	d, _ = disposition(carol)
+	assert.Equal(s.T(), manager.InterceptDispositionType_WAITING, d)
+}
@@ -34,12 +34,14 @@ import (
 )
 
 type State interface {
+	AcceptInterceptHandover(string, string, string, func(*rpc.InterceptSpec)) (*rpc.InterceptInfo, *rpc.InterceptInfo, error)
 	AddAgent(*rpc.AgentInfo, time.Time) string
 	AddClient(*rpc.ClientInfo, time.Time) string
 	AddIntercept(context.Context, string, string, *rpc.CreateInterceptRequest) (*rpc.ClientInfo, *rpc.InterceptInfo, error)
 	AddInterceptFinalizer(string, InterceptFinalizer) error
 	AddSessionConsumptionMetrics(metrics *rpc.TunnelMetrics)
 	AgentsLookupDNS(context.Context, string, *rpc.DNSRequest) (dnsproxy.RRs, int, error)
+	ClientInterceptID(string, string) (string, bool)
 	CountAgents() int
 	CountClients() int
 	CountIntercepts() int
@@ -47,8 +49,10 @@ type State interface {
 	CountTunnels() int
 	CountTunnelIngress() uint64
 	CountTunnelEgress() uint64
+	ExpireIntercepts(context.Context, time.Time) []*rpc.InterceptInfo
 	ExpireSessions(context.Context, time.Time, time.Time)
 	GetAgent(string) *rpc.AgentInfo
+	GetAllAgents() map[string]*rpc.AgentInfo
 	GetAllClients() map[string]*rpc.ClientInfo
 	GetClient(string) *rpc.ClientInfo
 	GetSession(string) SessionState
@@ -72,6 +76,8 @@ type State interface {
 	SetTempLogLevel(context.Context, *rpc.LogLevelRequest)
 	SetAllClientSessionsFinalizer(finalizer allClientSessionsFinalizer)
 	SetAllInterceptsFinalizer(finalizer allInterceptsFinalizer)
+	SetMetrics(*Metrics)
+	GetMetrics() *Metrics
 	SetPrometheusMetrics(interceptCounterVec *prometheus.CounterVec,
 		interceptStatusGaugeVec *prometheus.GaugeVec,
 		connectCounterVec *prometheus.CounterVec,
@@ -135,6 +141,11 @@ type state struct {
 	connectActiveStatusGauge   *prometheus.GaugeVec
 	interceptCounter           *prometheus.CounterVec
 	interceptActiveStatusGauge *prometheus.GaugeVec
+	metrics                    atomic.Pointer[Metrics]
+
+	// queueMu serializes the changes of the intercept queues. It's separate from mu, because
+	// intercepts are removed, and hence queues are advanced, when a session is removed.
+	queueMu sync.Mutex
 
 	// Possibly extended version of the state. Use when calling interface methods.
 	self State
@@ -175,6 +186,11 @@ func (s *state) checkAgentsForIntercept(intercept *rpc.InterceptInfo) (errCode r
 		// Continue through; we can transition to an error state from here.
 	case rpc.InterceptDispositionType_WAITING:
 		// Continue through; we can transition to an error state from here.
+	case rpc.InterceptDispositionType_PAUSED:
+		// Continue through; we can transition to an error state from here.
+	case rpc.InterceptDispositionType_QUEUED:
+		// Don't overwrite this state. The agents are checked when the intercept leaves the queue.
+		return intercept.Disposition, intercept.Message
 	// error states ////////////////////////////////////////////////////////
 	case rpc.InterceptDispositionType_NO_CLIENT:
 		// Don't overwrite this error state.
@@ -296,9 +312,13 @@ func (s *state) gcSessionIntercepts(ctx context.Context, sessionID string) {
 		} else if errCode, errMsg := s.checkAgentsForIntercept(intercept); errCode != 0 {
 			// Refcount went to zero:
 			// Tell the client, so that the client can tell us to delete it.
+			oldDisposition := intercept.Disposition
 			intercept.Disposition = errCode
 			intercept.Message = errMsg
 			s.intercepts.Store(interceptID, intercept)
+			if releasesAgent(oldDisposition, errCode) {
+				s.advanceInterceptQueue(ctx, intercept.Spec)
+			}
 		} else if isAgent && agent.PodIp == intercept.PodIp {
 			// The agent whose podIP was stored by the intercept is dead, but it's not the last agent
 			// Send it back to waiting so that one of the other agents can pick it up and set their own podIP
@@ -317,6 +337,9 @@ func (s *state) ExpireSessions(ctx context.Context, clientMoment, agentMoment ti
 			moment = clientMoment
 		}
 		if sess.LastMarked().Before(moment) {
+			if m := s.GetMetrics(); m != nil {
+				counterAdd(m.ExpiredSessions, prometheus.Labels{"session_type": sessionType(sess)}, 1)
+			}
 			s.RemoveSession(ctx, id)
 		}
 		return true
@@ -415,15 +438,20 @@ func (s *state) AddAgent(agent *rpc.AgentInfo, now time.Time) string {
 		}
 		// Check whether each intercept needs to either (1) be moved in to a NO_AGENT state
 		// because this agent made things inconsistent, or (2) be moved out of a NO_AGENT
-		// state because it just gained an agent.
+		// state because it just gained an agent. In the latter case, an intercept created with
+		// a queue is queued if another intercept took over the agent while it had none.
 		if errCode, errMsg := s.checkAgentsForIntercept(intercept); errCode != 0 {
+			oldDisposition := intercept.Disposition
 			intercept.Disposition = errCode
 			intercept.Message = errMsg
 			s.intercepts.Store(interceptID, intercept)
+			if releasesAgent(oldDisposition, errCode) {
+				s.advanceInterceptQueue(s.backgroundCtx, intercept.Spec)
+			}
 		} else if intercept.Disposition == rpc.InterceptDispositionType_NO_AGENT {
 			intercept.Disposition = rpc.InterceptDispositionType_WAITING
 			intercept.Message = ""
-			s.intercepts.Store(interceptID, intercept)
+			s.storeRegainedIntercept(s.backgroundCtx, intercept)
 		}
 	}
 	return sessionID
@@ -434,7 +462,7 @@ func (s *state) GetAgent(sessionID string) *rpc.AgentInfo {
 	return ret
 }
 
-func (s *state) getAllAgents() map[string]*rpc.AgentInfo {
+func (s *state) GetAllAgents() map[string]*rpc.AgentInfo {
 	return s.agents.LoadAll()
 }
 
@@ -488,6 +516,13 @@ func (s *state) AddIntercept(ctx context.Context, sessionID, clusterID string, c
 
 	cept := s.self.NewInterceptInfo(interceptID, &clientSession, cir)
 
+	// Hold the queue lock until the intercept is stored, so that the queue isn't advanced in between.
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	if spec.Queue {
+		s.enqueueIfConflicting(cept)
+	}
+
 	// Wrap each potential-state-change in a
 	//
 	//     if cept.Disposition == rpc.InterceptDispositionType_WAITING { … }
@@ -513,14 +548,19 @@ func (s *state) AddIntercept(ctx context.Context, sessionID, clusterID string, c
 }
 
 func (s *state) NewInterceptInfo(interceptID string, session *rpc.SessionInfo, ciReq *rpc.CreateInterceptRequest) *rpc.InterceptInfo {
-	return &rpc.InterceptInfo{
+	now := time.Now()
+	ii := &rpc.InterceptInfo{
 		Spec:          ciReq.InterceptSpec,
 		Disposition:   rpc.InterceptDispositionType_WAITING,
 		Message:       "Waiting for Agent approval",
 		Id:            interceptID,
 		ClientSession: session,
-		ModifiedAt:    timestamppb.Now(),
+		ModifiedAt:    timestamppb.New(now),
+	}
+	if ttl := ciReq.InterceptSpec.Ttl.AsDuration(); ttl > 0 {
+		ii.ExpiresAt = timestamppb.New(now.Add(ttl))
 	}
+	return ii
 }
 
 func (s *state) AddInterceptFinalizer(interceptID string, finalizer InterceptFinalizer) error {
@@ -566,6 +606,20 @@ func (s *state) getAgentsInNamespace(namespace string) map[string]*rpc.AgentInfo
 // times.  So: it is safe to perform blocking operations in your mutator function, but you must take
 // care that it is safe to call your mutator function multiple times.
 func (s *state) UpdateIntercept(interceptID string, apply func(*rpc.InterceptInfo)) *rpc.InterceptInfo {
+	var oldDisposition rpc.InterceptDispositionType
+	newInfo := s.updateIntercept(interceptID, func(ii *rpc.InterceptInfo) {
+		oldDisposition = ii.Disposition
+		apply(ii)
+	})
+	if newInfo != nil && releasesAgent(oldDisposition, newInfo.Disposition) {
+		s.advanceInterceptQueue(s.backgroundCtx, newInfo.Spec)
+	}
+	return newInfo
+}
+
+// updateIntercept is like UpdateIntercept, but it doesn't advance the intercept queue. It's used
+// when queueMu is locked.
+func (s *state) updateIntercept(interceptID string, apply func(*rpc.InterceptInfo)) *rpc.InterceptInfo {
 	for {
 		cur, ok := s.intercepts.Load(interceptID)
 		if !ok || cur == nil {
@@ -609,6 +663,7 @@ func (s *state) UpdateClient(sessionID string, apply func(*rpc.ClientInfo)) *rpc
 func (s *state) RemoveIntercept(ctx context.Context, interceptID string) {
 	if intercept, didDelete := s.intercepts.LoadAndDelete(interceptID); didDelete {
 		s.FinalizeIntercept(ctx, intercept)
+		s.advanceInterceptQueue(ctx, intercept.Spec)
 	}
 }
 
@@ -656,13 +711,14 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 		return status.Errorf(codes.NotFound, "Session %q not found", sessionID)
 	}
 
+	peerID := ss.AwaitingBidiMapOwnerSessionID(stream)
 	var scm *SessionConsumptionMetrics
 	switch sst := ss.(type) {
 	case *agentSessionState:
 		// If it's an agent, find the associated clientSessionState.
-		if clientSessionID := sst.AwaitingBidiMapOwnerSessionID(stream); clientSessionID != "" {
+		if peerID != "" {
 			s.mu.RLock()
-			as, ok := s.sessions.Load(clientSessionID) // get awaiting state
+			as, ok := s.sessions.Load(peerID) // get awaiting state
 			s.mu.RUnlock()
 			if ok { // if found
 				if css, isClient := as.(*clientSessionState); isClient {
@@ -675,7 +731,12 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 	default:
 	}
 
-	bidiPipe, err := ss.OnConnect(ctx, stream, &s.tunnelCounter, scm)
+	tunnelSCM, tunnelDone := scm, func() {}
+	if peerID != "" {
+		tunnelSCM, tunnelDone = s.meterTunnel(scm, sessionID, peerID)
+	}
+	bidiPipe, err := ss.OnConnect(ctx, stream, &s.tunnelCounter, tunnelSCM)
+	defer tunnelDone()
 	if err != nil {
 		return err
 	}
@@ -726,6 +787,9 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 		if css, isClient := ss.(*clientSessionState); isClient {
 			scm = css.ConsumptionMetrics()
 		}
+		var done func()
+		scm, done = s.meterTunnel(scm, sessionID)
+		defer done()
 		endPoint = tunnel.NewDialer(stream, func() {}, scm.FromClientBytes, scm.ToClientBytes)
 		endPoint.Start(ctx)
 	}
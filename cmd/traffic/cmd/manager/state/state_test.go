@@ -34,6 +34,7 @@ func (s *suiteState) SetupTest() {
 		timedLogLevel:   log.NewTimedLevel("debug", log.SetLevel),
 		llSubs:          newLoglevelSubscribers(),
 	}
+	s.state.self = s.state
 }
 
 type FakeClock struct {
@@ -73,7 +74,7 @@ func (s *suiteState) TestStateInternal() {
 		a.Equal(demoAgent1, s.GetAgent(d1))
 		a.Equal(demoAgent2, s.GetAgent(d2))
 
-		agents := s.getAllAgents()
+		agents := s.GetAllAgents()
 		a.Len(agents, 4)
 		a.Contains(agents, helloAgent)
 		a.Contains(agents, helloProAgent)
@@ -32,6 +32,13 @@ func AgentContainer(
 	if len(ports) == 0 {
 		return nil
 	}
+	if config.MetricsPort > 0 {
+		ports = append(ports, core.ContainerPort{
+			Name:          MetricsPortName,
+			ContainerPort: int32(config.MetricsPort),
+			Protocol:      core.ProtocolTCP,
+		})
+	}
 
 	evs := make([]core.EnvVar, 0, len(config.Containers)*5)
 	efs := make([]core.EnvFromSource, 0, len(config.Containers)*3)
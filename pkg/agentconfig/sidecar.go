@@ -30,6 +30,7 @@ const (
 	ExportsMountPoint        = "/tel_app_exports"
 	TempVolumeName           = "tel-agent-tmp"
 	TempMountPoint           = "/tmp"
+	MetricsPortName          = "tel-agent-mtrcs"
 	EnvPrefix                = "_TEL_"
 	EnvPrefixAgent           = EnvPrefix + "AGENT_"
 	EnvPrefixApp             = EnvPrefix + "APP_"
@@ -66,11 +67,15 @@ const (
 )
 
 // Intercept describes the mapping between a service port and an intercepted container port.
+//
+// An intercept of a container port that no service targets has no ServiceName. Its ServicePortName
+// and ServicePort are then equal to the ContainerPortName and ContainerPort, and TargetPortNumeric
+// is true so that traffic to the container port is redirected to the agent port.
 type Intercept struct {
 	// The name of the intercepted container port
 	ContainerPortName string `json:"containerPortName,omitempty"`
 
-	// Name of intercepted service
+	// Name of intercepted service, or empty when the container port has no service
 	ServiceName string `json:"serviceName,omitempty"`
 
 	// UID of intercepted service
@@ -166,6 +171,9 @@ type Sidecar struct {
 	// The port used by the agent's GRPC tracing server
 	TracingPort uint16 `json:"tracingPort,omitempty"`
 
+	// The port used by the agent's Prometheus metrics server
+	MetricsPort uint16 `json:"metricsPort,omitempty"`
+
 	// Resources for the sidecar
 	Resources *core.ResourceRequirements `json:"resources,omitempty"`
 
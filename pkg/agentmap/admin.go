@@ -0,0 +1,23 @@
+package agentmap
+
+const (
+	// AdminTokenAudience is the audience of the tokens that the traffic-manager requires for its admin calls.
+	// Such tokens are obtained using a TokenRequest for the OperatorServiceAccount or the AdminServiceAccount,
+	// so they can't be used to access the API server.
+	AdminTokenAudience = "telepresence-traffic-manager"
+
+	// OperatorServiceAccount is the service account in the traffic-manager's namespace that may list the
+	// sessions, intercepts, and agents of all clients.
+	OperatorServiceAccount = "traffic-manager-operator"
+
+	// AdminServiceAccount is the service account in the traffic-manager's namespace that may also remove
+	// intercepts and sessions.
+	AdminServiceAccount = "traffic-manager-admin"
+
+	// CallerUserKey, CallerUIDKey, and CallerGroupsKey are the gRPC metadata keys that carry the Kubernetes
+	// identity of the caller of an admin call. The token of the admin call belongs to a shared service account,
+	// so the traffic-manager uses this identity to authorize the caller and to attribute the call to it.
+	CallerUserKey   = "telepresence-caller-user"
+	CallerUIDKey    = "telepresence-caller-uid"
+	CallerGroupsKey = "telepresence-caller-groups"
+)
@@ -57,12 +57,10 @@ func findServicesForPod(ctx context.Context, pod *core.PodTemplateSpec, svcName
 		if err != nil {
 			return nil, err
 		}
-		if len(svcs) > 0 {
-			return svcs, nil
-		}
-		return nil, fmt.Errorf("unable to find services that selects pod %s.%s using labels %s", pod.Name, pod.Namespace, lbs)
+		return svcs, nil
 	default:
-		return nil, fmt.Errorf("unable to resolve a service using pod %s.%s because it has no labels", pod.Name, pod.Namespace)
+		// A pod without labels cannot be selected by a service, but its container ports can still be intercepted.
+		return nil, nil
 	}
 }
 
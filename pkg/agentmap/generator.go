@@ -3,6 +3,7 @@ package agentmap
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -19,7 +20,12 @@ import (
 const (
 	ServicePortAnnotation = agentconfig.DomainPrefix + "inject-service-port"
 	ServiceNameAnnotation = agentconfig.DomainPrefix + "inject-service-name"
-	ManagerAppName        = "traffic-manager"
+
+	// ContainerPortsAnnotation is a comma separated list of names or numbers of container ports that
+	// can be intercepted without a service. The value "all" declares all container ports. Container
+	// ports are always declared when no service selects the pod.
+	ContainerPortsAnnotation = agentconfig.DomainPrefix + "inject-container-ports"
+	ManagerAppName           = "traffic-manager"
 )
 
 type GeneratorConfig interface {
@@ -41,6 +47,7 @@ type BasicGeneratorConfig struct {
 	AgentPort           uint16
 	APIPort             uint16
 	TracingPort         uint16
+	MetricsPort         uint16
 	QualifiedAgentImage string
 	ManagerNamespace    string
 	LogLevel            string
@@ -100,9 +107,17 @@ func (cfg *BasicGeneratorConfig) Generate(
 			return nil, err
 		}
 	}
+	if cpa, ok := pod.Annotations[ContainerPortsAnnotation]; ok || len(svcs) == 0 {
+		if ccs, err = appendContainerPortConfigs(pod, cpa, portNumber, ccs, replaceContainers, existingConfig); err != nil {
+			return nil, err
+		}
+	}
 	if len(ccs) == 0 {
-		return nil, fmt.Errorf("found no service with a port that matches a container in pod %s.%s", pod.Name, pod.Namespace)
+		return nil, fmt.Errorf(
+			"found no service with a port that matches a container in pod %s.%s, and no container port that can be intercepted without a service",
+			pod.Name, pod.Namespace)
 	}
+	ccs = appendPortlessContainers(pod, ccs)
 
 	ag := &agentconfig.Sidecar{
 		AgentImage:    cfg.QualifiedAgentImage,
@@ -115,6 +130,7 @@ func (cfg *BasicGeneratorConfig) Generate(
 		ManagerPort:   cfg.ManagerPort,
 		APIPort:       cfg.APIPort,
 		TracingPort:   cfg.TracingPort,
+		MetricsPort:   cfg.MetricsPort,
 		Containers:    ccs,
 		InitResources: cfg.InitResources,
 		Resources:     cfg.Resources,
@@ -138,7 +154,6 @@ func appendAgentContainerConfigs(
 	if err != nil {
 		return nil, err
 	}
-nextSvcPort:
 	for _, port := range ports {
 		cn, i := findContainerMatchingPort(&port, pod.Spec.Containers)
 		if cn == nil || cn.Name == agentconfig.ContainerName {
@@ -181,33 +196,166 @@ nextSvcPort:
 				}
 			}
 		}
+		ccs = appendIntercept(ccs, cn, ic, replaceContainers)
+	}
+	return ccs, nil
+}
 
-		// The container might already have intercepts declared
-		for _, cc := range ccs {
-			if cc.Name == cn.Name {
-				cc.Intercepts = append(cc.Intercepts, ic)
-				continue nextSvcPort
+// appendContainerPortConfigs appends intercepts for container ports that aren't targeted by any service. Such
+// intercepts have no ServiceName. Their ServicePortName and ServicePort are copied from the container port, so
+// that a PortIdentifier can refer to them, and their traffic is always redirected to the agent using iptables.
+// Only ports matching the given comma separated list of names or numbers are considered unless the list is empty
+// or "all".
+func appendContainerPortConfigs(
+	pod *core.PodTemplateSpec,
+	namesOrNumbers string,
+	portNumber func(int32) uint16,
+	ccs []*agentconfig.Container,
+	replaceContainers agentconfig.ReplacePolicy,
+	existingConfig agentconfig.SidecarExt,
+) ([]*agentconfig.Container, error) {
+	filter, err := containerPortFilter(namesOrNumbers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation in pod %s.%s: %w", ContainerPortsAnnotation, pod.Name, pod.Namespace, err)
+	}
+	cns := pod.Spec.Containers
+	for ci := range cns {
+		cn := &cns[ci]
+		if cn.Name == agentconfig.ContainerName {
+			continue
+		}
+		rp := replaceContainers
+		if existingConfig != nil {
+			for _, cc := range existingConfig.AgentConfig().Containers {
+				if cc.Name == cn.Name {
+					rp = cc.Replace
+					break
+				}
 			}
 		}
-		var mounts []string
-		if l := len(cn.VolumeMounts); l > 0 {
-			mounts = make([]string, l)
-			for i, vm := range cn.VolumeMounts {
-				mounts[i] = vm.MountPath
+		for pi := range cn.Ports {
+			p := &cn.Ports[pi]
+			if !filter(p) {
+				continue
+			}
+			proto := p.Protocol
+			if proto == "" {
+				proto = core.ProtocolTCP
 			}
+			if containerPortIntercepted(ccs, cn.Name, uint16(p.ContainerPort), proto) {
+				continue
+			}
+			ccs = appendIntercept(ccs, cn, &agentconfig.Intercept{
+				ServicePortName:   p.Name,
+				ServicePort:       uint16(p.ContainerPort),
+				TargetPortNumeric: true,
+				Protocol:          proto,
+				AgentPort:         portNumber(p.ContainerPort),
+				ContainerPortName: p.Name,
+				ContainerPort:     uint16(p.ContainerPort),
+			}, rp)
 		}
-		ccs = append(ccs, &agentconfig.Container{
-			Name:       cn.Name,
-			EnvPrefix:  CapsBase26(uint64(len(ccs))) + "_",
-			MountPoint: agentconfig.MountPrefixApp + "/" + cn.Name,
-			Mounts:     mounts,
-			Intercepts: []*agentconfig.Intercept{ic},
-			Replace:    replaceContainers,
-		})
 	}
 	return ccs, nil
 }
 
+// containerPortFilter returns a function that answers true for container ports that matches the given comma
+// separated list of port names or numbers. The function answers true for all ports when the list is empty
+// or equal to "all".
+func containerPortFilter(namesOrNumbers string) (func(*core.ContainerPort) bool, error) {
+	namesOrNumbers = strings.TrimSpace(namesOrNumbers)
+	if namesOrNumbers == "" || namesOrNumbers == "all" {
+		return func(*core.ContainerPort) bool { return true }, nil
+	}
+	names := make(map[string]struct{})
+	numbers := make(map[int32]struct{})
+	for _, nn := range strings.Split(namesOrNumbers, ",") {
+		nn = strings.TrimSpace(nn)
+		if number, err := strconv.Atoi(nn); err == nil {
+			if errs := validation.IsValidPortNum(number); len(errs) > 0 {
+				return nil, fmt.Errorf(strings.Join(errs, "\n"))
+			}
+			numbers[int32(number)] = struct{}{}
+		} else {
+			if errs := validation.IsValidPortName(nn); len(errs) > 0 {
+				return nil, fmt.Errorf(strings.Join(errs, "\n"))
+			}
+			names[nn] = struct{}{}
+		}
+	}
+	return func(p *core.ContainerPort) bool {
+		if _, ok := numbers[p.ContainerPort]; ok {
+			return true
+		}
+		_, ok := names[p.Name]
+		return ok && p.Name != ""
+	}, nil
+}
+
+// containerPortIntercepted returns true if the given container already has an intercept for the given port and protocol.
+func containerPortIntercepted(ccs []*agentconfig.Container, cnName string, port uint16, proto core.Protocol) bool {
+	for _, cc := range ccs {
+		if cc.Name != cnName {
+			continue
+		}
+		for _, ic := range cc.Intercepts {
+			if ic.ContainerPort == port && (ic.Protocol == proto || ic.Protocol == "" && proto == core.ProtocolTCP) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appendIntercept appends the given intercept to the config of the given container, and creates that
+// config unless it already exists.
+func appendIntercept(ccs []*agentconfig.Container, cn *core.Container, ic *agentconfig.Intercept, replaceContainers agentconfig.ReplacePolicy) []*agentconfig.Container {
+	// The container might already have intercepts declared
+	for _, cc := range ccs {
+		if cc.Name == cn.Name {
+			cc.Intercepts = append(cc.Intercepts, ic)
+			return ccs
+		}
+	}
+	cc := newContainerConfig(cn, len(ccs), replaceContainers)
+	cc.Intercepts = []*agentconfig.Intercept{ic}
+	return append(ccs, cc)
+}
+
+// appendPortlessContainers appends a config without intercepts for each application container that
+// has no interceptable ports, so that the environment and mounts of that container can be ingested.
+func appendPortlessContainers(pod *core.PodTemplateSpec, ccs []*agentconfig.Container) []*agentconfig.Container {
+	cns := pod.Spec.Containers
+	for i := range cns {
+		cn := &cns[i]
+		if cn.Name == agentconfig.ContainerName || slices.ContainsFunc(ccs, func(cc *agentconfig.Container) bool {
+			return cc.Name == cn.Name
+		}) {
+			continue
+		}
+		ccs = append(ccs, newContainerConfig(cn, len(ccs), agentconfig.ReplacePolicyNever))
+	}
+	return ccs
+}
+
+// newContainerConfig creates the config of the given container, which is the nth container in the config.
+func newContainerConfig(cn *core.Container, n int, replaceContainers agentconfig.ReplacePolicy) *agentconfig.Container {
+	var mounts []string
+	if l := len(cn.VolumeMounts); l > 0 {
+		mounts = make([]string, l)
+		for i, vm := range cn.VolumeMounts {
+			mounts[i] = vm.MountPath
+		}
+	}
+	return &agentconfig.Container{
+		Name:       cn.Name,
+		EnvPrefix:  CapsBase26(uint64(n)) + "_",
+		MountPoint: agentconfig.MountPrefixApp + "/" + cn.Name,
+		Mounts:     mounts,
+		Replace:    replaceContainers,
+	}
+}
+
 // filterServicePorts iterates through a list of ports in a service and
 // only returns the ports that match the given nameOrNumber. All ports will
 // be returned if nameOrNumber is equal to the empty string.
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+)
+
+type adminSessionJSONOutput struct {
+	SessionID       string        `json:"session_id"`
+	Client          string        `json:"client"`
+	Namespace       string        `json:"namespace,omitempty"`
+	InstallID       string        `json:"install_id"`
+	Version         string        `json:"version"`
+	ConnectDuration time.Duration `json:"connect_duration"`
+	LastUpdate      *time.Time    `json:"last_update,omitempty"`
+	FromClientBytes uint64        `json:"from_client_bytes"`
+	ToClientBytes   uint64        `json:"to_client_bytes"`
+}
+
+type adminInterceptJSONOutput struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	Workload    string `json:"workload"`
+	Client      string `json:"client"`
+	SessionID   string `json:"session_id"`
+	Disposition string `json:"disposition"`
+	Message     string `json:"message,omitempty"`
+}
+
+type adminAgentJSONOutput struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+	PodIP     string `json:"pod_ip"`
+	Version   string `json:"version"`
+}
+
+func adminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "admin",
+		Args: cobra.NoArgs,
+
+		Short: "Inspect and manage the sessions, intercepts, and agents of all users of the traffic-manager",
+		Long: `Inspect and manage the sessions, intercepts, and agents of all users of the traffic-manager.
+
+The commands require a traffic-manager that is installed with adminApi.enabled=true. They
+authenticate using a short-lived token for the traffic-manager-operator service account when
+listing things, and for the traffic-manager-admin service account when removing things. Both
+service accounts live in the traffic-manager's namespace, and the current context must be
+allowed to create tokens for them (the "create" verb on the "serviceaccounts/token" resource).
+The identity of the current context is sent along with the token, and the traffic-manager
+requires that it has the same access as the service account, and attributes removals to it.
+The credentials of the current context are never sent to the traffic-manager.`,
+	}
+	cmd.AddCommand(adminSessions(), adminIntercepts(), adminAgents(), adminRemoveIntercept(), adminRemoveSession())
+	return cmd
+}
+
+func adminSessions() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sessions",
+		Args:  cobra.NoArgs,
+		Short: "List the sessions of all clients connected to the traffic-manager",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			r, err := daemon.GetUserClient(ctx).GetAdminSessions(ctx, &empty.Empty{})
+			if err != nil {
+				return err
+			}
+			if output.WantsFormatted(cmd) {
+				output.Object(ctx, adminSessionsJSON(r.Sessions), false)
+			} else {
+				printAdminSessions(cmd.OutOrStdout(), r.Sessions)
+			}
+			return nil
+		},
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func adminIntercepts() *cobra.Command {
+	return &cobra.Command{
+		Use:   "intercepts",
+		Args:  cobra.NoArgs,
+		Short: "List the intercepts of all clients connected to the traffic-manager",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			r, err := daemon.GetUserClient(ctx).GetAdminIntercepts(ctx, &empty.Empty{})
+			if err != nil {
+				return err
+			}
+			if output.WantsFormatted(cmd) {
+				output.Object(ctx, adminInterceptsJSON(r.Intercepts), false)
+			} else {
+				printAdminIntercepts(cmd.OutOrStdout(), r.Intercepts)
+			}
+			return nil
+		},
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func adminAgents() *cobra.Command {
+	return &cobra.Command{
+		Use:   "agents",
+		Args:  cobra.NoArgs,
+		Short: "List all traffic-agents known to the traffic-manager",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			r, err := daemon.GetUserClient(ctx).GetAdminAgents(ctx, &empty.Empty{})
+			if err != nil {
+				return err
+			}
+			if output.WantsFormatted(cmd) {
+				output.Object(ctx, adminAgentsJSON(r.Agents), false)
+			} else {
+				printAdminAgents(cmd.OutOrStdout(), r.Agents)
+			}
+			return nil
+		},
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func adminRemoveIntercept() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove-intercept <intercept id>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Remove an intercept, regardless of which client created it",
+		Long: `Remove an intercept, regardless of which client created it. The id of the intercept is
+listed by "telepresence admin intercepts".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			_, err := daemon.GetUserClient(ctx).AdminRemoveIntercept(ctx, &manager.AdminRemoveRequest{Id: args[0]})
+			if err != nil {
+				return err
+			}
+			if !output.WantsFormatted(cmd) {
+				fmt.Fprintf(cmd.OutOrStdout(), "Intercept %s removed\n", args[0])
+			}
+			return nil
+		},
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func adminRemoveSession() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove-session <session id>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Remove a client session and all its intercepts from the traffic-manager",
+		Long: `Remove a client session from the traffic-manager, along with all intercepts that it created.
+The id of the session is listed by "telepresence admin sessions".
+
+This doesn't disconnect the client. A client that is still running will notice that its session
+is gone and connect again using a new session, but without the intercepts. The command is mainly
+useful for removing sessions that belong to clients that are gone.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			_, err := daemon.GetUserClient(ctx).AdminRemoveSession(ctx, &manager.AdminRemoveRequest{Id: args[0]})
+			if err != nil {
+				return err
+			}
+			if !output.WantsFormatted(cmd) {
+				fmt.Fprintf(cmd.OutOrStdout(), "Session %s removed\n", args[0])
+			}
+			return nil
+		},
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func adminSessionsJSON(ss []*manager.AdminSession) []*adminSessionJSONOutput {
+	o := make([]*adminSessionJSONOutput, len(ss))
+	for i, s := range ss {
+		so := &adminSessionJSONOutput{
+			SessionID:       s.SessionId,
+			Client:          s.Client.GetName(),
+			Namespace:       s.Client.GetNamespace(),
+			InstallID:       s.Client.GetInstallId(),
+			Version:         s.Client.GetVersion(),
+			ConnectDuration: s.ConnectDuration.AsDuration(),
+			FromClientBytes: s.FromClientBytes,
+			ToClientBytes:   s.ToClientBytes,
+		}
+		if s.LastUpdate != nil {
+			lu := s.LastUpdate.AsTime()
+			so.LastUpdate = &lu
+		}
+		o[i] = so
+	}
+	return o
+}
+
+func printAdminSessions(out io.Writer, ss []*manager.AdminSession) {
+	if len(ss) == 0 {
+		fmt.Fprintln(out, "No sessions")
+		return
+	}
+	header := []string{"SESSION", "CLIENT", "NAMESPACE", "VERSION", "CONNECTED", "FROM CLIENT", "TO CLIENT"}
+	rows := make([][]string, len(ss))
+	for i, s := range ss {
+		rows[i] = []string{
+			s.SessionId,
+			s.Client.GetName(),
+			s.Client.GetNamespace(),
+			s.Client.GetVersion(),
+			s.ConnectDuration.AsDuration().Truncate(time.Second).String(),
+			formatBytes(s.FromClientBytes),
+			formatBytes(s.ToClientBytes),
+		}
+	}
+	printTable(out, header, rows)
+}
+
+func adminInterceptsJSON(is []*manager.InterceptInfo) []*adminInterceptJSONOutput {
+	o := make([]*adminInterceptJSONOutput, len(is))
+	for i, ii := range is {
+		o[i] = &adminInterceptJSONOutput{
+			ID:          ii.Id,
+			Name:        ii.Spec.GetName(),
+			Namespace:   ii.Spec.GetNamespace(),
+			Workload:    ii.Spec.GetAgent(),
+			Client:      ii.Spec.GetClient(),
+			SessionID:   ii.ClientSession.GetSessionId(),
+			Disposition: ii.Disposition.String(),
+			Message:     ii.Message,
+		}
+	}
+	return o
+}
+
+func printAdminIntercepts(out io.Writer, is []*manager.InterceptInfo) {
+	if len(is) == 0 {
+		fmt.Fprintln(out, "No intercepts")
+		return
+	}
+	header := []string{"ID", "NAMESPACE", "WORKLOAD", "CLIENT", "DISPOSITION"}
+	rows := make([][]string, len(is))
+	for i, ii := range is {
+		rows[i] = []string{
+			ii.Id,
+			ii.Spec.GetNamespace(),
+			ii.Spec.GetAgent(),
+			ii.Spec.GetClient(),
+			ii.Disposition.String(),
+		}
+	}
+	printTable(out, header, rows)
+}
+
+func adminAgentsJSON(as []*manager.AgentInfo) []*adminAgentJSONOutput {
+	o := make([]*adminAgentJSONOutput, len(as))
+	for i, a := range as {
+		o[i] = &adminAgentJSONOutput{
+			Name:      a.Name,
+			Namespace: a.Namespace,
+			PodName:   a.PodName,
+			PodIP:     a.PodIp,
+			Version:   a.Version,
+		}
+	}
+	return o
+}
+
+func printAdminAgents(out io.Writer, as []*manager.AgentInfo) {
+	if len(as) == 0 {
+		fmt.Fprintln(out, "No agents")
+		return
+	}
+	header := []string{"NAMESPACE", "WORKLOAD", "POD", "POD IP", "VERSION"}
+	rows := make([][]string, len(as))
+	for i, a := range as {
+		rows[i] = []string{a.Namespace, a.Name, a.PodName, a.PodIp, a.Version}
+	}
+	printTable(out, header, rows)
+}
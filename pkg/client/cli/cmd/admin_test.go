@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_printAdminSessions(t *testing.T) {
+	ss := []*manager.AdminSession{
+		{
+			SessionId:       "5f0e5b6c-8d1c-4b8e-9e4f-2a4b7c1d9e01",
+			Client:          &manager.ClientInfo{Name: "alice@host", Namespace: "default", Version: "2.19.0"},
+			ConnectDuration: durationpb.New(75*time.Second + 300*time.Millisecond),
+			FromClientBytes: 1536,
+			ToClientBytes:   12,
+		},
+		{
+			SessionId: "0a1b2c3d",
+			Client:    &manager.ClientInfo{Name: "bob@other-host", Namespace: "staging", Version: "2.18.2"},
+		},
+	}
+	buf := bytes.Buffer{}
+	printAdminSessions(&buf, ss)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^SESSION\s+CLIENT\s+NAMESPACE\s+VERSION\s+CONNECTED\s+FROM CLIENT\s+TO CLIENT$`, lines[0])
+	assert.Regexp(t, `^5f0e5b6c-8d1c-4b8e-9e4f-2a4b7c1d9e01\s+alice@host\s+default\s+2\.19\.0\s+1m15s\s+1\.5KiB\s+12B$`, lines[1])
+	assert.Regexp(t, `^0a1b2c3d\s+bob@other-host\s+staging\s+2\.18\.2\s+0s\s+0B\s+0B$`, lines[2])
+	assert.Equal(t, strings.Index(lines[0], "CLIENT"), strings.Index(lines[2], "bob@other-host"))
+
+	buf.Reset()
+	printAdminSessions(&buf, nil)
+	assert.Equal(t, "No sessions\n", buf.String())
+}
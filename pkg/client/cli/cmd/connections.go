@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// connectionsRefreshInterval is the interval between refreshes when the connections are watched.
+const connectionsRefreshInterval = time.Second
+
+type connectionsCommand struct {
+	watch bool
+}
+
+type connectionJSONOutput struct {
+	Protocol         string        `json:"protocol"`
+	Source           string        `json:"source"`
+	Destination      string        `json:"destination"`
+	DestinationName  string        `json:"destination_name,omitempty"`
+	Route            string        `json:"route"`
+	BytesIn          uint64        `json:"bytes_in"`
+	BytesOut         uint64        `json:"bytes_out"`
+	Started          time.Time     `json:"started"`
+	Age              time.Duration `json:"age"`
+	RoundTripLatency time.Duration `json:"round_trip_latency"`
+}
+
+func connections() *cobra.Command {
+	s := &connectionsCommand{}
+	cmd := &cobra.Command{
+		Use:  "connections",
+		Args: cobra.NoArgs,
+
+		Short: "List active connections to the cluster",
+		Long: `List the connections that are currently routed to the cluster, together with the number
+of bytes received and sent, their age, whether they are routed via the traffic-manager or directly
+to a traffic-agent, and the round-trip latency of their tunnel.
+
+The NAME column shows the service or pod that the destination IP belongs to. It's empty when
+the IP doesn't belong to a service or pod in a mapped namespace or in the traffic-manager's
+namespace.`,
+		RunE: s.run,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+	cmd.Flags().BoolVarP(&s.watch, "watch", "w", false,
+		fmt.Sprintf("refresh the list every %s until interrupted", connectionsRefreshInterval))
+	return cmd
+}
+
+func (s *connectionsCommand) run(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	watch := s.watch || output.WantsStream(cmd)
+	formatted := output.WantsFormatted(cmd)
+	if watch && formatted && !output.WantsStream(cmd) {
+		return errcat.User.New("--watch can only be combined with --output json-stream")
+	}
+
+	ctx := cmd.Context()
+	userD := daemon.GetUserClient(ctx)
+	stdout := cmd.OutOrStdout()
+	ticker := time.NewTicker(connectionsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		r, err := userD.GetConnections(ctx, &empty.Empty{})
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		switch {
+		case formatted:
+			output.Object(ctx, connectionsJSON(r.Connections, now), false)
+		case watch:
+			// Move the cursor home and clear the screen before redrawing.
+			fmt.Fprint(stdout, "\x1b[H\x1b[2J")
+			fallthrough
+		default:
+			printConnections(stdout, r.Connections, now)
+		}
+		if !watch {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func connectionsJSON(cs []*rpc.Connection, now time.Time) []*connectionJSONOutput {
+	o := make([]*connectionJSONOutput, len(cs))
+	for i, c := range cs {
+		started := c.Started.AsTime()
+		o[i] = &connectionJSONOutput{
+			Protocol:         c.Protocol,
+			Source:           hostPort(c.SourceIp, c.SourcePort),
+			Destination:      hostPort(c.DestinationIp, c.DestinationPort),
+			DestinationName:  c.DestinationName,
+			Route:            c.Route,
+			BytesIn:          c.BytesIn,
+			BytesOut:         c.BytesOut,
+			Started:          started,
+			Age:              now.Sub(started),
+			RoundTripLatency: c.RoundTripLatency.AsDuration(),
+		}
+	}
+	return o
+}
+
+func printConnections(out io.Writer, cs []*rpc.Connection, now time.Time) {
+	if len(cs) == 0 {
+		fmt.Fprintln(out, "No active connections")
+		return
+	}
+	header := []string{"PROTO", "SOURCE", "DESTINATION", "NAME", "ROUTE", "IN", "OUT", "AGE", "RTT"}
+	rows := make([][]string, len(cs))
+	for i, c := range cs {
+		rows[i] = []string{
+			c.Protocol,
+			hostPort(c.SourceIp, c.SourcePort),
+			hostPort(c.DestinationIp, c.DestinationPort),
+			c.DestinationName,
+			c.Route,
+			formatBytes(c.BytesIn),
+			formatBytes(c.BytesOut),
+			now.Sub(c.Started.AsTime()).Truncate(time.Second).String(),
+			c.RoundTripLatency.AsDuration().Round(time.Microsecond).String(),
+		}
+	}
+	printTable(out, header, rows)
+}
+
+// printTable prints the given header and rows as left-aligned columns separated by two spaces.
+func printTable(out io.Writer, header []string, rows [][]string) {
+	widths := make([]int, len(header))
+	for _, r := range append([][]string{header}, rows...) {
+		for i, v := range r {
+			if len(v) > widths[i] {
+				widths[i] = len(v)
+			}
+		}
+	}
+	for _, r := range append([][]string{header}, rows...) {
+		last := len(r) - 1
+		for i, v := range r {
+			if i < last {
+				fmt.Fprintf(out, "%-*s  ", widths[i], v)
+			} else {
+				fmt.Fprintln(out, v)
+			}
+		}
+	}
+}
+
+func hostPort(ip net.IP, port int32) string {
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+}
+
+// formatBytes formats the given number of bytes using binary units.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+func Test_formatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		0:                  "0B",
+		1023:               "1023B",
+		1024:               "1.0KiB",
+		1536:               "1.5KiB",
+		5 * 1024 * 1024:    "5.0MiB",
+		3 << 30:            "3.0GiB",
+		(1 << 40) + 1<<39:  "1.5TiB",
+		1024*1024*1024 - 1: "1024.0MiB",
+	}
+	for n, expected := range tests {
+		assert.Equal(t, expected, formatBytes(n), "formatBytes(%d)", n)
+	}
+}
+
+func Test_printConnections(t *testing.T) {
+	now := time.Now()
+	cs := []*rpc.Connection{
+		{
+			Protocol:         "tcp",
+			SourceIp:         net.ParseIP("10.0.0.1"),
+			SourcePort:       53000,
+			DestinationIp:    net.ParseIP("10.96.0.12"),
+			DestinationPort:  80,
+			DestinationName:  "svc/echo.default",
+			Route:            "traffic-manager",
+			BytesIn:          2048,
+			BytesOut:         12,
+			Started:          timestamppb.New(now.Add(-90 * time.Second)),
+			RoundTripLatency: durationpb.New(1500 * time.Microsecond),
+		},
+		{
+			Protocol:         "udp",
+			SourceIp:         net.ParseIP("fd00::1"),
+			SourcePort:       42000,
+			DestinationIp:    net.ParseIP("fd00::2"),
+			DestinationPort:  8080,
+			Route:            "traffic-agent echo-7d8f9.default:8081",
+			Started:          timestamppb.New(now.Add(-2 * time.Second)),
+			RoundTripLatency: durationpb.New(3 * time.Millisecond),
+		},
+	}
+	buf := bytes.Buffer{}
+	printConnections(&buf, cs, now)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^PROTO\s+SOURCE\s+DESTINATION\s+NAME\s+ROUTE\s+IN\s+OUT\s+AGE\s+RTT$`, lines[0])
+	assert.Regexp(t, `^tcp\s+10\.0\.0\.1:53000\s+10\.96\.0\.12:80\s+svc/echo\.default\s+traffic-manager\s+2\.0KiB\s+12B\s+1m30s\s+1\.5ms$`, lines[1])
+	assert.Regexp(t, `^udp\s+\[fd00::1\]:42000\s+\[fd00::2\]:8080\s+traffic-agent echo-7d8f9\.default:8081\s+0B\s+0B\s+2s\s+3ms$`, lines[2])
+
+	// Columns are aligned
+	assert.Equal(t, strings.Index(lines[0], "ROUTE"), strings.Index(lines[1], "traffic-manager"))
+	assert.Equal(t, strings.Index(lines[0], "ROUTE"), strings.Index(lines[2], "traffic-agent"))
+
+	buf.Reset()
+	printConnections(&buf, nil, now)
+	assert.Equal(t, "No active connections\n", buf.String())
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+)
+
+func ingestCmd() *cobra.Command {
+	ic := &intercept.Command{}
+	cmd := &cobra.Command{
+		Use:   "ingest [flags] <workload> [-- <command with arguments...>]",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Ingest the environment and volume mounts of a workload container",
+		Long: `Ingest the environment and volume mounts of a workload container without intercepting any traffic.
+
+The traffic-agent is injected into the workload if needed. The environment of the container can be written
+to a file using --env-file or --env-json, or passed to a command or a --docker-run container, and its volumes
+are mounted locally. Outbound connections to the cluster work as usual, but no inbound traffic is ever routed
+to the local machine. The ingest is removed using "telepresence leave <workload>".`,
+		Annotations: map[string]string{
+			ann.Session:           ann.Required,
+			ann.UpdateCheckFormat: ann.Tel2,
+		},
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		RunE:              ic.Run,
+		ValidArgsFunction: ic.ValidArgs,
+	}
+	ic.AddIngestFlags(cmd)
+	return cmd
+}
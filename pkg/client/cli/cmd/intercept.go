@@ -23,5 +23,6 @@ func interceptCmd() *cobra.Command {
 		ValidArgsFunction: ic.ValidArgs,
 	}
 	ic.AddFlags(cmd)
+	cmd.AddCommand(interceptUpdate(), interceptPause(), interceptResume(), interceptHandover(), interceptAccept())
 	return cmd
 }
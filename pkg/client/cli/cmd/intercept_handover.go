@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func interceptHandover() *cobra.Command {
+	var to string
+	var withdraw bool
+	cmd := &cobra.Command{
+		Use:   "handover <intercept_name> --to <client>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Hand over an intercept to another client",
+		Long: `Offer an intercept to another client that is connected to the same traffic-manager. The intercept
+remains yours until the other client accepts it using "telepresence intercept accept". The client is
+identified by its name, which typically is "user@hostname". The offer is bound to the session that the
+client is connected with, so only that session can accept it.`,
+		Example: `  telepresence intercept handover echo --to alice@alice-laptop
+  telepresence intercept handover echo --withdraw`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if withdraw == (to != "") {
+				return errcat.User.New("exactly one of --to or --withdraw must be given")
+			}
+			return updateIntercept(cmd, &manager.UpdateInterceptRequest{Name: strings.TrimSpace(args[0]), HandoverTo: &to})
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&to, "to", "", "Name of the `client` that the intercept is handed over to")
+	flags.BoolVar(&withdraw, "withdraw", false, "Withdraw a handover that hasn't been accepted yet")
+	return cmd
+}
+
+type interceptAcceptCommand struct {
+	from    string
+	port    uint16
+	address string
+}
+
+func interceptAccept() *cobra.Command {
+	ac := &interceptAcceptCommand{}
+	cmd := &cobra.Command{
+		Use:   "accept [flags] <intercept_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Accept an intercept that has been handed over by another client",
+		Long: `Take over an intercept that another client has offered using "telepresence intercept handover". The
+intercept isn't removed, so the traffic-agent switches over to this client without dropping any traffic.
+Connections that are in progress when the handover happens are retained by the previous client.`,
+		Example: `  telepresence intercept accept echo --port 8080
+  telepresence intercept accept echo --from bob@bob-laptop`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE:         ac.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&ac.from, "from", "", "Name of the `client` that handed over the intercept. "+
+		"Only required when more than one client has handed over an intercept with the given name")
+	flags.Uint16Var(&ac.port, "port", 0, "Local `port` that the intercepted traffic is sent to. Defaults to the port used by the previous client")
+	flags.StringVar(&ac.address, "address", "", "Local IP `address` that the intercepted traffic is sent to. Defaults to the address used by the previous client")
+	return cmd
+}
+
+func (ac *interceptAcceptCommand) acceptRequest(cmd *cobra.Command, name string) (*manager.AcceptInterceptHandoverRequest, error) {
+	su := &manager.InterceptSpecUpdate{}
+	flags := cmd.Flags()
+	if flags.Changed("port") {
+		if ac.port == 0 {
+			return nil, errcat.User.New("--port must be a valid port number")
+		}
+		port := int32(ac.port)
+		su.TargetPort = &port
+	}
+	if flags.Changed("address") {
+		if iputil.Parse(ac.address) == nil {
+			return nil, errcat.User.Newf("--address %s is not a valid IP address", ac.address)
+		}
+		su.TargetHost = &ac.address
+	}
+	ar := &manager.AcceptInterceptHandoverRequest{Name: name, From: ac.from}
+	if su.TargetPort != nil || su.TargetHost != nil {
+		ar.SpecUpdate = su
+	}
+	return ar, nil
+}
+
+func (ac *interceptAcceptCommand) run(cmd *cobra.Command, args []string) error {
+	ar, err := ac.acceptRequest(cmd, strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+	if err = connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	ii, err := daemon.GetUserClient(ctx).AcceptInterceptHandover(ctx, ar)
+	return printUpdatedIntercept(cmd, ii, err)
+}
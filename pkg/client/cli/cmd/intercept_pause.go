@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+)
+
+func interceptPause() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause <intercept_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Pause an active intercept",
+		Long: `Temporarily send the intercepted traffic to the application container in the cluster. The intercept
+isn't removed, so its environment, mounts, and name are retained until it is resumed.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setInterceptPaused(cmd, args[0], true)
+		},
+	}
+}
+
+func interceptResume() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume <intercept_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Resume a paused intercept",
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setInterceptPaused(cmd, args[0], false)
+		},
+	}
+}
+
+func setInterceptPaused(cmd *cobra.Command, name string, paused bool) error {
+	return updateIntercept(cmd, &manager.UpdateInterceptRequest{Name: strings.TrimSpace(name), Paused: &paused})
+}
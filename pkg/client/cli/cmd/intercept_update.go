@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+type interceptUpdateCommand struct {
+	ports       []string
+	address     string
+	httpHeaders []string
+}
+
+func interceptUpdate() *cobra.Command {
+	uc := &interceptUpdateCommand{}
+	cmd := &cobra.Command{
+		Use:   "update [flags] <intercept_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Update an existing intercept",
+		Long: `Change the local target of an existing intercept. The intercept isn't removed, so
+connections that are in progress when the update happens are retained, and volume mounts
+aren't affected. New connections use the updated target.
+
+A --port without a svcPortIdentifier retargets the first port of the intercept. The other
+ports of an intercept that intercepts several ports are retargeted using --port
+<local port>:<svcPortIdentifier>.`,
+		Example: `  telepresence intercept update echo --port 8081
+  telepresence intercept update echo --port 8081 --port 9091:metrics
+  telepresence intercept update echo --address 10.0.0.1`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE:         uc.run,
+	}
+	uc.addFlags(cmd.Flags())
+	return cmd
+}
+
+func (uc *interceptUpdateCommand) addFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&uc.ports, "port", nil, ``+
+		"New local port that the intercepted traffic is sent to, using the format <local port>[:<svcPortIdentifier>]. "+
+		"Can be repeated to retarget several ports of the intercept")
+	flags.StringVar(&uc.address, "address", "", "New local IP `address` that the intercepted traffic is sent to")
+	flags.StringArrayVar(&uc.httpHeaders, "http-header", nil,
+		"Only intercept traffic that matches this \"HTTP2_HEADER=REGEXP\" specifier. Not supported by the tcp "+
+			"mechanism, which intercepts all traffic")
+}
+
+func (uc *interceptUpdateCommand) updateRequest(flags *pflag.FlagSet, name string) (*manager.UpdateInterceptRequest, error) {
+	su := &manager.InterceptSpecUpdate{}
+	for _, portSpec := range uc.ports {
+		pp, spi, _ := strings.Cut(portSpec, ":")
+		local, err := agentconfig.ParseNumericPort(pp)
+		if err != nil {
+			return nil, errcat.User.Newf("--port %s must be a valid port number, optionally followed by :<svcPortIdentifier>", portSpec)
+		}
+		port := int32(local)
+		switch {
+		case spi != "":
+			if _, ok := su.TargetPorts[spi]; ok {
+				return nil, errcat.User.Newf("port %s is retargeted more than once", spi)
+			}
+			if su.TargetPorts == nil {
+				su.TargetPorts = make(map[string]int32)
+			}
+			su.TargetPorts[spi] = port
+		case su.TargetPort != nil:
+			return nil, errcat.User.New("only one --port can omit the svcPortIdentifier")
+		default:
+			su.TargetPort = &port
+		}
+	}
+	if flags.Changed("address") {
+		if iputil.Parse(uc.address) == nil {
+			return nil, errcat.User.Newf("--address %s is not a valid IP address", uc.address)
+		}
+		su.TargetHost = &uc.address
+	}
+	if flags.Changed("http-header") {
+		// The tcp mechanism, which is the only mechanism of this client, intercepts all traffic.
+		return nil, errcat.User.New("--http-header cannot be used, because the tcp mechanism intercepts all traffic " +
+			"and cannot match HTTP headers")
+	}
+	if su.TargetPort == nil && su.TargetHost == nil && len(su.TargetPorts) == 0 {
+		return nil, errcat.User.New("at least one of --port or --address must be given")
+	}
+	return &manager.UpdateInterceptRequest{Name: name, SpecUpdate: su}, nil
+}
+
+func (uc *interceptUpdateCommand) run(cmd *cobra.Command, args []string) error {
+	ur, err := uc.updateRequest(cmd.Flags(), strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+	return updateIntercept(cmd, ur)
+}
+
+// updateIntercept sends the given request to the user daemon and prints the updated intercept.
+func updateIntercept(cmd *cobra.Command, ur *manager.UpdateInterceptRequest) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	ii, err := daemon.GetUserClient(ctx).UpdateIntercept(ctx, ur)
+	return printUpdatedIntercept(cmd, ii, err)
+}
+
+// printUpdatedIntercept prints the given intercept, or returns the given error, categorized as a user error
+// when the daemon rejected the request.
+func printUpdatedIntercept(cmd *cobra.Command, ii *manager.InterceptInfo, err error) error {
+	ctx := cmd.Context()
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.NotFound, codes.AlreadyExists, codes.InvalidArgument, codes.FailedPrecondition, codes.Unimplemented:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+	info := intercept.NewInfo(ctx, ii, "")
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, info, false)
+	} else {
+		out := cmd.OutOrStdout()
+		_, _ = info.WriteTo(out)
+		_, _ = fmt.Fprintln(out)
+	}
+	return nil
+}
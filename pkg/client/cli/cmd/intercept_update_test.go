@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_interceptUpdateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		port    *int32
+		ports   map[string]int32
+		host    *string
+		wantErr string
+	}{
+		{
+			name:    "nothing",
+			wantErr: "at least one of",
+		},
+		{
+			name: "port",
+			args: []string{"--port", "8081"},
+			port: func() *int32 { p := int32(8081); return &p }(),
+		},
+		{
+			name:    "zero port",
+			args:    []string{"--port", "0"},
+			wantErr: "valid port number",
+		},
+		{
+			name:  "several ports",
+			args:  []string{"--port", "8081", "--port", "9091:metrics"},
+			port:  func() *int32 { p := int32(8081); return &p }(),
+			ports: map[string]int32{"metrics": 9091},
+		},
+		{
+			name:    "two primary ports",
+			args:    []string{"--port", "8081", "--port", "8082"},
+			wantErr: "only one --port",
+		},
+		{
+			name:    "same port twice",
+			args:    []string{"--port", "9091:metrics", "--port", "9092:metrics"},
+			wantErr: "more than once",
+		},
+		{
+			name: "address",
+			args: []string{"--address", "10.0.0.1"},
+			host: func() *string { h := "10.0.0.1"; return &h }(),
+		},
+		{
+			name:    "bad address",
+			args:    []string{"--address", "localhost"},
+			wantErr: "not a valid IP address",
+		},
+		{
+			name:    "headers",
+			args:    []string{"--http-header", "x-user=alice", "--port", "8081"},
+			wantErr: "tcp mechanism intercepts all traffic",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &interceptUpdateCommand{}
+			flags := pflag.NewFlagSet("update", pflag.ContinueOnError)
+			uc.addFlags(flags)
+			require.NoError(t, flags.Parse(tt.args))
+			ur, err := uc.updateRequest(flags, "echo")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "echo", ur.Name)
+			su := ur.SpecUpdate
+			assert.Equal(t, tt.port, su.TargetPort)
+			assert.Equal(t, tt.ports, su.TargetPorts)
+			assert.Equal(t, tt.host, su.TargetHost)
+			assert.Nil(t, su.MechanismArgs)
+		})
+	}
+}
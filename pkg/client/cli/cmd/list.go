@@ -8,9 +8,11 @@ import (
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
@@ -25,6 +27,7 @@ type listCommand struct {
 	onlyIntercepts    bool
 	onlyAgents        bool
 	onlyInterceptable bool
+	onlyQueued        bool
 	debug             bool
 	namespace         string
 	watch             bool
@@ -51,6 +54,7 @@ func list() *cobra.Command {
 	flags.BoolVarP(&s.onlyIntercepts, "intercepts", "i", false, "intercepts only")
 	flags.BoolVarP(&s.onlyAgents, "agents", "a", false, "with installed agents only")
 	flags.BoolVarP(&s.onlyInterceptable, "only-interceptable", "o", true, "interceptable workloads only")
+	flags.BoolVar(&s.onlyQueued, "queue", false, "queued intercepts only, with their position in the queue")
 	flags.BoolVar(&s.debug, "debug", false, "include debugging information")
 	flags.StringVarP(&s.namespace, "namespace", "n", "", "If present, the namespace scope for this CLI request")
 
@@ -96,7 +100,7 @@ func (s *listCommand) list(cmd *cobra.Command, _ []string) error {
 	userD := daemon.GetUserClient(ctx)
 	var filter connector.ListRequest_Filter
 	switch {
-	case s.onlyIntercepts:
+	case s.onlyIntercepts, s.onlyQueued:
 		filter = connector.ListRequest_INTERCEPTS
 	case s.onlyAgents:
 		filter = connector.ListRequest_INSTALLED_AGENTS
@@ -161,9 +165,14 @@ func (s *listCommand) list(cmd *cobra.Command, _ []string) error {
 }
 
 func (s *listCommand) printList(ctx context.Context, workloads []*connector.WorkloadInfo, stdout io.Writer, formattedOut bool) {
+	if s.onlyQueued {
+		workloads = queuedWorkloads(workloads)
+	}
 	if len(workloads) == 0 {
 		if formattedOut {
 			output.Object(ctx, []struct{}{}, false)
+		} else if s.onlyQueued {
+			fmt.Fprintln(stdout, "No queued intercepts")
 		} else {
 			fmt.Fprintln(stdout, "No Workloads (Deployments, StatefulSets, or ReplicaSets)")
 		}
@@ -248,3 +257,22 @@ func (s *listCommand) printList(ctx context.Context, workloads []*connector.Work
 		}
 	}
 }
+
+// queuedWorkloads returns the workloads that have queued intercepts, retaining only those intercepts.
+func queuedWorkloads(workloads []*connector.WorkloadInfo) []*connector.WorkloadInfo {
+	var qws []*connector.WorkloadInfo
+	for _, w := range workloads {
+		var qis []*manager.InterceptInfo
+		for _, ii := range w.InterceptInfos {
+			if ii.Disposition == manager.InterceptDispositionType_QUEUED {
+				qis = append(qis, ii)
+			}
+		}
+		if len(qis) > 0 {
+			qw := proto.Clone(w).(*connector.WorkloadInfo)
+			qw.InterceptInfos = qis
+			qws = append(qws, qw)
+		}
+	}
+	return qws
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_queuedWorkloads(t *testing.T) {
+	active := &manager.InterceptInfo{Id: "a:echo", Disposition: manager.InterceptDispositionType_ACTIVE}
+	queued := &manager.InterceptInfo{Id: "b:echo", Disposition: manager.InterceptDispositionType_QUEUED, QueuePosition: 1}
+	workloads := []*connector.WorkloadInfo{
+		{Name: "echo", InterceptInfos: []*manager.InterceptInfo{active, queued}},
+		{Name: "hello", InterceptInfos: []*manager.InterceptInfo{active}},
+		{Name: "idle"},
+	}
+	qws := queuedWorkloads(workloads)
+	require.Len(t, qws, 1)
+	assert.Equal(t, "echo", qws[0].Name)
+	require.Len(t, qws[0].InterceptInfos, 1)
+	assert.Equal(t, queued.Id, qws[0].InterceptInfos[0].Id)
+	assert.Len(t, workloads[0].InterceptInfos, 2, "the given workloads must not be modified")
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/shaper"
+)
+
+type shapeCommand struct {
+	clear bool
+}
+
+func shape() *cobra.Command {
+	s := &shapeCommand{}
+	cmd := &cobra.Command{
+		Use:   "shape [rule...]",
+		Short: "Show or change the traffic shaping of connections to the cluster",
+		Long: `Show or change the rules used when shaping the traffic that is routed to the cluster, in order to
+simulate a slow or unreliable network. Without arguments, the current rules are shown. Arguments
+replace the current rules. Each rule has the form:
+
+  destination=<CIDR, IP, or name>,latency=<duration>,jitter=<duration>,bandwidth=<bytes per second>,loss=<percent>
+
+All keys are optional. A rule without destination applies to all connections, and a name such as
+"echo" or "echo.default" matches a service or pod with that name. The first matching rule is used.
+Latency and jitter are added to each direction, and the bandwidth limit applies to each direction
+of each connection. Lost UDP messages are dropped, and lost TCP messages are delayed as if they
+were retransmitted. Connections that were established when no rules were in effect are not shaped.`,
+		Example: `  telepresence shape destination=echo.default,latency=200ms,jitter=50ms
+  telepresence shape destination=10.96.0.0/12,bandwidth=256Ki,loss=2%
+  telepresence shape --clear`,
+		RunE: s.run,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+	cmd.Flags().BoolVar(&s.clear, "clear", false, "remove all traffic shaping rules")
+	return cmd
+}
+
+func (s *shapeCommand) run(cmd *cobra.Command, args []string) error {
+	if s.clear && len(args) > 0 {
+		return errcat.User.New("--clear cannot be combined with rules")
+	}
+	rules, err := shaper.ParseRules(args)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	if err = connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	userD := daemon.GetUserClient(ctx)
+	if s.clear || len(rules) > 0 {
+		if _, err = userD.SetTrafficShaping(ctx, &rpc.TrafficShapingRules{Rules: rules}); err != nil {
+			return err
+		}
+	}
+	r, err := userD.GetTrafficShaping(ctx, &empty.Empty{})
+	if err != nil {
+		return err
+	}
+	current, err := shaper.RulesFromRPC(r.Rules)
+	if err != nil {
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		ss := make([]string, len(current))
+		for i, r := range current {
+			ss[i] = r.String()
+		}
+		output.Object(ctx, ss, false)
+		return nil
+	}
+	out := cmd.OutOrStdout()
+	if len(current) == 0 {
+		fmt.Fprintln(out, "No traffic shaping")
+		return nil
+	}
+	for _, r := range current {
+		fmt.Fprintln(out, r)
+	}
+	return nil
+}
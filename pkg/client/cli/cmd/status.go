@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
@@ -82,8 +84,12 @@ type TrafficManagerStatus struct {
 }
 
 type ConnectStatusIntercept struct {
-	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
-	Client string `json:"client,omitempty" yaml:"client,omitempty"`
+	Name          string     `json:"name,omitempty" yaml:"name,omitempty"`
+	Client        string     `json:"client,omitempty" yaml:"client,omitempty"`
+	Disposition   string     `json:"disposition,omitempty" yaml:"disposition,omitempty"`
+	QueuePosition int32      `json:"queue_position,omitempty" yaml:"queue_position,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	Expiring      bool       `json:"expiring,omitempty" yaml:"expiring,omitempty"`
 }
 
 const (
@@ -279,10 +285,18 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 		us.KubernetesServer = status.ClusterServer
 		us.KubernetesContext = status.ClusterContext
 		for _, icept := range status.GetIntercepts().GetIntercepts() {
-			us.Intercepts = append(us.Intercepts, ConnectStatusIntercept{
-				Name:   icept.Spec.Name,
-				Client: icept.Spec.Client,
-			})
+			ci := ConnectStatusIntercept{
+				Name:          icept.Spec.Name,
+				Client:        icept.Spec.Client,
+				Disposition:   icept.Disposition.String(),
+				QueuePosition: icept.QueuePosition,
+				Expiring:      icept.Expiring,
+			}
+			if icept.ExpiresAt != nil {
+				expiresAt := icept.ExpiresAt.AsTime()
+				ci.ExpiresAt = &expiresAt
+			}
+			us.Intercepts = append(us.Intercepts, ci)
 		}
 		us.Namespace = status.Namespace
 		us.ManagerNamespace = status.ManagerNamespace
@@ -557,7 +571,20 @@ func (cs *UserDaemonStatus) print(kvf *ioutil.KeyValueFormatter) {
 		subKvf := ioutil.DefaultKeyValueFormatter()
 		subKvf.Indent = "  "
 		for _, intercept := range cs.Intercepts {
-			subKvf.Add(intercept.Name, intercept.Client)
+			desc := intercept.Client
+			switch {
+			case intercept.QueuePosition > 0:
+				desc += fmt.Sprintf(", queued at position %d", intercept.QueuePosition)
+			case intercept.Disposition != "" && intercept.Disposition != manager.InterceptDispositionType_ACTIVE.String():
+				desc += ", " + strings.ToLower(intercept.Disposition)
+			}
+			if intercept.ExpiresAt != nil {
+				desc += ", expires at " + intercept.ExpiresAt.Local().Format(time.DateTime)
+				if intercept.Expiring {
+					desc += " (soon)"
+				}
+			}
+			subKvf.Add(intercept.Name, desc)
 		}
 		subKvf.Println(out)
 	}
@@ -134,8 +134,8 @@ func OnlySubcommands(cmd *cobra.Command, args []string) error {
 
 func WithSubCommands(ctx context.Context) context.Context {
 	return MergeSubCommands(ctx,
-		configCmd(), connectCmd(), gatherLogs(), gatherTraces(), genYAML(), helmCmd(),
-		interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), loglevel(), quit(), statusCmd(),
+		adminCmd(), configCmd(), connectCmd(), connections(), gatherLogs(), gatherTraces(), genYAML(), helmCmd(),
+		ingestCmd(), interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), loglevel(), quit(), shape(), statusCmd(),
 		testVPN(), uninstall(), uploadTraces(), version(), listNamespaces(), listContexts(),
 	)
 }
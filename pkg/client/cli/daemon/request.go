@@ -70,6 +70,10 @@ func InitRequest(cmd *cobra.Command) *Request {
 	nwFlags.StringSliceVar(&cr.AllowConflictingSubnets,
 		"allow-conflicting-subnets", nil, ``+
 			`Comma separated list of CIDR that will be allowed to conflict with local subnets`)
+	nwFlags.StringArrayVar(&cr.TrafficShaping,
+		"shape", nil, ``+
+			`Traffic shaping rule on the form destination=<CIDR, IP, or name>,latency=<duration>,jitter=<duration>,`+
+			`bandwidth=<bytes per second>,loss=<percent>. All keys are optional. Can be repeated`)
 
 	// Docker flags
 	nwFlags.Bool(global.FlagDocker, false, "Start, or connect to, daemon in a docker container")
@@ -1,8 +1,11 @@
 package intercept
 
 import (
+	"math"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -12,13 +15,17 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/flags"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
+	"github.com/telepresenceio/telepresence/v2/pkg/envfile"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
 )
 
 type Command struct {
 	Name           string // Command[0] || `${Command[0]}-${--namespace}` // which depends on a combinationof --workload and --namespace
 	AgentName      string // --workload || Command[0] // only valid if !localOnly
-	Port           string // --port // only valid if !localOnly
+	Port           string // first --port // only valid if !localOnly
+	ports          []string
 	ServiceName    string // --service // only valid if !localOnly
 	Address        string // --address // only valid if !localOnly
 	LocalOnly      bool   // --local-only
@@ -26,17 +33,38 @@ type Command struct {
 
 	Replace bool // whether --replace was passed
 
-	EnvFile  string   // --env-file
-	EnvJSON  string   // --env-json
-	Mount    string   // --mount // "true", "false", or desired mount point // only valid if !localOnly
-	MountSet bool     // whether --mount was passed
-	ToPod    []string // --to-pod
+	Faults []string // --fault
+
+	AdditionalPorts []string // --port flags after the first one
+
+	TTL   time.Duration // --ttl, or computed from --until
+	Until string        // --until
+	Queue bool          // --queue
+
+	Ingest        bool   // true when invoked as "telepresence ingest"
+	ContainerName string // --container // only valid if Ingest
+
+	EnvFile       string   // --env-file
+	EnvJSON       string   // --env-json
+	EnvSyntax     string   // --env-syntax
+	Mount         string   // --mount // "true", "false", or desired mount point // only valid if !localOnly
+	MountSet      bool     // whether --mount was passed
+	MountMode     string   // --mount-mode
+	MountKeep     bool     // --mount-keep
+	MountInclude  []string // --mount-include
+	MountExclude  []string // --mount-exclude
+	MountReadOnly bool     // --mount-readonly
+	ToPod         []string // --to-pod
 
 	DockerRun          bool     // --docker-run
 	DockerBuild        string   // --docker-build DIR | URL
 	DockerBuildOptions []string // --docker-build-opt key=value, // Optional flag to docker build can be repeated (but not comma separated)
 	DockerDebug        string   // --docker-debug DIR | URL
 	DockerMount        string   // --docker-mount // where to mount in a docker container. Defaults to mount unless mount is "true" or "false".
+	DevContainer       string   // --devcontainer FILE
+	DebugPorts         []uint   // --debug-port
+	DockerCompose      string   // --docker-compose FILE
+	ComposeService     string   // --compose-service
 	Cmdline            []string // Command[1:]
 
 	Mechanism      string // --mechanism tcp
@@ -48,11 +76,13 @@ type Command struct {
 func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet := cmd.Flags()
 	flagSet.StringVarP(&a.AgentName, "workload", "w", "", "Name of workload (Deployment, ReplicaSet) to intercept, if different from <name>")
-	flagSet.StringVarP(&a.Port, "port", "p", "", ``+
+	flagSet.StringArrayVarP(&a.ports, "port", "p", nil, ``+
 		`Local port to forward to. If intercepting a service with multiple ports, `+
 		`use <local port>:<svcPortIdentifier>, where the identifier is the port name or port number. `+
 		`With --docker-run and a daemon that doesn't run in docker', use <local port>:<container port> or `+
-		`<local port>:<container port>:<svcPortIdentifier>.`,
+		`<local port>:<container port>:<svcPortIdentifier>. Can be repeated to intercept several ports of the `+
+		`workload, possibly belonging to different containers, with one intercept. Each repeated port must declare `+
+		`its svcPortIdentifier.`,
 	)
 
 	flagSet.StringVar(&a.Address, "address", "127.0.0.1", ``+
@@ -66,8 +96,12 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 		`Declare a local-only intercept for the purpose of getting direct outbound access to the intercept's namespace`)
 
 	flagSet.StringVarP(&a.EnvFile, "env-file", "e", "", ``+
-		`Also emit the remote environment to an env file in Docker Compose format. `+
-		`See https://docs.docker.com/compose/env-file/ for more information on the limitations of this format.`)
+		`Also emit the remote environment to an env file. The format is determined by --env-syntax and defaults to `+
+		`Docker Compose format. See https://docs.docker.com/compose/env-file/ for more information on the `+
+		`limitations of that format.`)
+
+	flagSet.StringVar(&a.EnvSyntax, "env-syntax", envfile.Docker.String(), ``+
+		`The syntax of the file written by --env-file. One of `+strings.Join(envfile.SyntaxNames(), ", "))
 
 	flagSet.StringVarP(&a.EnvJSON, "env-json", "j", "", `Also emit the remote environment to a file as a JSON blob.`)
 
@@ -94,6 +128,26 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.StringArrayVar(&a.DockerBuildOptions, "docker-build-opt", nil,
 		`Option to docker-build in the form key=value, e.g. --docker-build-opt tag=mytag. Can be repeated`)
 
+	flagSet.StringVar(&a.DevContainer, "devcontainer", "", ``+
+		`Write a devcontainer.json to the given file when the --docker-debug container has started. It describes the `+
+		`container's image, mounts, network, and forwarded ports, and names the running container, so that an IDE such `+
+		`as VS Code or JetBrains Gateway can attach to it. The environment, which often contains secrets, is written `+
+		`to an env file in the user's cache directory that the devcontainer.json refers to`)
+
+	flagSet.UintSliceVar(&a.DebugPorts, "debug-port", nil, ``+
+		`A port that a debugger listens to in the --docker-debug container. The port is forwarded to the same `+
+		`port on localhost and added to the forwarded ports of --devcontainer. Can be repeated`)
+
+	flagSet.StringVar(&a.DockerCompose, "docker-compose", "", ``+
+		`Run the Docker Compose project in the given file with "docker compose up", passing arguments after -- to it. `+
+		`The service given by --compose-service handles the intercepted traffic using the intercepted environment and `+
+		`volume mounts. When the daemon runs in docker, all services that don't declare a network_mode share the `+
+		`daemon container's network, and must therefore use distinct ports and reach each other using localhost. `+
+		`Otherwise, the services reach the cluster through the host's network`)
+
+	flagSet.StringVar(&a.ComposeService, "compose-service", "", ``+
+		`The service in the --docker-compose file that handles the intercepted traffic`)
+
 	flagSet.StringVar(&a.DockerMount, "docker-mount", "", ``+
 		`The volume mount point in docker. Defaults to same as "--mount"`)
 
@@ -104,6 +158,27 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.BoolVar(&a.DetailedOutput, "detailed-output", false,
 		`Provide very detailed info about the intercept when used together with --output=json or --output=yaml'`)
 
+	flagSet.StringVar(&a.MountMode, "mount-mode", remotefs.MountModeFUSE, ``+
+		`How remote volumes are made available at the mount point. Use "fuse" to mount them using sshfs or FTP, "sync" `+
+		`to copy them into the mount point and then keep the copy in sync with remote changes, or "sync-rw" to also `+
+		`push local changes to the remote volumes. The sync modes don't require FUSE, need an empty or non-existent `+
+		`mount point, and remove the copied files when the intercept ends, unless --mount-keep is used`)
+
+	flagSet.BoolVar(&a.MountKeep, "mount-keep", false, ``+
+		`Keep the files that --mount-mode=sync or sync-rw copied into the --mount directory when the intercept ends`)
+
+	flagSet.StringArrayVar(&a.MountInclude, "mount-include", nil, ``+
+		`Glob pattern for the remote volume paths to mount, relative to the mount point. A pattern that matches a `+
+		`directory also matches everything below it, e.g. --mount-include /var/run/secrets. Can be repeated. `+
+		`All paths are mounted when no pattern is given`)
+
+	flagSet.StringArrayVar(&a.MountExclude, "mount-exclude", nil, ``+
+		`Glob pattern for remote volume paths to exclude from the mount, relative to the mount point. Takes `+
+		`precedence over --mount-include. Can be repeated`)
+
+	flagSet.BoolVar(&a.MountReadOnly, "mount-readonly", false,
+		`Make the traffic-agent reject all attempts to modify the mounted remote volumes`)
+
 	flagSet.Uint16Var(&a.LocalMountPort, "local-mount-port", 0,
 		`Do not mount remote directories. Instead, expose this port on localhost to an external mounter`)
 
@@ -111,11 +186,59 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 		`Indicates if the traffic-agent should replace application containers in workload pods. `+
 			`The default behavior is for the agent sidecar to be installed alongside existing containers.`)
 
+	flagSet.StringArrayVar(&a.Faults, "fault", nil, ``+
+		`Fault that the traffic-agent injects into a percentage of the intercepted HTTP requests, or connections `+
+		`that don't carry HTTP, in the form <type>[=<value>][:<percentage>]. The type is abort (value is an HTTP status, default 503), delay (value is `+
+		`a duration), or reset, and the percentage defaults to 100, e.g. --fault abort=500:10% or --fault delay=2s:50. `+
+		`Can be repeated`)
+
+	flagSet.DurationVar(&a.TTL, "ttl", 0, ``+
+		`Remove the intercept automatically when this duration has elapsed, e.g. --ttl 30m`)
+
+	flagSet.StringVar(&a.Until, "until", "", ``+
+		`Remove the intercept automatically at this local time, given as HH:MM or as an RFC3339 timestamp, e.g. --until 18:00`)
+
+	flagSet.BoolVar(&a.Queue, "queue", false, ``+
+		`If the workload port is intercepted already, wait in a queue instead of failing. The intercept becomes active `+
+		`when the intercepts before it have ended. The queue is shown by "telepresence list --queue"`)
+
 	// Hide these flags. They are still functional but deprecated. Using them will yield a deprecation message.
 	flagSet.Lookup("local-only").Hidden = true
 	flagSet.Lookup("namespace").Hidden = true
 }
 
+// ingestFlags are the flags of the intercept command that also apply to the ingest command.
+var ingestFlags = []string{ //nolint:gochecknoglobals // constant
+	"env-file",
+	"env-syntax",
+	"env-json",
+	"mount",
+	"mount-mode",
+	"mount-include",
+	"mount-exclude",
+	"mount-readonly",
+	"local-mount-port",
+	"docker-run",
+	"docker-build",
+	"docker-build-opt",
+	"docker-mount",
+	"detailed-output",
+}
+
+// AddIngestFlags adds the flags of the ingest command, which is an intercept command that
+// doesn't intercept any traffic.
+func (a *Command) AddIngestFlags(cmd *cobra.Command) {
+	ic := &cobra.Command{}
+	a.AddFlags(ic)
+	flagSet := cmd.Flags()
+	for _, name := range ingestFlags {
+		flagSet.AddFlag(ic.Flags().Lookup(name))
+	}
+	flagSet.StringVarP(&a.ContainerName, "container", "c", "", ``+
+		`Name of the container to ingest. Defaults to the first container of the workload's pod template`)
+	a.Ingest = true
+}
+
 func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 	flags.DeprecationIfChanged(cmd, "local-only", "use telepresence connect to set the namespace")
 	flags.DeprecationIfChanged(cmd, "namespace", "use telepresence connect to set the namespace")
@@ -140,9 +263,65 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 				return errcat.User.New("a local-only intercept cannot have mounts")
 			}
 		}
+		if a.HasMountPolicy() {
+			return errcat.User.New("a local-only intercept cannot have mounts")
+		}
+		if len(a.Faults) > 0 {
+			return errcat.User.New("a local-only intercept cannot have faults")
+		}
+		if a.TTL != 0 || a.Until != "" {
+			return errcat.User.New("a local-only intercept cannot have a --ttl or --until")
+		}
+		if a.Queue {
+			return errcat.User.New("a local-only intercept cannot be queued")
+		}
 		return nil
 	}
+	if a.Until != "" {
+		if a.TTL != 0 {
+			return errcat.User.New("--ttl and --until cannot be used together")
+		}
+		ttl, err := untilTTL(a.Until, time.Now())
+		if err != nil {
+			return errcat.User.New(err)
+		}
+		a.TTL = ttl
+	} else if a.TTL < 0 {
+		return errcat.User.Newf("--ttl %s must not be negative", a.TTL)
+	}
+	if _, err := forwarder.ParseFaults(a.Faults); err != nil {
+		return errcat.User.New(err)
+	}
+
+	switch a.MountMode {
+	case remotefs.MountModeFUSE:
+		if a.MountKeep {
+			return errcat.User.Newf("--mount-keep can only be used with --mount-mode=%s or %s", remotefs.MountModeSync, remotefs.MountModeSyncRW)
+		}
+	case remotefs.MountModeSync, remotefs.MountModeSyncRW:
+		if a.LocalMountPort > 0 {
+			return errcat.User.Newf("--mount-mode=%s cannot be used with --local-mount-port", a.MountMode)
+		}
+		if a.MountMode == remotefs.MountModeSyncRW && a.MountReadOnly {
+			return errcat.User.Newf("--mount-mode=%s cannot be used with --mount-readonly", a.MountMode)
+		}
+		if _, mountPoint := a.GetMountPoint(); a.MountKeep && mountPoint == "" {
+			return errcat.User.New("--mount-keep requires an explicit --mount directory")
+		}
+	default:
+		return errcat.User.Newf("invalid --mount-mode %q, must be one of %s, %s, or %s",
+			a.MountMode, remotefs.MountModeFUSE, remotefs.MountModeSync, remotefs.MountModeSyncRW)
+	}
+
+	if _, err := envfile.ParseSyntax(a.EnvSyntax); err != nil {
+		return errcat.User.New(err)
+	}
 
+	for _, p := range append(a.MountInclude, a.MountExclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return errcat.User.Newf("invalid mount pattern %q: %v", p, err)
+		}
+	}
 	if a.LocalMountPort > 0 && client.GetConfig(cmd.Context()).Intercept().UseFtp {
 		return errcat.User.New("only SFTP can be used with --local-mount-port. Client is configured to perform remote mounts using FTP")
 	}
@@ -151,6 +330,10 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 	if a.AgentName == "" {
 		a.AgentName = a.Name
 	}
+	if len(a.ports) > 0 {
+		a.Port = a.ports[0]
+		a.AdditionalPorts = a.ports[1:]
+	}
 	if a.Port == "" {
 		a.Port = strconv.Itoa(client.GetConfig(cmd.Context()).Intercept().DefaultPort)
 	}
@@ -165,10 +348,30 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 	if a.DockerDebug != "" {
 		drCount++
 	}
+	if a.DockerCompose != "" {
+		drCount++
+		if a.ComposeService == "" {
+			return errcat.User.New("--docker-compose requires --compose-service")
+		}
+	} else if a.ComposeService != "" {
+		return errcat.User.New("--compose-service must be used together with --docker-compose")
+	}
 	if drCount > 1 {
-		return errcat.User.New("only one of --docker-run, --docker-build, or --docker-debug can be used")
+		return errcat.User.New("only one of --docker-run, --docker-build, --docker-debug, or --docker-compose can be used")
 	}
 	a.DockerRun = drCount == 1
+	if a.Queue && (len(a.Cmdline) > 0 || a.DockerRun || a.EnvFile != "" || a.EnvJSON != "") {
+		// The intercept has no environment while it's queued.
+		return errcat.User.New("--queue cannot be used together with a command, --docker-run, --env-file, or --env-json")
+	}
+	if a.DockerDebug == "" && (a.DevContainer != "" || len(a.DebugPorts) > 0) {
+		return errcat.User.New("--devcontainer and --debug-port must be used together with --docker-debug")
+	}
+	for _, port := range a.DebugPorts {
+		if port == 0 || port > math.MaxUint16 {
+			return errcat.User.Newf("invalid --debug-port %d", port)
+		}
+	}
 	if a.DockerRun {
 		if err := a.ValidateDockerArgs(); err != nil {
 			return err
@@ -236,6 +439,11 @@ func (a *Command) ValidArgs(cmd *cobra.Command, args []string, toComplete string
 	return list, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
 }
 
+// HasMountPolicy returns true if the command limits what's mounted, or how.
+func (a *Command) HasMountPolicy() bool {
+	return a.MountReadOnly || len(a.MountInclude) > 0 || len(a.MountExclude) > 0
+}
+
 // GetMountPoint returns a boolean indicating if mounts are enabled or not, and path
 // indicating a mount point.
 func (a *Command) GetMountPoint() (bool, string) {
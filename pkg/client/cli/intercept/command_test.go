@@ -0,0 +1,30 @@
+package intercept
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddIngestFlags(t *testing.T) {
+	ic := &Command{}
+	cmd := &cobra.Command{}
+	ic.AddIngestFlags(cmd)
+	assert.True(t, ic.Ingest)
+
+	flags := cmd.Flags()
+	for _, name := range ingestFlags {
+		assert.NotNil(t, flags.Lookup(name), name)
+	}
+	for _, name := range []string{"port", "service", "address", "fault", "queue", "ttl", "mechanism"} {
+		assert.Nil(t, flags.Lookup(name), name)
+	}
+
+	require.NoError(t, flags.Parse([]string{"--container", "app", "--env-file", "x.env", "--mount=false"}))
+	assert.Equal(t, "app", ic.ContainerName)
+	assert.Equal(t, "x.env", ic.EnvFile)
+	assert.Equal(t, "false", ic.Mount)
+	assert.Equal(t, "tcp", ic.Mechanism, "defaults of the intercept flags must apply")
+}
@@ -0,0 +1,238 @@
+package intercept
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+// composeNetworkKeys are the service keys that conflict with a network_mode that shares the network of another
+// container.
+var composeNetworkKeys = []string{ //nolint:gochecknoglobals // this is a constant
+	"dns",
+	"dns_opt",
+	"dns_search",
+	"domainname",
+	"expose",
+	"extra_hosts",
+	"hostname",
+	"links",
+	"mac_address",
+	"networks",
+	"ports",
+}
+
+// composeRewrite describes how a compose project is rewritten so that its services run with the intercepted
+// environment and reach the cluster.
+type composeRewrite struct {
+	// service is the name of the compose service that handles the intercepted traffic.
+	service string
+
+	// containerName is the container name given to the service unless it already declares one.
+	containerName string
+
+	// daemonContainer is the name of the daemon container when the daemon runs in docker. All services that
+	// don't declare their own network_mode will then share its network.
+	daemonContainer string
+
+	// envFile is added to the env_file list of the service.
+	envFile string
+
+	// ports are added to the port mappings of the service.
+	ports []string
+
+	// volumes are added to the volumes of the service, each in the form <source>:<target>.
+	volumes []string
+
+	// externalVolumes are volume names that must be declared as external volumes in the project.
+	externalVolumes []string
+}
+
+// loadComposeFile reads the given compose file and verifies that it declares the given service.
+func loadComposeFile(file, service string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errcat.User.New(err)
+	}
+	var project map[string]any
+	if err = yaml.Unmarshal(data, &project); err != nil {
+		return nil, errcat.User.Newf("unable to parse compose file %s: %w", file, err)
+	}
+	services, _ := project["services"].(map[string]any)
+	if _, ok := services[service]; !ok {
+		names := make([]string, 0, len(services))
+		for name := range services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errcat.User.Newf("compose file %s has no service %q. Available services are %v", file, service, names)
+	}
+	return project, nil
+}
+
+// rewrite modifies the given compose project in place.
+func (cr *composeRewrite) rewrite(project map[string]any) error {
+	services, _ := project["services"].(map[string]any)
+	for name, sv := range services {
+		svc, ok := sv.(map[string]any)
+		if !ok {
+			if sv != nil {
+				return errcat.User.Newf("compose service %q is not a mapping", name)
+			}
+			svc = make(map[string]any)
+			services[name] = svc
+		}
+		if _, ok := svc["network_mode"]; ok && name != cr.service {
+			// The service has explicitly chosen its network.
+			continue
+		}
+		if cr.daemonContainer != "" {
+			for _, key := range composeNetworkKeys {
+				delete(svc, key)
+			}
+			svc["network_mode"] = "container:" + cr.daemonContainer
+		} else {
+			delete(svc, "network_mode")
+			appendComposeList(svc, "dns_search", "tel2-search")
+		}
+		if name != cr.service {
+			continue
+		}
+		if _, ok := svc["container_name"]; !ok && cr.containerName != "" {
+			svc["container_name"] = cr.containerName
+		}
+		if cr.envFile != "" {
+			appendComposeList(svc, "env_file", cr.envFile)
+		}
+		appendComposeList(svc, "ports", cr.ports...)
+		appendComposeList(svc, "volumes", cr.volumes...)
+	}
+
+	if len(cr.externalVolumes) > 0 {
+		vols, _ := project["volumes"].(map[string]any)
+		if vols == nil {
+			vols = make(map[string]any)
+			project["volumes"] = vols
+		}
+		for _, vol := range cr.externalVolumes {
+			vols[vol] = map[string]any{"external": true}
+		}
+	}
+	return nil
+}
+
+// appendComposeList appends values to a service key that may be declared as a single string or as a list.
+func appendComposeList(svc map[string]any, key string, values ...string) {
+	if len(values) == 0 {
+		return
+	}
+	var list []any
+	switch ev := svc[key].(type) {
+	case []any:
+		list = ev
+	case nil:
+	default:
+		list = []any{ev}
+	}
+	for _, v := range values {
+		list = append(list, v)
+	}
+	svc[key] = list
+}
+
+func (s *state) prepareDockerCompose() error {
+	project, err := loadComposeFile(s.DockerCompose, s.ComposeService)
+	if err != nil {
+		return err
+	}
+	s.composeProject = project
+	return nil
+}
+
+// startDockerCompose rewrites the compose project so that the intercepted service uses the intercepted environment
+// and volumes, and then runs "docker compose up" with the given arguments.
+func (s *state) startDockerCompose(ctx context.Context, envFile string, args []string) *dockerRun {
+	dr := &dockerRun{}
+	ud := daemon.GetUserClient(ctx)
+
+	absFile, err := filepath.Abs(s.DockerCompose)
+	if err != nil {
+		dr.err = err
+		return dr
+	}
+	absEnv, err := filepath.Abs(envFile)
+	if err != nil {
+		dr.err = err
+		return dr
+	}
+	cr := composeRewrite{
+		service:       s.ComposeService,
+		containerName: s.containerName(),
+		envFile:       absEnv,
+	}
+	if !ud.Containerized() {
+		cr.ports = s.dockerPortMappings()
+		if dockerMount := s.dockerMountPoint(ctx); dockerMount != "" {
+			cr.volumes = []string{fmt.Sprintf("%s:%s", s.mountPoint, dockerMount)}
+		}
+	} else {
+		cr.daemonContainer = ud.DaemonID.ContainerName()
+		var mounts []string
+		if dr.volumes, mounts, dr.err = s.startVolumeMounts(ctx, cr.daemonContainer); dr.err != nil {
+			return dr
+		}
+		for i, vol := range dr.volumes {
+			cr.volumes = append(cr.volumes, fmt.Sprintf("%s:%s", vol, mounts[i]))
+		}
+		cr.externalVolumes = dr.volumes
+	}
+	if dr.err = cr.rewrite(s.composeProject); dr.err != nil {
+		return dr
+	}
+	if svc, ok := s.composeProject["services"].(map[string]any)[s.ComposeService].(map[string]any); ok {
+		dr.name, _ = svc["container_name"].(string)
+	}
+
+	data, err := yaml.Marshal(s.composeProject)
+	if err != nil {
+		dr.err = err
+		return dr
+	}
+	file, err := os.CreateTemp("", "tel-compose-*.yaml")
+	if err != nil {
+		dr.err = err
+		return dr
+	}
+	composeFile := file.Name()
+	_, err = file.Write(data)
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		_ = os.Remove(composeFile)
+		dr.err = err
+		return dr
+	}
+	dlog.Debugf(ctx, "Rewrote compose file %s into %s", absFile, composeFile)
+
+	// Relative paths in the rewritten file must still be resolved from the directory of the original file.
+	composeArgs := []string{"compose", "--file", composeFile, "--project-directory", filepath.Dir(absFile)}
+	engine := docker.GetEngine(ctx).Name()
+	dr.down = func(ctx context.Context) error {
+		defer os.Remove(composeFile)
+		return proc.Run(ctx, nil, engine, append(composeArgs, "down")...)
+	}
+	args = append(append(composeArgs, "up"), args...)
+	dr.cmd, dr.err = proc.Start(context.WithoutCancel(ctx), nil, engine, args...)
+	return dr
+}
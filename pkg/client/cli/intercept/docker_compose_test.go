@@ -0,0 +1,103 @@
+package intercept
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testComposeFile = `
+services:
+  api:
+    image: api
+    ports: ["8080:8080"]
+    env_file: api.env
+    networks: [backend]
+  db:
+    image: postgres
+    ports: ["5432:5432"]
+  tool:
+    image: tool
+    network_mode: host
+  empty:
+networks:
+  backend: {}
+`
+
+func loadTestCompose(t *testing.T) map[string]any {
+	file := filepath.Join(t.TempDir(), "compose.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(testComposeFile), 0o600))
+	_, err := loadComposeFile(file, "nope")
+	assert.ErrorContains(t, err, "[api db empty tool]")
+	project, err := loadComposeFile(file, "api")
+	require.NoError(t, err)
+	return project
+}
+
+func composeService(t *testing.T, project map[string]any, name string) map[string]any {
+	svc, ok := project["services"].(map[string]any)[name].(map[string]any)
+	require.True(t, ok, name)
+	return svc
+}
+
+func TestComposeRewrite_containerized(t *testing.T) {
+	project := loadTestCompose(t)
+	cr := composeRewrite{
+		service:         "api",
+		containerName:   "intercept-api-8080",
+		daemonContainer: "tp-kind",
+		envFile:         "/tmp/tel.env",
+		volumes:         []string{"vol-1:/var/run/secrets"},
+		externalVolumes: []string{"vol-1"},
+	}
+	require.NoError(t, cr.rewrite(project))
+
+	api := composeService(t, project, "api")
+	assert.Equal(t, "container:tp-kind", api["network_mode"])
+	assert.Equal(t, "intercept-api-8080", api["container_name"])
+	assert.Equal(t, []any{"api.env", "/tmp/tel.env"}, api["env_file"])
+	assert.Equal(t, []any{"vol-1:/var/run/secrets"}, api["volumes"])
+	assert.NotContains(t, api, "ports")
+	assert.NotContains(t, api, "networks")
+
+	db := composeService(t, project, "db")
+	assert.Equal(t, "container:tp-kind", db["network_mode"])
+	assert.NotContains(t, db, "ports")
+	assert.NotContains(t, db, "env_file")
+
+	assert.Equal(t, "container:tp-kind", composeService(t, project, "empty")["network_mode"])
+	assert.Equal(t, "host", composeService(t, project, "tool")["network_mode"])
+	assert.Equal(t, map[string]any{"vol-1": map[string]any{"external": true}}, project["volumes"])
+
+	// The result must be valid YAML
+	_, err := yaml.Marshal(project)
+	require.NoError(t, err)
+}
+
+func TestComposeRewrite_host(t *testing.T) {
+	project := loadTestCompose(t)
+	cr := composeRewrite{
+		service:       "api",
+		containerName: "intercept-api-8080",
+		envFile:       "/tmp/tel.env",
+		ports:         []string{"8081:8080"},
+		volumes:       []string{"/tmp/telfs:/tmp/telfs"},
+	}
+	require.NoError(t, cr.rewrite(project))
+
+	api := composeService(t, project, "api")
+	assert.NotContains(t, api, "network_mode")
+	assert.Equal(t, []any{"tel2-search"}, api["dns_search"])
+	assert.Equal(t, []any{"8080:8080", "8081:8080"}, api["ports"])
+	assert.Equal(t, []any{"backend"}, api["networks"])
+	assert.Equal(t, []any{"/tmp/telfs:/tmp/telfs"}, api["volumes"])
+
+	db := composeService(t, project, "db")
+	assert.Equal(t, []any{"tel2-search"}, db["dns_search"])
+	assert.Equal(t, []any{"5432:5432"}, db["ports"])
+	assert.NotContains(t, project, "volumes")
+}
@@ -0,0 +1,146 @@
+package intercept
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/envfile"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// devContainer is the subset of the devcontainer.json format (https://containers.dev/implementors/json_reference/)
+// that describes the container started by --docker-debug.
+type devContainer struct {
+	Name           string         `json:"name"`
+	Image          string         `json:"image"`
+	RunArgs        []string       `json:"runArgs,omitempty"`
+	Mounts         []string       `json:"mounts,omitempty"`
+	ForwardPorts   []uint16       `json:"forwardPorts,omitempty"`
+	CapAdd         []string       `json:"capAdd,omitempty"`
+	SecurityOpt    []string       `json:"securityOpt,omitempty"`
+	Customizations map[string]any `json:"customizations,omitempty"`
+}
+
+// devContainerAttach is added to the customizations of the devcontainer.json. It tells the IDE what container to
+// attach to while the intercept is active.
+type devContainerAttach struct {
+	Intercept     string `json:"intercept"`
+	ContainerName string `json:"containerName"`
+}
+
+// newDevContainer returns the devContainer that describes the --docker-debug container. The intercepted environment
+// often contains secrets, so it's referenced using the given env file rather than included in the devcontainer.json.
+func (s *state) newDevContainer(dr *dockerRun, envFile, daemonContainer string) *devContainer {
+	image, _ := firstDockerArg(s.Cmdline)
+	dc := &devContainer{
+		Name:        "telepresence " + s.Name(),
+		Image:       image,
+		CapAdd:      []string{"SYS_PTRACE"},
+		SecurityOpt: []string{"apparmor=unconfined"},
+		Customizations: map[string]any{
+			"telepresence": &devContainerAttach{Intercept: s.Name(), ContainerName: dr.name},
+		},
+	}
+
+	mountType := "bind"
+	if daemonContainer != "" {
+		// The handler shares the network of the daemon container, so it listens to the local port
+		dc.RunArgs = []string{"--network", "container:" + daemonContainer}
+		dc.ForwardPorts = append(dc.ForwardPorts, s.localPort)
+		for _, pm := range s.portMappings {
+			dc.ForwardPorts = append(dc.ForwardPorts, pm.local)
+		}
+		mountType = "volume"
+	} else {
+		dc.RunArgs = []string{"--dns-search", "tel2-search"}
+		if s.dockerPort != 0 {
+			dc.ForwardPorts = append(dc.ForwardPorts, s.dockerPort)
+		}
+		for _, pm := range s.portMappings {
+			if pm.docker != 0 {
+				dc.ForwardPorts = append(dc.ForwardPorts, pm.docker)
+			}
+		}
+	}
+	dc.RunArgs = append(dc.RunArgs, "--env-file", envFile)
+	for _, port := range s.DebugPorts {
+		dc.ForwardPorts = append(dc.ForwardPorts, uint16(port))
+	}
+	for _, m := range dr.mounts {
+		// The source may be a Windows path with a drive letter, so split at the last colon.
+		i := strings.LastIndexByte(m, ':')
+		dc.Mounts = append(dc.Mounts, fmt.Sprintf("source=%s,target=%s,type=%s", m[:i], m[i+1:], mountType))
+	}
+	return dc
+}
+
+// writeDevContainer writes a devcontainer.json that describes the running --docker-debug container to the file
+// given by --devcontainer. The environment is written to a file in the user's cache directory that only the user
+// can read, so that secrets don't end up in the project that the devcontainer.json is part of.
+func (s *state) writeDevContainer(ctx context.Context, dr *dockerRun, env map[string]string) error {
+	var daemonContainer string
+	if ud := daemon.GetUserClient(ctx); ud.Containerized() {
+		daemonContainer = ud.DaemonID.ContainerName()
+	}
+	envDir := filepath.Join(filelocation.AppUserCacheDir(ctx), "devcontainers")
+	if err := os.MkdirAll(envDir, 0o700); err != nil {
+		return err
+	}
+	envFile := filepath.Join(envDir, s.Name()+".env")
+	if err := writeEnvPrivate(envFile, envfile.Docker, env); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s.newDevContainer(dr, envFile, daemonContainer), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.DevContainer), 0o755); err != nil {
+		return err
+	}
+	if err = writePrivate(s.DevContainer, append(data, '\n')); err != nil {
+		return err
+	}
+	ioutil.Printf(output.Info(ctx), "Wrote %s. Attach your IDE to the container %q\n", s.DevContainer, dr.name)
+	return nil
+}
+
+// openPrivate creates or truncates the given file, and ensures that only the user can read and write it, also
+// when it existed before.
+func openPrivate(name string) (*os.File, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.Chmod(0o600); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func writePrivate(name string, data []byte) error {
+	f, err := openPrivate(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+func writeEnvPrivate(name string, syntax envfile.Syntax, env map[string]string) error {
+	f, err := openPrivate(name)
+	if err != nil {
+		return err
+	}
+	return writeEnvToFileAndClose(f, syntax, env)
+}
@@ -0,0 +1,61 @@
+package intercept
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/envfile"
+)
+
+func TestNewDevContainer(t *testing.T) {
+	s := &state{
+		Command: &Command{
+			Name:        "echo",
+			Cmdline:     []string{"-it", "sha256:abc", "/bin/sh"},
+			DockerDebug: ".",
+			DebugPorts:  []uint{2345},
+		},
+		localPort:  8080,
+		dockerPort: 80,
+	}
+	dr := &dockerRun{name: "intercept-echo-8080", mounts: []string{"/tmp/telfs:/var/run/secrets"}}
+	envFile := "/home/me/.cache/telepresence/devcontainers/echo.env"
+
+	dc := s.newDevContainer(dr, envFile, "")
+	assert.Equal(t, "sha256:abc", dc.Image)
+	assert.Equal(t, []string{"--dns-search", "tel2-search", "--env-file", envFile}, dc.RunArgs)
+	assert.Equal(t, []uint16{80, 2345}, dc.ForwardPorts)
+	assert.Equal(t, []string{"source=/tmp/telfs,target=/var/run/secrets,type=bind"}, dc.Mounts)
+
+	dr.mounts = []string{"vol-1:/var/run/secrets"}
+	dc = s.newDevContainer(dr, envFile, "tp-kind")
+	assert.Equal(t, []string{"--network", "container:tp-kind", "--env-file", envFile}, dc.RunArgs)
+	assert.Equal(t, []uint16{8080, 2345}, dc.ForwardPorts)
+	assert.Equal(t, []string{"source=vol-1,target=/var/run/secrets,type=volume"}, dc.Mounts)
+
+	data, err := json.Marshal(dc)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"customizations":{"telepresence":{"intercept":"echo","containerName":"intercept-echo-8080"}}`)
+	assert.NotContains(t, string(data), "containerEnv")
+}
+
+func TestWriteEnvPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not enforced on windows")
+	}
+	name := filepath.Join(t.TempDir(), "echo.env")
+	require.NoError(t, os.WriteFile(name, []byte("OLD=1\n"), 0o644))
+	require.NoError(t, writeEnvPrivate(name, envfile.Docker, map[string]string{"DB_PASSWORD": "secret"}))
+	fi, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "DB_PASSWORD=secret\n", string(data))
+}
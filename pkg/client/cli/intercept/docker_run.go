@@ -108,6 +108,11 @@ type dockerRun struct {
 	err     error
 	name    string
 	volumes []string
+	mounts  []string // the volume mounts of the container in the form <source>:<target>
+
+	// down, when set, stops and removes everything that the run started. It replaces the stop of the
+	// named container and is always called when the run ends.
+	down func(context.Context) error
 }
 
 func (dr *dockerRun) wait(ctx context.Context) error {
@@ -118,6 +123,15 @@ func (dr *dockerRun) wait(ctx context.Context) error {
 			cancel()
 		}()
 	}
+	if dr.down != nil {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 15*time.Second)
+			if err := dr.down(ctx); err != nil {
+				dlog.Error(ctx, err)
+			}
+			cancel()
+		}()
+	}
 
 	if dr.err != nil {
 		return errcat.NoDaemonLogs.New(dr.err)
@@ -143,6 +157,10 @@ func (dr *dockerRun) wait(ctx context.Context) error {
 		signalled.Store(true)
 		// Kill the docker run after a grace period in case it isn't stopped
 		killTimer.Reset(2 * time.Second)
+		if dr.down != nil {
+			// Stopped and removed when the command ends.
+			return
+		}
 		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
 		defer cancel()
 		if err := docker.StopContainer(docker.EnableClient(ctx), dr.name); err != nil {
@@ -162,6 +180,23 @@ func (dr *dockerRun) wait(ctx context.Context) error {
 	return err
 }
 
+// dockerMountPoint returns the directory where the remote volumes are mounted in a container started with
+// --docker-run, or an empty string when the volumes aren't mounted under a common directory.
+func (s *state) dockerMountPoint(ctx context.Context) string {
+	if s.mountPoint == "" || daemon.GetUserClient(ctx).Containerized() {
+		return ""
+	}
+	if s.DockerMount != "" {
+		return s.DockerMount
+	}
+	return s.mountPoint
+}
+
+// containerName returns the name of the container that runs the intercept handler when it isn't named explicitly.
+func (s *state) containerName() string {
+	return fmt.Sprintf("intercept-%s-%d", s.Name(), s.localPort)
+}
+
 func (s *state) startInDocker(ctx context.Context, envFile string, args []string) *dockerRun {
 	ourArgs := []string{
 		"run",
@@ -175,7 +210,7 @@ func (s *state) startInDocker(ctx context.Context, envFile string, args []string
 		return dr
 	}
 	if dr.name == "" {
-		dr.name = fmt.Sprintf("intercept-%s-%d", s.Name(), s.localPort)
+		dr.name = s.containerName()
 		ourArgs = append(ourArgs, "--name", dr.name)
 	}
 	if s.DockerDebug != "" {
@@ -184,17 +219,14 @@ func (s *state) startInDocker(ctx context.Context, envFile string, args []string
 
 	if !ud.Containerized() {
 		ourArgs = append(ourArgs, "--dns-search", "tel2-search")
-		if s.dockerPort != 0 {
-			ourArgs = append(ourArgs, "-p", fmt.Sprintf("%d:%d", s.localPort, s.dockerPort))
+		for _, pm := range s.dockerPortMappings() {
+			ourArgs = append(ourArgs, "-p", pm)
 		}
-		dockerMount := ""
-		if s.mountPoint != "" { // do we have a mount point at all?
-			if dockerMount = s.DockerMount; dockerMount == "" {
-				dockerMount = s.mountPoint
-			}
+		for _, port := range s.DebugPorts {
+			ourArgs = append(ourArgs, "-p", fmt.Sprintf("%d:%d", port, port))
 		}
-		if dockerMount != "" {
-			ourArgs = append(ourArgs, "-v", fmt.Sprintf("%s:%s", s.mountPoint, dockerMount))
+		if dockerMount := s.dockerMountPoint(ctx); dockerMount != "" {
+			dr.mounts = append(dr.mounts, fmt.Sprintf("%s:%s", s.mountPoint, dockerMount))
 		}
 	} else {
 		daemonName := ud.DaemonID.ContainerName()
@@ -210,26 +242,46 @@ func (s *state) startInDocker(ctx context.Context, envFile string, args []string
 		if !set {
 			ourArgs = append(ourArgs, "--rm")
 		}
-		if !(s.mountDisabled || s.info == nil) {
-			m := s.info.Mount
-			if m != nil {
-				if err := docker.EnsureVolumePlugin(ctx); err != nil {
-					ioutil.Printf(output.Err(ctx), "Remote mount disabled: %s\n", err)
-				}
-				container := s.env["TELEPRESENCE_CONTAINER"]
-				dlog.Infof(ctx, "Mounting %v from container %s", m.Mounts, container)
-				dr.volumes, dr.err = docker.StartVolumeMounts(ctx, daemonName, container, m.Port, m.Mounts, nil)
-				if dr.err != nil {
-					return dr
-				}
-				for i, vol := range dr.volumes {
-					ourArgs = append(ourArgs, "-v", fmt.Sprintf("%s:%s", vol, m.Mounts[i]))
-				}
-			}
+		var mounts []string
+		if dr.volumes, mounts, dr.err = s.startVolumeMounts(ctx, daemonName); dr.err != nil {
+			return dr
 		}
+		for i, vol := range dr.volumes {
+			dr.mounts = append(dr.mounts, fmt.Sprintf("%s:%s", vol, mounts[i]))
+		}
+	}
+	for _, m := range dr.mounts {
+		ourArgs = append(ourArgs, "-v", m)
 	}
 
 	args = append(ourArgs, args...)
-	dr.cmd, dr.err = proc.Start(context.WithoutCancel(ctx), nil, "docker", args...)
+	dr.cmd, dr.err = proc.Start(context.WithoutCancel(ctx), nil, docker.GetEngine(ctx).Name(), args...)
 	return dr
 }
+
+// startVolumeMounts starts one docker volume for each remote mount of the intercept, using the volume plugin that
+// connects to the intercept's SFTP server through the daemon container. It returns the names of the volumes and
+// the mount points that they correspond to. Nothing is mounted, and a warning is printed, when the container
+// engine doesn't support volume plugins.
+func (s *state) startVolumeMounts(ctx context.Context, daemonName string) ([]string, []string, error) {
+	if s.mountDisabled || s.info == nil || s.info.Mount == nil {
+		return nil, nil, nil
+	}
+	m := s.info.Mount
+	if engine := docker.GetEngine(ctx); !engine.VolumePlugins() {
+		ioutil.Printf(output.Err(ctx), "Warning: the remote volumes are not mounted in the container, because the %s "+
+			"container engine doesn't support volume plugins. Connect without --docker to have them bind-mounted "+
+			"from a local mount instead, or use --mount=false to silence this warning.\n", engine.Name())
+		return nil, nil, nil
+	}
+	if err := docker.EnsureVolumePlugin(ctx); err != nil {
+		return nil, nil, fmt.Errorf("unable to mount remote volumes, use --mount=false to run without them: %w", err)
+	}
+	container := s.remoteEnv["TELEPRESENCE_CONTAINER"]
+	dlog.Infof(ctx, "Mounting %v from container %s", m.Mounts, container)
+	vols, err := docker.StartVolumeMounts(ctx, daemonName, container, m.Port, m.Mounts, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vols, m.Mounts, nil
+}
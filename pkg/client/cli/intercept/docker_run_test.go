@@ -0,0 +1,27 @@
+package intercept
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+)
+
+func TestStartVolumeMounts_noVolumePlugins(t *testing.T) {
+	cfg := client.GetDefaultConfig()
+	cfg.Docker().Engine = client.ContainerEnginePodman
+	stderr := &strings.Builder{}
+	ctx := dos.WithStderr(client.WithConfig(dlog.NewTestContext(t, false), cfg), stderr)
+
+	s := &state{info: &Info{Mount: &Mount{Port: 2222, Mounts: []string{"/var/run/secrets"}}}}
+	vols, mounts, err := s.startVolumeMounts(ctx, "tp-kind")
+	require.NoError(t, err)
+	assert.Empty(t, vols)
+	assert.Empty(t, mounts)
+	assert.Contains(t, stderr.String(), "podman container engine doesn't support volume plugins")
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
 	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 )
 
@@ -26,6 +29,16 @@ type Mount struct {
 	PodIP     string   `json:"pod_ip,omitempty"        yaml:"pod_ip,omitempty"`
 	Port      int32    `json:"port,omitempty"          yaml:"port,omitempty"`
 	Mounts    []string `json:"mounts,omitempty"        yaml:"mounts,omitempty"`
+	Include   []string `json:"include,omitempty"       yaml:"include,omitempty"`
+	Exclude   []string `json:"exclude,omitempty"       yaml:"exclude,omitempty"`
+	ReadOnly  bool     `json:"read_only,omitempty"     yaml:"read_only,omitempty"`
+}
+
+// Port is an additional port of an intercept.
+type Port struct {
+	ServicePortID string `json:"service_port_id,omitempty" yaml:"service_port_id,omitempty"`
+	TargetPort    int32  `json:"target_port,omitempty"     yaml:"target_port,omitempty"`
+	ContainerName string `json:"container_name,omitempty"  yaml:"container_name,omitempty"`
 }
 
 type Info struct {
@@ -45,6 +58,14 @@ type Info struct {
 	Global        bool              `json:"global,omitempty"          yaml:"global,omitempty"`
 	PreviewURL    string            `json:"preview_url,omitempty"     yaml:"preview_url,omitempty"`
 	Ingress       *Ingress          `json:"ingress,omitempty"         yaml:"ingress,omitempty"`
+	Faults        []string          `json:"faults,omitempty"          yaml:"faults,omitempty"`
+	HandoverTo    string            `json:"handover_to,omitempty"     yaml:"handover_to,omitempty"`
+	QueuePosition int32             `json:"queue_position,omitempty"  yaml:"queue_position,omitempty"`
+	ExpiresAt     *time.Time        `json:"expires_at,omitempty"      yaml:"expires_at,omitempty"`
+	Expiring      bool              `json:"expiring,omitempty"        yaml:"expiring,omitempty"`
+	Ingest        bool              `json:"ingest,omitempty"          yaml:"ingest,omitempty"`
+	ContainerName string            `json:"container_name,omitempty"  yaml:"container_name,omitempty"`
+	Ports         []*Port           `json:"ports,omitempty"           yaml:"ports,omitempty"`
 	debug         bool
 }
 
@@ -93,6 +114,9 @@ func NewMount(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 			PodIP:     ii.PodIp,
 			Port:      port,
 			Mounts:    mounts,
+			Include:   ii.Spec.MountInclude,
+			Exclude:   ii.Spec.MountExclude,
+			ReadOnly:  ii.Spec.MountReadOnly,
 		}
 	}
 	return nil
@@ -100,7 +124,7 @@ func NewMount(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 
 func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string) *Info {
 	spec := ii.Spec
-	return &Info{
+	info := &Info{
 		ID:            ii.Id,
 		Name:          spec.Name,
 		Disposition:   ii.Disposition.String(),
@@ -117,7 +141,36 @@ func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		Global:        spec.Mechanism == "tcp",
 		PreviewURL:    PreviewURL(ii.PreviewDomain),
 		Ingress:       NewIngress(ii.PreviewSpec),
+		Faults:        describeFaults(spec.Faults),
+		HandoverTo:    ii.HandoverTo,
+		QueuePosition: ii.QueuePosition,
+		Ingest:        spec.Ingest,
+		ContainerName: spec.ContainerName,
+	}
+	for _, ap := range spec.AdditionalPorts {
+		info.Ports = append(info.Ports, &Port{
+			ServicePortID: ap.ServicePortIdentifier,
+			TargetPort:    ap.TargetPort,
+			ContainerName: ap.ContainerName,
+		})
+	}
+	if ii.ExpiresAt != nil {
+		expiresAt := ii.ExpiresAt.AsTime()
+		info.ExpiresAt = &expiresAt
+		info.Expiring = ii.Expiring
+	}
+	return info
+}
+
+func describeFaults(fis []*manager.FaultInjection) []string {
+	if len(fis) == 0 {
+		return nil
+	}
+	fs := make([]string, len(fis))
+	for i, fi := range fis {
+		fs[i] = forwarder.DescribeFault(fi)
 	}
+	return fs
 }
 
 func (ii *Info) WriteTo(w io.Writer) (int64, error) {
@@ -126,7 +179,8 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 	kvf.Add("Intercept name", ii.Name)
 	kvf.Add("State", func() string {
 		msg := ""
-		if manager.InterceptDispositionType_value[ii.Disposition] > int32(manager.InterceptDispositionType_WAITING) {
+		if d := manager.InterceptDispositionType_value[ii.Disposition]; d > int32(manager.InterceptDispositionType_WAITING) &&
+			d != int32(manager.InterceptDispositionType_PAUSED) && d != int32(manager.InterceptDispositionType_QUEUED) {
 			msg += "error: "
 		}
 		msg += ii.Disposition
@@ -141,14 +195,22 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 		kvf.Add("ID", ii.ID)
 	}
 
-	kvf.Add(
-		"Destination",
-		net.JoinHostPort(ii.TargetHost, fmt.Sprintf("%d", ii.TargetPort)),
-	)
+	if ii.Ingest {
+		kvf.Add("Container", ii.ContainerName)
+	} else {
+		kvf.Add(
+			"Destination",
+			net.JoinHostPort(ii.TargetHost, fmt.Sprintf("%d", ii.TargetPort)),
+		)
+	}
 
 	if ii.ServicePortID != "" {
 		kvf.Add("Service Port Identifier", ii.ServicePortID)
 	}
+	for _, p := range ii.Ports {
+		kvf.Add("Additional Port", fmt.Sprintf("%s -> %s in container %s",
+			p.ServicePortID, net.JoinHostPort(ii.TargetHost, strconv.Itoa(int(p.TargetPort))), p.ContainerName))
+	}
 	if ii.debug {
 		m := "http"
 		if ii.Global {
@@ -161,21 +223,55 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 
 	if m := ii.Mount; m != nil {
 		if m.LocalDir != "" {
-			kvf.Add("Volume Mount Point", m.LocalDir)
+			if m.ReadOnly {
+				kvf.Add("Volume Mount Point", m.LocalDir+" (read-only)")
+			} else {
+				kvf.Add("Volume Mount Point", m.LocalDir)
+			}
+			if len(m.Include) > 0 {
+				kvf.Add("Volume Mount Include", strings.Join(m.Include, ", "))
+			}
+			if len(m.Exclude) > 0 {
+				kvf.Add("Volume Mount Exclude", strings.Join(m.Exclude, ", "))
+			}
 		} else if m.Error != "" {
 			kvf.Add("Volume Mount Error", m.Error)
 		}
 	}
 
-	kvf.Add("Intercepting", func() string {
-		if ii.FilterDesc != "" {
-			return ii.FilterDesc
-		}
-		if ii.Global {
-			return `using mechanism "tcp"`
+	if ii.Ingest {
+		kvf.Add("Ingesting", "environment and mounts only, no traffic is intercepted")
+	} else {
+		kvf.Add("Intercepting", func() string {
+			if ii.FilterDesc != "" {
+				return ii.FilterDesc
+			}
+			if ii.Global {
+				return `using mechanism "tcp"`
+			}
+			return fmt.Sprintf("using mechanism=%q with args=%q", "http", ii.HttpFilter)
+		}())
+	}
+
+	if len(ii.Faults) > 0 {
+		kvf.Add("Injecting faults", strings.Join(ii.Faults, ", "))
+	}
+
+	if ii.QueuePosition > 0 {
+		kvf.Add("Queue position", strconv.Itoa(int(ii.QueuePosition)))
+	}
+
+	if ii.ExpiresAt != nil {
+		expiresAt := ii.ExpiresAt.Local().Format(time.DateTime)
+		if ii.Expiring {
+			expiresAt += " (soon)"
 		}
-		return fmt.Sprintf("using mechanism=%q with args=%q", "http", ii.HttpFilter)
-	}())
+		kvf.Add("Expires at", expiresAt)
+	}
+
+	if ii.HandoverTo != "" {
+		kvf.Add("Handed over to", ii.HandoverTo+" (not yet accepted)")
+	}
 
 	if ii.PreviewURL != "" {
 		previewURL := ii.PreviewURL
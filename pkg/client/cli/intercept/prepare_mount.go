@@ -0,0 +1,47 @@
+package intercept
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// PrepareSyncDir returns the absolute path of the directory used by a sync mount, after ensuring that
+// it exists, and true if the directory was created. A temporary directory is created when dir is empty.
+// Unlike a FUSE mount point, this is always a directory, also on Windows. An existing directory must be
+// empty, because its content would otherwise be mixed with, and in sync-rw mode pushed to, the remote
+// volumes.
+func PrepareSyncDir(cwd string, dir string) (string, bool, error) {
+	if dir == "" {
+		dir, err := os.MkdirTemp("", "telfs-")
+		return dir, err == nil, err
+	}
+
+	// filepath.Abs uses os.Getwd but we need the working dir of the cli
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cwd, dir)
+		dir = filepath.Clean(dir)
+	}
+
+	f, err := os.Open(dir)
+	switch {
+	case err == nil:
+		_, err = f.Readdirnames(1)
+		_ = f.Close()
+		if err == nil {
+			return "", false, errcat.User.Newf("sync mount directory %s is not empty", dir)
+		}
+		if !errors.Is(err, io.EOF) {
+			return "", false, err
+		}
+		return dir, false, nil
+	case errors.Is(err, os.ErrNotExist):
+		err = os.MkdirAll(dir, 0o700)
+		return dir, err == nil, err
+	default:
+		return "", false, err
+	}
+}
@@ -0,0 +1,29 @@
+package intercept
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareSyncDir(t *testing.T) {
+	cwd := t.TempDir()
+
+	dir, created, err := PrepareSyncDir(cwd, "new")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, filepath.Join(cwd, "new"), dir)
+	assert.DirExists(t, dir)
+
+	// An existing empty directory is fine.
+	dir, created, err = PrepareSyncDir(cwd, dir)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local.txt"), []byte("local"), 0o600))
+	_, _, err = PrepareSyncDir(cwd, "new")
+	assert.ErrorContains(t, err, "is not empty")
+}
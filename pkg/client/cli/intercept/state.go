@@ -1,20 +1,20 @@
 package intercept
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"runtime"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 	core "k8s.io/api/core/v1"
 
@@ -27,10 +27,14 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnet"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/envfile"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 )
@@ -46,14 +50,18 @@ type State interface {
 type state struct {
 	*Command
 	cmd           *cobra.Command
-	env           map[string]string
+	env           map[string]string // the environment after applying the configured transforms
+	remoteEnv     map[string]string // the environment of the intercepted container
 	mountDisabled bool
 	mountPoint    string // if non-empty, this the final mount point of a successful mount
 	localPort     uint16 // the parsed <local port>
 	dockerPort    uint16
+	portMappings  []portMapping // the parsed additional ports
 	status        *connector.ConnectInfo
 	info          *Info // Info from the created intercept
 
+	composeProject map[string]any // the parsed --docker-compose file
+
 	// Possibly extended version of the state. Use when calling interface methods.
 	self State
 }
@@ -105,22 +113,65 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 
 	ud := daemon.GetUserClient(ctx)
 
-	// Parse port into spec based on how it's formatted
 	var err error
-	s.localPort, s.dockerPort, spec.ServicePortIdentifier, err = parsePort(s.Port, s.DockerRun, ud.Containerized())
-	if err != nil {
-		return nil, err
+	if s.Ingest {
+		// An ingest has no port, and no target to forward to.
+		spec.Ingest = true
+		spec.ContainerName = s.ContainerName
+	} else {
+		// Parse port into spec based on how it's formatted
+		s.localPort, s.dockerPort, spec.ServicePortIdentifier, err = parsePort(s.Port, s.DockerRun, ud.Containerized())
+		if err != nil {
+			return nil, err
+		}
+		spec.TargetPort = int32(s.localPort)
+		if spec.AdditionalPorts, err = s.parseAdditionalPorts(ud.Containerized()); err != nil {
+			return nil, err
+		}
+		if iputil.Parse(s.Address) == nil {
+			return nil, fmt.Errorf("--address %s is not a valid IP address", s.Address)
+		}
+		spec.TargetHost = s.Address
+		if spec.Faults, err = forwarder.ParseFaults(s.Faults); err != nil {
+			return nil, errcat.User.New(err)
+		}
 	}
-	spec.TargetPort = int32(s.localPort)
-	if iputil.Parse(s.Address) == nil {
-		return nil, fmt.Errorf("--address %s is not a valid IP address", s.Address)
+	spec.MountInclude = s.MountInclude
+	spec.MountExclude = s.MountExclude
+	spec.MountReadOnly = s.MountReadOnly
+	if s.TTL > 0 {
+		spec.Ttl = durationpb.New(s.TTL)
 	}
-	spec.TargetHost = s.Address
+	spec.Queue = s.Queue
 
 	mountEnabled, mountPoint := s.GetMountPoint()
-	if !mountEnabled {
+	syncMode := s.MountMode == remotefs.MountModeSync || s.MountMode == remotefs.MountModeSyncRW
+	switch {
+	case !mountEnabled:
 		s.mountDisabled = true
-	} else {
+	case syncMode:
+		if ud.Containerized() {
+			return nil, errcat.User.Newf("--mount-mode=%s cannot be used when the daemon runs in a container", s.MountMode)
+		}
+		// A sync mount is just a local directory, so it needs no FUSE capability.
+		ir.MountMode = s.MountMode
+		var cwd string
+		if cwd, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+		var created bool
+		if ir.MountPoint, created, err = PrepareSyncDir(cwd, mountPoint); err != nil {
+			return nil, err
+		}
+		switch {
+		case s.MountKeep:
+			ir.SyncCleanup = remotefs.SyncCleanupKeep
+		case created:
+			ir.SyncCleanup = remotefs.SyncCleanupRemove
+		default:
+			ir.SyncCleanup = remotefs.SyncCleanupClear
+		}
+	default:
 		if ud.Containerized() && ir.LocalMountPort == 0 {
 			// No use having the remote container actually mount, so let's have it create a bridge
 			// to the remote sftp server instead.
@@ -198,7 +249,11 @@ func (s *state) Run(ctx context.Context) error {
 	}
 
 	// start intercept, run command, then leave the intercept
-	if s.DockerRun {
+	if s.DockerCompose != "" {
+		if err := s.prepareDockerCompose(); err != nil {
+			return err
+		}
+	} else if s.DockerRun {
 		if err := s.prepareDockerRun(docker.EnableClient(ctx)); err != nil {
 			return err
 		}
@@ -269,6 +324,12 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 	scout.SetMetadatum(ctx, "service_namespace", r.GetInterceptInfo().GetSpec().GetNamespace())
 	intercept = r.InterceptInfo
 	scout.SetMetadatum(ctx, "intercept_id", intercept.Id)
+	if intercept.Disposition == manager.InterceptDispositionType_QUEUED {
+		// The intercept has no environment and no mounts until it leaves the queue.
+		s.info = NewInfo(ctx, intercept, "")
+		s.printInfo(ctx, detailedOutput)
+		return true, nil
+	}
 
 	s.env = intercept.Environment
 	if s.env == nil {
@@ -276,6 +337,10 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 	}
 	s.env["TELEPRESENCE_INTERCEPT_ID"] = intercept.Id
 	s.env["TELEPRESENCE_ROOT"] = intercept.ClientMountPoint
+	s.remoteEnv = s.env
+	if s.env, err = s.transformEnv(ctx, intercept.ClientMountPoint); err != nil {
+		return true, err
+	}
 	if s.EnvFile != "" {
 		if err = s.writeEnvFile(); err != nil {
 			return true, err
@@ -302,6 +367,11 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 		mountError = volumeMountProblem.Error()
 	}
 	s.info = NewInfo(ctx, intercept, mountError)
+	s.printInfo(ctx, detailedOutput)
+	return true, nil
+}
+
+func (s *state) printInfo(ctx context.Context, detailedOutput bool) {
 	if detailedOutput {
 		output.Object(ctx, s.info, true)
 	} else {
@@ -309,7 +379,6 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 		_, _ = s.info.WriteTo(out)
 		_, _ = fmt.Fprintln(out)
 	}
-	return true, nil
 }
 
 func (s *state) leave(ctx context.Context) error {
@@ -343,19 +412,21 @@ func (s *state) runCommand(ctx context.Context) error {
 		return errcat.NoDaemonLogs.New(proc.Wait(ctx, func() {}, cmd))
 	}
 
-	envFile := s.EnvFile
-	if envFile == "" {
-		file, err := os.CreateTemp("", "tel-*.env")
-		if err != nil {
-			return fmt.Errorf("failed to create temporary environment file. %w", err)
-		}
-		defer os.Remove(file.Name())
-
-		if err = s.writeEnvToFileAndClose(file); err != nil {
-			return err
-		}
-		envFile = file.Name()
+	// Paths in the container's environment must be rewritten using the docker mount point. The file given
+	// with --env-file uses the paths of the host, so the container always gets a file of its own.
+	env, err := s.transformEnv(ctx, s.dockerMountPoint(ctx))
+	if err != nil {
+		return err
 	}
+	file, err := os.CreateTemp("", "tel-*.env")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary environment file. %w", err)
+	}
+	defer os.Remove(file.Name())
+	if err = writeEnvToFileAndClose(file, envfile.Docker, env); err != nil {
+		return err
+	}
+	envFile := file.Name()
 
 	// Ensure that the intercept handler is stopped properly if the daemon quits
 	procCtx, cancel := context.WithCancel(ctx)
@@ -364,10 +435,21 @@ func (s *state) runCommand(ctx context.Context) error {
 			dlog.Error(ctx)
 		}
 	}()
-	dr := s.startInDocker(ctx, envFile, s.Cmdline)
+	var dr *dockerRun
+	if s.DockerCompose != "" {
+		dr = s.startDockerCompose(ctx, envFile, s.Cmdline)
+	} else {
+		dr = s.startInDocker(ctx, envFile, s.Cmdline)
+	}
 	if dr.err == nil {
 		dr.err = s.addInterceptorToDaemon(ctx, dr.cmd, dr.name)
 	}
+	if dr.err == nil && s.DevContainer != "" {
+		// The container is already running, so a failure to describe it isn't fatal.
+		if err := s.writeDevContainer(ctx, dr, env); err != nil {
+			ioutil.Printf(output.Err(ctx), "Unable to write dev container configuration: %v\n", err)
+		}
+	}
 	return dr.wait(procCtx)
 }
 
@@ -403,49 +485,91 @@ func (s *state) checkMountCapability(ctx context.Context) error {
 }
 
 func (s *state) writeEnvFile() error {
-	file, err := os.Create(s.EnvFile)
+	syntax, err := envfile.ParseSyntax(s.EnvSyntax)
 	if err != nil {
-		return errcat.NoDaemonLogs.Newf("failed to create environment file %q: %w", s.EnvFile, err)
+		return errcat.User.New(err)
 	}
-	return s.writeEnvToFileAndClose(file)
+	return writeEnv(s.EnvFile, syntax, s.env)
 }
 
-func (s *state) writeEnvToFileAndClose(file *os.File) (err error) {
-	defer file.Close()
-	w := bufio.NewWriter(file)
+func (s *state) writeEnvJSON() error {
+	return writeEnv(s.EnvJSON, envfile.JSON, s.env)
+}
 
-	keys := make([]string, len(s.env))
-	i := 0
-	for k := range s.env {
-		keys[i] = k
-		i++
+func writeEnv(name string, syntax envfile.Syntax, env map[string]string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return errcat.NoDaemonLogs.Newf("failed to create environment file %q: %w", name, err)
 	}
-	sort.Strings(keys)
+	return writeEnvToFileAndClose(file, syntax, env)
+}
 
-	for _, k := range keys {
-		if _, err = w.WriteString(k); err != nil {
-			return err
-		}
-		if err = w.WriteByte('='); err != nil {
-			return err
+// transformEnv applies the configured transforms to the environment of the intercepted container, using the
+// given root as the location of the remote volumes.
+func (s *state) transformEnv(ctx context.Context, root string) (map[string]string, error) {
+	env := s.remoteEnv
+	if root != "" && root != env["TELEPRESENCE_ROOT"] {
+		env = maps.Clone(env)
+		env["TELEPRESENCE_ROOT"] = root
+	}
+	env, err := client.TransformEnv(env, root, client.GetConfig(ctx).Intercept().EnvTransforms)
+	if err != nil {
+		return nil, errcat.Config.New(err)
+	}
+	return env, nil
+}
+
+func writeEnvToFileAndClose(file *os.File, syntax envfile.Syntax, env map[string]string) error {
+	err := syntax.Write(file, env)
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+type portMapping struct {
+	local  uint16
+	docker uint16
+}
+
+// parseAdditionalPorts parses the additional ports of the intercept. Unlike the first port, an additional port must
+// declare its service port identifier.
+func (s *state) parseAdditionalPorts(remote bool) ([]*manager.InterceptPort, error) {
+	if len(s.AdditionalPorts) == 0 {
+		return nil, nil
+	}
+	aps := make([]*manager.InterceptPort, len(s.AdditionalPorts))
+	s.portMappings = make([]portMapping, len(s.AdditionalPorts))
+	for i, portSpec := range s.AdditionalPorts {
+		local, docker, spi, err := parsePort(portSpec, s.DockerRun, remote)
+		if err != nil {
+			return nil, err
 		}
-		if _, err = w.WriteString(s.env[k]); err != nil {
-			return err
+		if spi == "" {
+			return nil, errcat.User.Newf("--port %s must declare a svcPortIdentifier, because it's not the first --port", portSpec)
 		}
-		if err = w.WriteByte('\n'); err != nil {
-			return err
+		if local == s.localPort || slices.ContainsFunc(s.portMappings[:i], func(pm portMapping) bool { return pm.local == local }) {
+			return nil, errcat.User.Newf("local port %d is used by more than one --port", local)
 		}
+		s.portMappings[i] = portMapping{local: local, docker: docker}
+		aps[i] = &manager.InterceptPort{ServicePortIdentifier: spi, TargetPort: int32(local)}
 	}
-	return w.Flush()
+	return aps, nil
 }
 
-func (s *state) writeEnvJSON() error {
-	data, err := json.MarshalIndent(s.env, "", "  ")
-	if err != nil {
-		// Creating JSON from a map[string]string should never fail
-		panic(err)
+// dockerPortMappings returns the <local port>:<container port> pairs that must be published when the
+// intercept handler runs in a docker container that doesn't share the network of the daemon.
+func (s *state) dockerPortMappings() []string {
+	var pms []string
+	if s.dockerPort != 0 {
+		pms = append(pms, fmt.Sprintf("%d:%d", s.localPort, s.dockerPort))
+	}
+	for _, pm := range s.portMappings {
+		if pm.docker != 0 {
+			pms = append(pms, fmt.Sprintf("%d:%d", pm.local, pm.docker))
+		}
 	}
-	return os.WriteFile(s.EnvJSON, data, 0o644)
+	return pms
 }
 
 // parsePort parses portSpec based on how it's formatted.
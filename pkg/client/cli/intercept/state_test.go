@@ -0,0 +1,33 @@
+package intercept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAdditionalPorts(t *testing.T) {
+	s := &state{Command: &Command{AdditionalPorts: []string{"9091:metrics", "9092:8443"}}, localPort: 8080}
+	aps, err := s.parseAdditionalPorts(false)
+	require.NoError(t, err)
+	require.Len(t, aps, 2)
+	assert.Equal(t, "metrics", aps[0].ServicePortIdentifier)
+	assert.Equal(t, int32(9091), aps[0].TargetPort)
+	assert.Equal(t, "8443", aps[1].ServicePortIdentifier)
+	assert.Equal(t, int32(9092), aps[1].TargetPort)
+	assert.Empty(t, s.dockerPortMappings())
+
+	s = &state{Command: &Command{AdditionalPorts: []string{"9091:9090:metrics"}, DockerRun: true}, localPort: 8080, dockerPort: 80}
+	_, err = s.parseAdditionalPorts(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"8080:80", "9091:9090"}, s.dockerPortMappings())
+
+	s = &state{Command: &Command{AdditionalPorts: []string{"9091"}}, localPort: 8080}
+	_, err = s.parseAdditionalPorts(false)
+	assert.ErrorContains(t, err, "must declare a svcPortIdentifier")
+
+	s = &state{Command: &Command{AdditionalPorts: []string{"8080:metrics"}}, localPort: 8080}
+	_, err = s.parseAdditionalPorts(false)
+	assert.ErrorContains(t, err, "used by more than one --port")
+}
@@ -0,0 +1,32 @@
+package intercept
+
+import (
+	"fmt"
+	"time"
+)
+
+// untilTTL returns the duration from now until the given time. The time is either an RFC3339 timestamp, or a
+// local time of day in the form HH:MM or HH:MM:SS, in which case the next occurrence of that time is used.
+func untilTTL(until string, now time.Time) (time.Duration, error) {
+	if t, err := time.Parse(time.RFC3339, until); err == nil {
+		if !t.After(now) {
+			return 0, fmt.Errorf("--until %s is not in the future", until)
+		}
+		return t.Sub(now), nil
+	}
+	var tod time.Time
+	var err error
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if tod, err = time.ParseInLocation(layout, until, now.Location()); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("--until %s is neither a time of day in the form HH:MM nor an RFC3339 timestamp", until)
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), tod.Hour(), tod.Minute(), tod.Second(), 0, now.Location())
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.Sub(now), nil
+}
@@ -0,0 +1,39 @@
+package intercept
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_untilTTL(t *testing.T) {
+	now := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		until   string
+		want    time.Duration
+		wantErr string
+	}{
+		{until: "18:00", want: 3*time.Hour + 30*time.Minute},
+		{until: "14:30:30", want: 30 * time.Second},
+		{until: "09:00", want: 18*time.Hour + 30*time.Minute},
+		{until: "14:30", want: 24 * time.Hour},
+		{until: "2024-03-15T15:00:00Z", want: 30 * time.Minute},
+		{until: "2024-03-15T16:00:00+01:00", want: 30 * time.Minute},
+		{until: "2024-03-15T14:00:00Z", wantErr: "not in the future"},
+		{until: "6pm", wantErr: "neither a time of day"},
+		{until: "25:00", wantErr: "neither a time of day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.until, func(t *testing.T) {
+			got, err := untilTTL(tt.until, now)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,6 +25,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 const ConfigFile = "config.yml"
@@ -39,6 +41,7 @@ type Config interface {
 	TelepresenceAPI() *TelepresenceAPI
 	Intercept() *Intercept
 	Cluster() *Cluster
+	Docker() *Docker
 	Merge(Config)
 }
 
@@ -52,6 +55,7 @@ type BaseConfig struct {
 	TelepresenceAPIV TelepresenceAPI `json:"telepresenceAPI,omitempty" yaml:"telepresenceAPI,omitempty"`
 	InterceptV       Intercept       `json:"intercept,omitempty" yaml:"intercept,omitempty"`
 	ClusterV         Cluster         `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	DockerV          Docker          `json:"docker,omitempty" yaml:"docker,omitempty"`
 }
 
 func (c *BaseConfig) OSSpecific() *OSSpecificConfig {
@@ -90,6 +94,10 @@ func (c *BaseConfig) Cluster() *Cluster {
 	return &c.ClusterV
 }
 
+func (c *BaseConfig) Docker() *Docker {
+	return &c.DockerV
+}
+
 func ParseConfigYAML(data []byte) (Config, error) {
 	cfg := GetDefaultConfig()
 	if err := yaml.Unmarshal(data, cfg); err != nil {
@@ -108,6 +116,7 @@ func (c *BaseConfig) Merge(lc Config) {
 	c.TelepresenceAPIV.merge(lc.TelepresenceAPI())
 	c.InterceptV.merge(lc.Intercept())
 	c.ClusterV.merge(lc.Cluster())
+	c.DockerV.merge(lc.Docker())
 }
 
 func (c *BaseConfig) String() string {
@@ -722,6 +731,14 @@ type Grpc struct {
 	// MaxReceiveSize is the maximum message size in bytes the client can receive in a gRPC call or stream message.
 	// Overrides the gRPC default of 4MB.
 	MaxReceiveSizeV resource.Quantity `json:"maxReceiveSize,omitempty" yaml:"maxReceiveSize,omitempty"`
+
+	// Compression is the compression that the client proposes when it creates tunnel streams
+	// to the traffic-manager or a traffic-agent.
+	Compression tunnel.Compression `json:"compression,omitempty" yaml:"compression,omitempty"`
+
+	// MultiplexTunnels controls whether the root daemon uses one single multiplexed tunnel to the
+	// traffic-manager instead of creating one tunnel per connection.
+	MultiplexTunnels bool `json:"multiplexTunnels,omitempty" yaml:"multiplexTunnels,omitempty"`
 }
 
 func (g *Grpc) MaxReceiveSize() int64 {
@@ -737,6 +754,12 @@ func (g *Grpc) merge(o *Grpc) {
 	if !o.MaxReceiveSizeV.IsZero() {
 		g.MaxReceiveSizeV = o.MaxReceiveSizeV
 	}
+	if o.Compression != tunnel.CompressionNone {
+		g.Compression = o.Compression
+	}
+	if o.MultiplexTunnels {
+		g.MultiplexTunnels = true
+	}
 }
 
 // UnmarshalYAML parses the images YAML.
@@ -761,6 +784,20 @@ func (g *Grpc) UnmarshalYAML(node *yaml.Node) (err error) {
 			} else {
 				g.MaxReceiveSizeV = val
 			}
+		case "compression":
+			c, err := tunnel.ParseCompression(v.Value)
+			if err != nil {
+				logrus.Warn(WithLoc(err.Error(), ms[i]))
+			} else {
+				g.Compression = c
+			}
+		case "multiplexTunnels":
+			b, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				logrus.Warnf("unable to parse bool %q: %v", v.Value, WithLoc(err.Error(), ms[i]))
+			} else {
+				g.MultiplexTunnels = b
+			}
 		default:
 			logrus.Warn(WithLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
 		}
@@ -770,17 +807,25 @@ func (g *Grpc) UnmarshalYAML(node *yaml.Node) (err error) {
 
 // IsZero controls whether this element will be included in marshalled output.
 func (g Grpc) IsZero() bool {
-	return g.MaxReceiveSizeV.IsZero()
+	return g.MaxReceiveSizeV.IsZero() && g.Compression == tunnel.CompressionNone && !g.MultiplexTunnels
 }
 
 // MarshalYAML is not using pointer receiver here, because Cloud is not pointer in the Config struct.
 func (g Grpc) MarshalYAML() (any, error) {
+	gm := make(map[string]any)
 	if !g.MaxReceiveSizeV.IsZero() {
-		return map[string]any{
-			"maxReceiveSize": g.MaxReceiveSizeV.String(),
-		}, nil
+		gm["maxReceiveSize"] = g.MaxReceiveSizeV.String()
+	}
+	if g.Compression != tunnel.CompressionNone {
+		gm["compression"] = g.Compression.String()
+	}
+	if g.MultiplexTunnels {
+		gm["multiplexTunnels"] = true
 	}
-	return nil, nil
+	if len(gm) == 0 {
+		return nil, nil
+	}
+	return gm, nil
 }
 
 type TelepresenceAPI struct {
@@ -803,6 +848,7 @@ type Intercept struct {
 	AppProtocolStrategy k8sapi.AppProtocolStrategy `json:"appProtocolStrategy,omitempty" yaml:"appProtocolStrategy,omitempty"`
 	DefaultPort         int                        `json:"defaultPort,omitempty" yaml:"defaultPort,omitempty"`
 	UseFtp              bool                       `json:"useFtp,omitempty" yaml:"useFtp,omitempty"`
+	EnvTransforms       []EnvTransform             `json:"envTransforms,omitempty" yaml:"envTransforms,omitempty"`
 }
 
 func (ic *Intercept) merge(o *Intercept) {
@@ -815,11 +861,17 @@ func (ic *Intercept) merge(o *Intercept) {
 	if o.UseFtp {
 		ic.UseFtp = true
 	}
+	if len(o.EnvTransforms) > 0 {
+		ic.EnvTransforms = o.EnvTransforms
+	}
 }
 
 // IsZero controls whether this element will be included in marshalled output.
 func (ic Intercept) IsZero() bool {
-	return ic == defaultIntercept
+	return ic.AppProtocolStrategy == defaultIntercept.AppProtocolStrategy &&
+		ic.DefaultPort == defaultIntercept.DefaultPort &&
+		ic.UseFtp == defaultIntercept.UseFtp &&
+		len(ic.EnvTransforms) == 0
 }
 
 // MarshalYAML is not using pointer receiver here, because Intercept is not pointer in the Config struct.
@@ -834,6 +886,9 @@ func (ic Intercept) MarshalYAML() (any, error) {
 	if ic.UseFtp {
 		im["useFtp"] = true
 	}
+	if len(ic.EnvTransforms) > 0 {
+		im["envTransforms"] = ic.EnvTransforms
+	}
 	return im, nil
 }
 
@@ -895,6 +950,73 @@ func (cc Cluster) MarshalYAML() (any, error) {
 	return cm, nil
 }
 
+// The container engines that can be used for the daemon container and the containers started by
+// telepresence intercept --docker-run.
+const (
+	ContainerEngineDocker  = "docker"
+	ContainerEnginePodman  = "podman"
+	ContainerEngineNerdctl = "nerdctl"
+)
+
+type Docker struct {
+	// Engine is the container engine, one of ContainerEngineDocker, ContainerEnginePodman, or ContainerEngineNerdctl.
+	Engine string `json:"engine,omitempty" yaml:"engine,omitempty"`
+}
+
+var defaultDocker = Docker{ //nolint:gochecknoglobals // constant
+	Engine: ContainerEngineDocker,
+}
+
+// UnmarshalYAML parses the docker YAML.
+func (d *Docker) UnmarshalYAML(node *yaml.Node) (err error) {
+	if node.Kind != yaml.MappingNode {
+		return errors.New(WithLoc("docker must be an object", node))
+	}
+
+	ms := node.Content
+	top := len(ms)
+	for i := 0; i < top; i += 2 {
+		kv, err := StringKey(ms[i])
+		if err != nil {
+			return err
+		}
+		v := ms[i+1]
+		switch kv {
+		case "engine":
+			switch v.Value {
+			case ContainerEngineDocker, ContainerEnginePodman, ContainerEngineNerdctl:
+				d.Engine = v.Value
+			default:
+				return errors.New(WithLoc(fmt.Sprintf("invalid container engine %q, must be one of %s, %s, or %s",
+					v.Value, ContainerEngineDocker, ContainerEnginePodman, ContainerEngineNerdctl), v))
+			}
+		default:
+			logrus.Warn(WithLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
+		}
+	}
+	return nil
+}
+
+func (d *Docker) merge(o *Docker) {
+	if o.Engine != defaultDocker.Engine {
+		d.Engine = o.Engine
+	}
+}
+
+// IsZero controls whether this element will be included in marshalled output.
+func (d Docker) IsZero() bool {
+	return d == defaultDocker
+}
+
+// MarshalYAML is not using pointer receiver here, because Docker is not pointer in the Config struct.
+func (d Docker) MarshalYAML() (any, error) {
+	dm := make(map[string]any)
+	if d.Engine != defaultDocker.Engine {
+		dm["engine"] = d.Engine
+	}
+	return dm, nil
+}
+
 var (
 	parsedFile string     //nolint:gochecknoglobals // protected by parseLock
 	parseLock  sync.Mutex //nolint:gochecknoglobals // protects parsedFile
@@ -961,6 +1083,7 @@ func GetDefaultBaseConfig() BaseConfig {
 		TelepresenceAPIV: TelepresenceAPI{},
 		InterceptV:       defaultIntercept,
 		ClusterV:         defaultCluster,
+		DockerV:          defaultDocker,
 	}
 }
 
@@ -15,6 +15,7 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 func TestGetConfig(t *testing.T) {
@@ -27,6 +28,8 @@ logLevels:
   rootDaemon: debug
 cluster:
   defaultManagerNamespace: hello
+docker:
+  engine: podman
 `,
 		/* sys2 */ `
 timeouts:
@@ -51,6 +54,11 @@ intercept:
   appProtocolStrategy: portName
   defaultPort: 9080
   useFtp: true
+  envTransforms:
+    - keys: KUBERNETES_*
+      drop: true
+    - set: DB_HOST
+      value: localhost
 `,
 	}
 
@@ -92,7 +100,10 @@ intercept:
 	assert.Equal(t, k8sapi.PortName, cfg.Intercept().AppProtocolStrategy)                        // from user
 	assert.Equal(t, 9080, cfg.Intercept().DefaultPort)                                           // from user
 	assert.True(t, cfg.Intercept().UseFtp)                                                       // from user
-	assert.Equal(t, cfg.Cluster().DefaultManagerNamespace, "hello")                              // from sys1
+	assert.Equal(t, []EnvTransform{{Keys: "KUBERNETES_*", Drop: true}, {Set: "DB_HOST", Value: "localhost"}},
+		cfg.Intercept().EnvTransforms) // from user
+	assert.Equal(t, cfg.Cluster().DefaultManagerNamespace, "hello") // from sys1
+	assert.Equal(t, ContainerEnginePodman, cfg.Docker().Engine)     // from sys1
 }
 
 func Test_ConfigMarshalYAML(t *testing.T) {
@@ -105,10 +116,14 @@ func Test_ConfigMarshalYAML(t *testing.T) {
 	cfg.Timeouts().PrivateTrafficManagerAPI = defaultTimeoutsTrafficManagerAPI + 20*time.Second
 	cfg.LogLevels().UserDaemon = logrus.TraceLevel
 	cfg.Grpc().MaxReceiveSizeV, _ = resource.ParseQuantity("20Mi")
+	cfg.Grpc().Compression = tunnel.CompressionZstd
+	cfg.Grpc().MultiplexTunnels = true
 	cfg.TelepresenceAPI().Port = 4567
 	cfg.Intercept().AppProtocolStrategy = k8sapi.PortName
 	cfg.Intercept().DefaultPort = 9080
+	cfg.Intercept().EnvTransforms = []EnvTransform{{Keys: "*_FILE", RewritePath: "/var/run/secrets"}}
 	cfg.Cluster().DefaultManagerNamespace = "hello-there"
+	cfg.Docker().Engine = ContainerEngineNerdctl
 	cfgBytes, err := yaml.Marshal(cfg)
 	require.NoError(t, err)
 
@@ -7,6 +7,10 @@ import (
 )
 
 func StopContainer(ctx context.Context, nameOrID string) error {
+	if !GetEngine(ctx).HasAPI() {
+		_, err := runCLI(ctx, "stop", nameOrID)
+		return err
+	}
 	cli, err := GetClient(ctx)
 	if err == nil {
 		err = cli.ContainerStop(ctx, nameOrID, container.StopOptions{})
@@ -2,13 +2,11 @@ package docker
 
 import (
 	"context"
-	"fmt"
-	"strings"
 	"sync"
 
 	"github.com/docker/docker/client"
 
-	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 )
 
 type clientKey struct{}
@@ -22,13 +20,16 @@ func (h *clientHandle) GetClient(ctx context.Context) (*client.Client, error) {
 	h.Lock()
 	defer h.Unlock()
 	if h.cli == nil {
-		cmd := proc.CommandContext(ctx, "docker", "context", "inspect", "--format", "{{.Endpoints.docker.Host}}")
-		stdout, err := proc.CaptureErr(cmd)
-		opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+		engine := GetEngine(ctx)
+		if !engine.HasAPI() {
+			return nil, errcat.Config.Newf("the %s container engine has no Docker compatible API", engine.Name())
+		}
+		host, err := engine.APIHost(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve docker context: %v", err)
+			return nil, err
 		}
-		if host := strings.TrimSpace(string(stdout)); host != "" {
+		opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+		if host != "" {
 			opts = append(opts, client.WithHost(host))
 		}
 		cli, err := client.NewClientWithOpts(opts...)
@@ -5,24 +5,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net"
-	"net/netip"
-	"net/url"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/filters"
-	dockerClient "github.com/docker/docker/client"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-	runtime2 "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/datawire/dlib/dexec"
@@ -88,9 +79,7 @@ func DaemonOptions(ctx context.Context, daemonID *daemon.Identifier) ([]string,
 	if cr.Hostname != "" {
 		opts = append(opts, "--hostname", cr.Hostname)
 	}
-	if runtime.GOOS == "linux" {
-		opts = append(opts, "--add-host", "host.docker.internal:host-gateway")
-	}
+	opts = append(opts, GetEngine(ctx).HostGatewayOptions()...)
 	env := client.GetEnv(ctx)
 	if env.ScoutDisable {
 		opts = append(opts, "-e", "SCOUT_DISABLE=1")
@@ -238,82 +227,12 @@ func enableK8SAuthenticator(ctx context.Context, daemonID *daemon.Identifier) er
 	return err
 }
 
-// handleLocalK8s checks if the cluster is using a well known provider (currently minikube or kind)
-// and if so, ensures that the daemon container is connected to its network.
-func handleLocalK8s(ctx context.Context, daemonID *daemon.Identifier, config *api.Config) error {
-	cc := config.Contexts[config.CurrentContext]
-	cl := config.Clusters[cc.Cluster]
-	isKind := strings.HasPrefix(cc.Cluster, "kind-")
-	dlog.Debugf(ctx, "isKind %t", isKind)
-	isMinikube := false
-	if !isKind {
-		if ex, ok := cl.Extensions["cluster_info"].(*runtime2.Unknown); ok {
-			var data map[string]any
-			isMinikube = json.Unmarshal(ex.Raw, &data) == nil && data["provider"] == "minikube.sigs.k8s.io"
-		}
-	}
-	if !(isKind || isMinikube) {
-		return nil
-	}
-
-	server, err := url.Parse(cl.Server)
-	if err != nil {
-		return err
-	}
-	host, portStr, err := net.SplitHostPort(server.Host)
-	if err != nil {
-		return err
-	}
-	addr, err := netip.ParseAddr(host)
-	if err != nil {
-		if host == "localhost" {
-			addr = netip.AddrFrom4([4]byte{127, 0, 0, 1})
-		}
-	}
-	port, err := strconv.ParseUint(portStr, 10, 16)
-	if err != nil {
-		return err
-	}
-
-	addrPort := netip.AddrPortFrom(addr, uint16(port))
-
-	// Let's check if we have a container with port bindings for the
-	// given addrPort that is a known k8sapi provider
-	cli, err := GetClient(ctx)
-	if err != nil {
-		return err
-	}
-	cjs := runningContainers(ctx, cli)
-
-	var hostPort, network string
-	if isKind {
-		hostPort, network = detectKind(cjs, addrPort)
-		dlog.Debugf(ctx, "hostPort %s, network %s", hostPort, network)
-	} else if isMinikube {
-		hostPort, network = detectMinikube(cjs, addrPort, cc.Cluster)
-	}
-	if hostPort != "" {
-		server.Host = hostPort
-		cl.Server = server.String()
-	}
-	if network != "" {
-		dcName := daemonID.ContainerName()
-		dlog.Debugf(ctx, "Connecting network %s to container %s", network, dcName)
-		if err = cli.NetworkConnect(ctx, network, dcName, nil); err != nil {
-			if !strings.Contains(err.Error(), "already exists") {
-				dlog.Debugf(ctx, "failed to connect network %s to container %s: %v", network, dcName, err)
-			}
-		}
-	}
-	return nil
-}
-
 // LaunchDaemon ensures that the image returned by ClientImage exists by calling PullImage. It then uses the
 // options DaemonOptions and DaemonArgs to start the image, and finally connectDaemon to connect to it. A
 // successful start yields a cache.Info entry in the cache.
 func LaunchDaemon(ctx context.Context, daemonID *daemon.Identifier) (conn *grpc.ClientConn, err error) {
 	if proc.RunningInContainer() {
-		return nil, errors.New("unable to start a docker container from within a container")
+		return nil, fmt.Errorf("unable to start a %s container from within a container", GetEngine(ctx).Name())
 	}
 	image := ClientImage(ctx)
 	if err = PullImage(ctx, image); err != nil {
@@ -342,7 +261,9 @@ func LaunchDaemon(ctx context.Context, daemonID *daemon.Identifier) (conn *grpc.
 	for i := 1; ; i++ {
 		_, err = tryLaunch(ctx, daemonID, addr.Port, allArgs)
 		if err != nil {
-			if !strings.Contains(err.Error(), "already in use by container") {
+			// The wording differs between docker ("already in use by container"), podman ("already in use by"),
+			// and nerdctl ("already used by").
+			if es := err.Error(); !(strings.Contains(es, "already in use by") || strings.Contains(es, "already used by")) {
 				return nil, errcat.NoDaemonLogs.New(err)
 			}
 			// This may happen if the daemon has died (and hence, we never discovered it), but
@@ -371,88 +292,11 @@ func LaunchDaemon(ctx context.Context, daemonID *daemon.Identifier) (conn *grpc.
 	return conn, nil
 }
 
-// containerPort returns the port that the container uses internally to expose the given
-// addrPort on the host. An empty string is returned when the addrPort is not found among
-// the container's port bindings.
-func containerPort(addrPort netip.AddrPort, ns *types.NetworkSettings) string {
-	for port, bindings := range ns.Ports {
-		for _, binding := range bindings {
-			addr, err := netip.ParseAddr(binding.HostIP)
-			if err != nil {
-				continue
-			}
-			pn, err := strconv.ParseUint(binding.HostPort, 10, 16)
-			if err != nil {
-				continue
-			}
-			if netip.AddrPortFrom(addr, uint16(pn)) == addrPort {
-				return port.Port()
-			}
-		}
-	}
-	return ""
-}
-
-// runningContainers returns the inspect data for all containers with status=running.
-func runningContainers(ctx context.Context, cli dockerClient.APIClient) []types.ContainerJSON {
-	cl, err := cli.ContainerList(ctx, types.ContainerListOptions{
-		Filters: filters.NewArgs(filters.KeyValuePair{Key: "status", Value: "running"}),
-	})
-	if err != nil {
-		dlog.Errorf(ctx, "failed to list containers: %v", err)
-		return nil
-	}
-	cjs := make([]types.ContainerJSON, 0, len(cl))
-	for _, cn := range cl {
-		cj, err := cli.ContainerInspect(ctx, cn.ID)
-		if err != nil {
-			dlog.Errorf(ctx, "container inspect on %v failed: %v", cn.Names, err)
-		} else {
-			cjs = append(cjs, cj)
-		}
-	}
-	return cjs
-}
-
-// detectMinikube returns the container IP:port for the given hostAddrPort for a container where the
-// "name.minikube.sigs.k8s.io" label is equal to the given cluster name.
-// Returns the internal IP:port for the given hostAddrPort and the name of a network that makes the
-// IP available.
-func detectMinikube(cns []types.ContainerJSON, hostAddrPort netip.AddrPort, clusterName string) (string, string) {
-	for _, cn := range cns {
-		if cfg, ns := cn.Config, cn.NetworkSettings; cfg != nil && ns != nil && cfg.Labels["name.minikube.sigs.k8s.io"] == clusterName {
-			if port := containerPort(hostAddrPort, ns); port != "" {
-				for networkName, network := range ns.Networks {
-					return net.JoinHostPort(network.IPAddress, port), networkName
-				}
-			}
-		}
-	}
-	return "", ""
-}
-
-// detectKind returns the container hostname:port for the given hostAddrPort for a container where the
-// "io.x-k8s.kind.role" label is equal to "control-plane".
-// Returns the internal hostname:port for the given hostAddrPort and the name of a network that makes the
-// hostname available.
-func detectKind(cns []types.ContainerJSON, hostAddrPort netip.AddrPort) (string, string) {
-	for _, cn := range cns {
-		if cfg, ns := cn.Config, cn.NetworkSettings; cfg != nil && ns != nil && cfg.Labels["io.x-k8s.kind.role"] == "control-plane" {
-			if port := containerPort(hostAddrPort, ns); port != "" {
-				hostPort := net.JoinHostPort(cfg.Hostname, port)
-				for networkName := range ns.Networks {
-					return hostPort, networkName
-				}
-			}
-		}
-	}
-	return "", ""
-}
-
 func stopContainer(ctx context.Context, daemonID *daemon.Identifier) {
 	args := []string{"stop", daemonID.ContainerName()}
-	dlog.Debug(ctx, shellquote.ShellString("docker", args))
-	if _, err := proc.CaptureErr(dexec.CommandContext(ctx, "docker", args...)); err != nil {
+	name := GetEngine(ctx).Name()
+	dlog.Debug(ctx, shellquote.ShellString(name, args))
+	if _, err := proc.CaptureErr(dexec.CommandContext(ctx, name, args...)); err != nil {
 		dlog.Warn(ctx, err)
 	}
 }
@@ -460,8 +304,9 @@ func stopContainer(ctx context.Context, daemonID *daemon.Identifier) {
 func tryLaunch(ctx context.Context, daemonID *daemon.Identifier, port int, args []string) (string, error) {
 	stdErr := bytes.Buffer{}
 	stdOut := bytes.Buffer{}
-	dlog.Debug(ctx, shellquote.ShellString("docker", args))
-	cmd := proc.CommandContext(ctx, "docker", args...)
+	name := GetEngine(ctx).Name()
+	dlog.Debug(ctx, shellquote.ShellString(name, args))
+	cmd := proc.CommandContext(ctx, name, args...)
 	cmd.DisableLogging = true
 	cmd.Stderr = &stdErr
 	cmd.Stdout = &stdOut
@@ -0,0 +1,177 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+// Engine is a container engine that is used to run the daemon container and the containers started by
+// telepresence intercept --docker-run. The engine is selected using the docker.engine configuration.
+type Engine interface {
+	// Name returns the name of the engine, which is also the name of its command line executable.
+	Name() string
+
+	// HasAPI returns true if the engine provides a Docker compatible API. The command line executable is
+	// used for all operations when it doesn't.
+	HasAPI() bool
+
+	// APIHost returns the host of the engine's Docker compatible API, or an empty string when the defaults
+	// of the Docker client apply.
+	APIHost(ctx context.Context) (string, error)
+
+	// VolumePlugins returns true if the engine supports Docker managed volume plugins.
+	VolumePlugins() bool
+
+	// HostGatewayOptions returns the run options that make the name host.docker.internal resolve to the host.
+	HostGatewayOptions() []string
+
+	// EnsureNetwork checks if a network with the given name exists, and creates it if that is not the case.
+	EnsureNetwork(ctx context.Context, name string) error
+}
+
+// GetEngine returns the container engine that is configured in the context.
+func GetEngine(ctx context.Context) Engine {
+	switch client.GetConfig(ctx).Docker().Engine {
+	case client.ContainerEnginePodman:
+		return podmanEngine{}
+	case client.ContainerEngineNerdctl:
+		return nerdctlEngine{}
+	default:
+		return dockerEngine{}
+	}
+}
+
+// runCLI runs the command line executable of the configured engine and returns its trimmed output.
+func runCLI(ctx context.Context, args ...string) (string, error) {
+	name := GetEngine(ctx).Name()
+	out, err := proc.CaptureErr(proc.CommandContext(ctx, name, args...))
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+type dockerEngine struct{}
+
+func (dockerEngine) Name() string {
+	return client.ContainerEngineDocker
+}
+
+func (dockerEngine) HasAPI() bool {
+	return true
+}
+
+func (dockerEngine) APIHost(ctx context.Context) (string, error) {
+	cmd := proc.CommandContext(ctx, "docker", "context", "inspect", "--format", "{{.Endpoints.docker.Host}}")
+	stdout, err := proc.CaptureErr(cmd)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve docker context: %v", err)
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+func (dockerEngine) VolumePlugins() bool {
+	return true
+}
+
+func (dockerEngine) HostGatewayOptions() []string {
+	if runtime.GOOS == "linux" {
+		return []string{"--add-host", "host.docker.internal:host-gateway"}
+	}
+	return nil
+}
+
+func (dockerEngine) EnsureNetwork(ctx context.Context, name string) error {
+	return ensureNetworkAPI(ctx, name)
+}
+
+// podmanEngine uses the Docker compatible API that Podman serves on its socket. Podman has no support for
+// Docker managed plugins.
+type podmanEngine struct{}
+
+func (podmanEngine) Name() string {
+	return client.ContainerEnginePodman
+}
+
+func (podmanEngine) HasAPI() bool {
+	return true
+}
+
+func (podmanEngine) APIHost(ctx context.Context) (string, error) {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host, nil
+	}
+	var args []string
+	if runtime.GOOS == "linux" {
+		args = []string{"info", "--format", "{{.Host.RemoteSocket.Path}}"}
+	} else {
+		// The socket reported by podman info is the one in the podman machine.
+		args = []string{"machine", "inspect", "--format", "{{.ConnectionInfo.PodmanSocket.Path}}"}
+	}
+	stdout, err := proc.CaptureErr(proc.CommandContext(ctx, "podman", args...))
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the podman socket: %v", err)
+	}
+	return socketHost(strings.TrimSpace(string(stdout))), nil
+}
+
+func (podmanEngine) VolumePlugins() bool {
+	return false
+}
+
+func (podmanEngine) HostGatewayOptions() []string {
+	// Podman adds host.docker.internal to the hosts file of all containers.
+	return nil
+}
+
+func (podmanEngine) EnsureNetwork(ctx context.Context, name string) error {
+	// Podman's API doesn't accept the IPAM configuration that's used when recreating a network.
+	return ensureNetworkCLI(ctx, name)
+}
+
+// nerdctlEngine uses the nerdctl command line for all operations, because containerd has no Docker compatible
+// API.
+type nerdctlEngine struct{}
+
+func (nerdctlEngine) Name() string {
+	return client.ContainerEngineNerdctl
+}
+
+func (nerdctlEngine) HasAPI() bool {
+	return false
+}
+
+func (nerdctlEngine) APIHost(context.Context) (string, error) {
+	return "", fmt.Errorf("%s has no Docker compatible API", client.ContainerEngineNerdctl)
+}
+
+func (nerdctlEngine) VolumePlugins() bool {
+	return false
+}
+
+func (nerdctlEngine) HostGatewayOptions() []string {
+	return []string{"--add-host", "host.docker.internal:host-gateway"}
+}
+
+func (nerdctlEngine) EnsureNetwork(ctx context.Context, name string) error {
+	return ensureNetworkCLI(ctx, name)
+}
+
+// socketHost returns the host URL for the given socket path.
+func socketHost(path string) string {
+	switch {
+	case path == "" || strings.Contains(path, "://"):
+		return path
+	case runtime.GOOS == "windows":
+		return "npipe://" + filepath.ToSlash(path)
+	default:
+		return "unix://" + path
+	}
+}
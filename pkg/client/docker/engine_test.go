@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+func TestGetEngine(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	cfg := client.GetDefaultConfig()
+	ctx = client.WithConfig(ctx, cfg)
+	assert.Equal(t, client.ContainerEngineDocker, GetEngine(ctx).Name())
+	assert.True(t, GetEngine(ctx).VolumePlugins())
+
+	cfg.Docker().Engine = client.ContainerEnginePodman
+	e := GetEngine(ctx)
+	assert.Equal(t, client.ContainerEnginePodman, e.Name())
+	assert.True(t, e.HasAPI())
+	assert.False(t, e.VolumePlugins())
+
+	cfg.Docker().Engine = client.ContainerEngineNerdctl
+	e = GetEngine(ctx)
+	assert.Equal(t, client.ContainerEngineNerdctl, e.Name())
+	assert.False(t, e.HasAPI())
+	_, err := e.APIHost(ctx)
+	assert.Error(t, err)
+}
+
+func TestSocketHost(t *testing.T) {
+	assert.Equal(t, "", socketHost(""))
+	assert.Equal(t, "tcp://localhost:2375", socketHost("tcp://localhost:2375"))
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, "unix:///run/user/1000/podman/podman.sock", socketHost("/run/user/1000/podman/podman.sock"))
+	}
+}
@@ -34,7 +34,7 @@ func BuildImage(ctx context.Context, context string, buildArgs []string) (string
 		context = dir
 		args = append(args, "--file", fn)
 	}
-	cmd := proc.StdCommand(ctx, "docker", append(args, context)...)
+	cmd := proc.StdCommand(ctx, GetEngine(ctx).Name(), append(args, context)...)
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	if err := cmd.Run(); err != nil {
@@ -46,16 +46,20 @@ func BuildImage(ctx context.Context, context string, buildArgs []string) (string
 // PullImage checks if the given image exists locally by doing docker image inspect. A docker pull is
 // performed if no local image is found. Stdout is silenced during those operations.
 func PullImage(ctx context.Context, image string) error {
-	cli, err := GetClient(ctx)
-	if err != nil {
-		return err
-	}
-	_, _, err = cli.ImageInspectWithRaw(ctx, image)
-	if err == nil {
-		// Image exists in the local cache, so don't bother pulling it.
+	engine := GetEngine(ctx)
+	if engine.HasAPI() {
+		cli, err := GetClient(ctx)
+		if err != nil {
+			return err
+		}
+		if _, _, err = cli.ImageInspectWithRaw(ctx, image); err == nil {
+			// Image exists in the local cache, so don't bother pulling it.
+			return nil
+		}
+	} else if _, err := runCLI(ctx, "image", "inspect", image); err == nil {
 		return nil
 	}
-	cmd := proc.StdCommand(ctx, "docker", "pull", image)
+	cmd := proc.StdCommand(ctx, engine.Name(), "pull", image)
 	// Docker run will put the pull logs in stderr, but docker pull will put them in stdout.
 	// We discard them here, so they don't spam the user. They'll get errors through stderr if it comes to it.
 	cmd.Stdout = io.Discard
@@ -64,8 +68,7 @@ func PullImage(ctx context.Context, image string) error {
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	err = cmd.Run()
-	if err != nil {
+	if err := cmd.Run(); err != nil {
 		fmt.Fprint(os.Stderr, stderr.String())
 		return err
 	}
@@ -0,0 +1,472 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	runtime2 "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+)
+
+// LocalCluster is a Kubernetes cluster that might run on the local host, either in containers or in the VM
+// that runs the container engine. The daemon container can't reach such a cluster using the server address in
+// the kubeconfig, because that address is only valid on the host.
+type LocalCluster struct {
+	// Context is the current kubeconfig context.
+	Context *api.Context
+
+	// Cluster is the cluster of the current context.
+	Cluster *api.Cluster
+
+	// Host and Port of the cluster's server.
+	Host string
+	Port uint16
+
+	containers []types.ContainerJSON
+	listed     bool
+	gateways   map[string]string
+}
+
+// AddrPort returns the server address. The result is invalid unless the host is an IP or "localhost".
+func (lc *LocalCluster) AddrPort() netip.AddrPort {
+	addr, err := netip.ParseAddr(lc.Host)
+	if err != nil {
+		if lc.Host != "localhost" {
+			return netip.AddrPort{}
+		}
+		addr = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+	}
+	return netip.AddrPortFrom(addr, lc.Port)
+}
+
+// IsHostLocal returns true if the server address is a loopback or unspecified address.
+func (lc *LocalCluster) IsHostLocal() bool {
+	ap := lc.AddrPort()
+	return ap.IsValid() && (ap.Addr().IsLoopback() || ap.Addr().IsUnspecified())
+}
+
+// Containers returns the inspect data of all running containers.
+func (lc *LocalCluster) Containers(ctx context.Context) []types.ContainerJSON {
+	if !lc.listed {
+		lc.containers = runningContainers(ctx)
+		lc.listed = true
+	}
+	return lc.containers
+}
+
+// NetworkGateway returns the gateway IP of the given network, or an empty string if it can't be determined.
+func (lc *LocalCluster) NetworkGateway(ctx context.Context, name string) string {
+	gw, ok := lc.gateways[name]
+	if !ok {
+		gw = networkGateway(ctx, name)
+		if lc.gateways == nil {
+			lc.gateways = make(map[string]string)
+		}
+		lc.gateways[name] = gw
+	}
+	return gw
+}
+
+// LocalClusterAccess describes how the daemon container reaches a local cluster.
+type LocalClusterAccess struct {
+	// Server is the host:port that replaces the host:port of the cluster's server. Empty if it isn't replaced.
+	Server string
+
+	// TLSServerName is the name used when verifying the server certificate. Required when the name of the Server
+	// isn't included in the certificate.
+	TLSServerName string
+
+	// Network is the name of a network that the daemon container must join. Empty if it doesn't need to join one.
+	Network string
+}
+
+// LocalClusterDetector detects a specific kind of local cluster.
+type LocalClusterDetector interface {
+	// Name returns the name of the local cluster provider, e.g. "kind".
+	Name() string
+
+	// Matches returns true if the cluster might be provided by this detector. It must not be expensive, because
+	// it's called for every cluster.
+	Matches(lc *LocalCluster) bool
+
+	// Detect returns how the daemon container can reach the cluster, or nil if the cluster wasn't detected.
+	Detect(ctx context.Context, lc *LocalCluster) *LocalClusterAccess
+}
+
+// localClusterDetectors are consulted in order. The first detector that matches and detects the cluster wins.
+var localClusterDetectors = []LocalClusterDetector{ //nolint:gochecknoglobals // extension point
+	kindDetector{},
+	minikubeDetector{},
+	k3dDetector{},
+	dockerDesktopDetector{},
+	&vmDetector{name: "rancher-desktop", match: func(cluster string) bool { return cluster == "rancher-desktop" }},
+	&vmDetector{name: "colima", match: func(cluster string) bool { return cluster == "colima" || strings.HasPrefix(cluster, "colima-") }},
+	talosDetector{},
+}
+
+// RegisterLocalClusterDetector adds a detector that is consulted before the built-in detectors.
+func RegisterLocalClusterDetector(d LocalClusterDetector) {
+	localClusterDetectors = append([]LocalClusterDetector{d}, localClusterDetectors...)
+}
+
+// handleLocalK8s checks if the cluster is provided by a well known local provider, and if so, ensures that the
+// daemon container can reach it by modifying the server of the cluster and connecting the container to the
+// provider's network.
+func handleLocalK8s(ctx context.Context, daemonID *daemon.Identifier, config *api.Config) error {
+	cc := config.Contexts[config.CurrentContext]
+	cl := config.Clusters[cc.Cluster]
+	server, err := url.Parse(cl.Server)
+	if err != nil {
+		return err
+	}
+	host, portStr, err := net.SplitHostPort(server.Host)
+	if err != nil {
+		// A server without a port can't be a local cluster, because they never use the default port.
+		return nil
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return err
+	}
+	provider, access := detectLocalCluster(ctx, &LocalCluster{Context: cc, Cluster: cl, Host: host, Port: uint16(port)})
+	if access == nil {
+		return nil
+	}
+	dlog.Debugf(ctx, "Detected %s cluster, server %q, network %q", provider, access.Server, access.Network)
+	if access.Server != "" {
+		server.Host = access.Server
+		cl.Server = server.String()
+	}
+	if access.TLSServerName != "" && cl.TLSServerName == "" {
+		cl.TLSServerName = access.TLSServerName
+	}
+	if access.Network != "" {
+		dcName := daemonID.ContainerName()
+		dlog.Debugf(ctx, "Connecting network %s to container %s", access.Network, dcName)
+		if err = connectNetwork(ctx, access.Network, dcName); err != nil {
+			if !strings.Contains(err.Error(), "already exists") {
+				dlog.Debugf(ctx, "failed to connect network %s to container %s: %v", access.Network, dcName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// detectLocalCluster returns the name of the provider of the given cluster and how the daemon container reaches it,
+// or nil if no detector recognizes the cluster.
+func detectLocalCluster(ctx context.Context, lc *LocalCluster) (string, *LocalClusterAccess) {
+	for _, d := range localClusterDetectors {
+		if d.Matches(lc) {
+			if access := d.Detect(ctx, lc); access != nil {
+				return d.Name(), access
+			}
+		}
+	}
+	return "", nil
+}
+
+// containerPort returns the port that the container uses internally to expose the given
+// addrPort on the host. An empty string is returned when the addrPort is not found among
+// the container's port bindings. A binding to an unspecified address matches all loopback
+// and unspecified addresses.
+func containerPort(addrPort netip.AddrPort, ns *types.NetworkSettings) string {
+	for port, bindings := range ns.Ports {
+		for _, binding := range bindings {
+			pn, err := strconv.ParseUint(binding.HostPort, 10, 16)
+			if err != nil || uint16(pn) != addrPort.Port() {
+				continue
+			}
+			addr, err := netip.ParseAddr(binding.HostIP)
+			if err != nil {
+				if binding.HostIP != "" {
+					continue
+				}
+				addr = netip.IPv4Unspecified()
+			}
+			if addr == addrPort.Addr() ||
+				addr.IsUnspecified() && (addrPort.Addr().IsLoopback() || addrPort.Addr().IsUnspecified()) {
+				return port.Port()
+			}
+		}
+	}
+	return ""
+}
+
+// findContainer returns the first container that satisfies the given label predicate and exposes the given
+// hostAddrPort, together with the port that it uses internally.
+func findContainer(cns []types.ContainerJSON, hostAddrPort netip.AddrPort, pred func(labels map[string]string) bool) (*types.ContainerJSON, string) {
+	for i := range cns {
+		cn := &cns[i]
+		if cfg, ns := cn.Config, cn.NetworkSettings; cfg != nil && ns != nil && pred(cfg.Labels) {
+			if port := containerPort(hostAddrPort, ns); port != "" {
+				return cn, port
+			}
+		}
+	}
+	return nil, ""
+}
+
+// containerNetwork returns the given network if the container is connected to it, or else the first network that
+// the container is connected to.
+func containerNetwork(cn *types.ContainerJSON, preferred string) (string, *network.EndpointSettings) {
+	if ep, ok := cn.NetworkSettings.Networks[preferred]; ok && ep != nil {
+		return preferred, ep
+	}
+	for name, ep := range cn.NetworkSettings.Networks {
+		if ep != nil {
+			return name, ep
+		}
+	}
+	return "", nil
+}
+
+// kindDetector detects clusters created by kind. The server is the hostname of the control-plane container, which
+// is included in its certificate.
+type kindDetector struct{}
+
+func (kindDetector) Name() string {
+	return "kind"
+}
+
+func (kindDetector) Matches(lc *LocalCluster) bool {
+	return strings.HasPrefix(lc.Context.Cluster, "kind-")
+}
+
+func (kindDetector) Detect(ctx context.Context, lc *LocalCluster) *LocalClusterAccess {
+	name := strings.TrimPrefix(lc.Context.Cluster, "kind-")
+	cn, port := findContainer(lc.Containers(ctx), lc.AddrPort(), func(labels map[string]string) bool {
+		if cl, ok := labels["io.x-k8s.kind.cluster"]; ok && cl != name {
+			return false
+		}
+		return labels["io.x-k8s.kind.role"] == "control-plane"
+	})
+	if cn == nil {
+		return nil
+	}
+	nw, _ := containerNetwork(cn, "kind")
+	return &LocalClusterAccess{Server: net.JoinHostPort(cn.Config.Hostname, port), Network: nw}
+}
+
+// minikubeDetector detects clusters created by minikube using the docker driver. The server is the IP of the
+// minikube container.
+type minikubeDetector struct{}
+
+func (minikubeDetector) Name() string {
+	return "minikube"
+}
+
+func (minikubeDetector) Matches(lc *LocalCluster) bool {
+	if ex, ok := lc.Cluster.Extensions["cluster_info"].(*runtime2.Unknown); ok {
+		var data map[string]any
+		return json.Unmarshal(ex.Raw, &data) == nil && data["provider"] == "minikube.sigs.k8s.io"
+	}
+	return false
+}
+
+func (minikubeDetector) Detect(ctx context.Context, lc *LocalCluster) *LocalClusterAccess {
+	cn, port := findContainer(lc.Containers(ctx), lc.AddrPort(), func(labels map[string]string) bool {
+		return labels["name.minikube.sigs.k8s.io"] == lc.Context.Cluster
+	})
+	if cn == nil {
+		return nil
+	}
+	nw, ep := containerNetwork(cn, lc.Context.Cluster)
+	if ep == nil {
+		return nil
+	}
+	return &LocalClusterAccess{Server: net.JoinHostPort(ep.IPAddress, port), Network: nw}
+}
+
+// k3dDetector detects clusters created by k3d. The server is the name of the cluster's load balancer container,
+// or of its server container when the cluster has no load balancer. k3d includes both in the certificate.
+type k3dDetector struct{}
+
+func (k3dDetector) Name() string {
+	return "k3d"
+}
+
+func (k3dDetector) Matches(lc *LocalCluster) bool {
+	return strings.HasPrefix(lc.Context.Cluster, "k3d-")
+}
+
+func (k3dDetector) Detect(ctx context.Context, lc *LocalCluster) *LocalClusterAccess {
+	name := strings.TrimPrefix(lc.Context.Cluster, "k3d-")
+	cns := lc.Containers(ctx)
+	for _, role := range []string{"loadbalancer", "server"} {
+		cn, port := findContainer(cns, lc.AddrPort(), func(labels map[string]string) bool {
+			return labels["k3d.cluster"] == name && labels["k3d.role"] == role
+		})
+		if cn != nil {
+			nw, _ := containerNetwork(cn, cn.Config.Labels["k3d.cluster.network"])
+			return &LocalClusterAccess{Server: net.JoinHostPort(strings.TrimPrefix(cn.Name, "/"), port), Network: nw}
+		}
+	}
+	return nil
+}
+
+// talosDetector detects clusters created by "talosctl cluster create" using the docker provisioner. The server is
+// the IP of the control plane container.
+type talosDetector struct{}
+
+func (talosDetector) Name() string {
+	return "talos"
+}
+
+func (talosDetector) Matches(lc *LocalCluster) bool {
+	// Talos clusters can have any name, so all clusters with a local server are candidates.
+	return lc.IsHostLocal()
+}
+
+func (talosDetector) Detect(ctx context.Context, lc *LocalCluster) *LocalClusterAccess {
+	cn, port := findContainer(lc.Containers(ctx), lc.AddrPort(), func(labels map[string]string) bool {
+		return labels["talos.owned"] == "true" && labels["talos.type"] == "controlplane"
+	})
+	if cn == nil {
+		return nil
+	}
+	nw, ep := containerNetwork(cn, cn.Config.Labels["talos.cluster.name"])
+	if ep == nil {
+		return nil
+	}
+	return &LocalClusterAccess{Server: net.JoinHostPort(ep.IPAddress, port), Network: nw}
+}
+
+// dockerDesktopDetector detects the cluster that Docker Desktop provides. Containers reach it using the name
+// kubernetes.docker.internal, which is also included in its certificate.
+type dockerDesktopDetector struct{}
+
+const dockerDesktopHost = "kubernetes.docker.internal"
+
+func (dockerDesktopDetector) Name() string {
+	return "docker-desktop"
+}
+
+func (dockerDesktopDetector) Matches(lc *LocalCluster) bool {
+	return lc.Context.Cluster == "docker-desktop"
+}
+
+func (dockerDesktopDetector) Detect(_ context.Context, lc *LocalCluster) *LocalClusterAccess {
+	if lc.Host == dockerDesktopHost {
+		return &LocalClusterAccess{}
+	}
+	return &LocalClusterAccess{Server: net.JoinHostPort(dockerDesktopHost, strconv.Itoa(int(lc.Port)))}
+}
+
+// vmDetector detects a cluster that runs in the same VM as the container engine, and is exposed on the host by
+// forwarding a local port to the same port in the VM. The daemon container reaches the VM using the gateway of
+// the telepresence network, and the certificate is verified using the host in the kubeconfig.
+type vmDetector struct {
+	name  string
+	match func(cluster string) bool
+}
+
+func (d *vmDetector) Name() string {
+	return d.name
+}
+
+func (d *vmDetector) Matches(lc *LocalCluster) bool {
+	return d.match(lc.Context.Cluster) && lc.IsHostLocal()
+}
+
+func (d *vmDetector) Detect(ctx context.Context, lc *LocalCluster) *LocalClusterAccess {
+	gw := lc.NetworkGateway(ctx, "telepresence")
+	if gw == "" {
+		return nil
+	}
+	tlsName := lc.Host
+	if lc.AddrPort().Addr().IsUnspecified() {
+		tlsName = "127.0.0.1"
+	}
+	return &LocalClusterAccess{Server: net.JoinHostPort(gw, strconv.Itoa(int(lc.Port))), TLSServerName: tlsName}
+}
+
+// runningContainers returns the inspect data for all containers with status=running.
+func runningContainers(ctx context.Context) []types.ContainerJSON {
+	if !GetEngine(ctx).HasAPI() {
+		return runningContainersCLI(ctx)
+	}
+	cli, err := GetClient(ctx)
+	if err != nil {
+		dlog.Errorf(ctx, "failed to list containers: %v", err)
+		return nil
+	}
+	cl, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{Key: "status", Value: "running"}),
+	})
+	if err != nil {
+		dlog.Errorf(ctx, "failed to list containers: %v", err)
+		return nil
+	}
+	cjs := make([]types.ContainerJSON, 0, len(cl))
+	for _, cn := range cl {
+		cj, err := cli.ContainerInspect(ctx, cn.ID)
+		if err != nil {
+			dlog.Errorf(ctx, "container inspect on %v failed: %v", cn.Names, err)
+		} else {
+			cjs = append(cjs, cj)
+		}
+	}
+	return cjs
+}
+
+// runningContainersCLI returns the inspect data for all containers with status=running using the command line of
+// the engine, which must produce Docker compatible output.
+func runningContainersCLI(ctx context.Context) []types.ContainerJSON {
+	ids, err := runCLI(ctx, "ps", "--filter", "status=running", "--format", "{{.ID}}")
+	if err != nil || ids == "" {
+		if err != nil {
+			dlog.Errorf(ctx, "failed to list containers: %v", err)
+		}
+		return nil
+	}
+	js, err := runCLI(ctx, append([]string{"inspect"}, strings.Fields(ids)...)...)
+	if err != nil {
+		dlog.Errorf(ctx, "failed to inspect containers: %v", err)
+		return nil
+	}
+	var cjs []types.ContainerJSON
+	if err = json.Unmarshal([]byte(js), &cjs); err != nil {
+		dlog.Errorf(ctx, "failed to parse container inspect output: %v", err)
+		return nil
+	}
+	return cjs
+}
+
+// networkGateway returns the gateway IP of the given network, or an empty string if it can't be determined.
+func networkGateway(ctx context.Context, name string) string {
+	gw, err := runCLI(ctx, "network", "inspect", "--format", "{{range .IPAM.Config}}{{.Gateway}} {{end}}", name)
+	if err != nil {
+		dlog.Debug(ctx, err)
+		return ""
+	}
+	for _, ip := range strings.Fields(gw) {
+		if addr, err := netip.ParseAddr(ip); err == nil && addr.Is4() {
+			return ip
+		}
+	}
+	return ""
+}
+
+// connectNetwork connects the given network to the given container.
+func connectNetwork(ctx context.Context, name, container string) error {
+	if !GetEngine(ctx).HasAPI() {
+		_, err := runCLI(ctx, "network", "connect", name, container)
+		return err
+	}
+	cli, err := GetClient(ctx)
+	if err != nil {
+		return err
+	}
+	return cli.NetworkConnect(ctx, name, container, nil)
+}
@@ -0,0 +1,140 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime2 "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func loadContainers(t *testing.T, fixture string) []types.ContainerJSON {
+	data, err := os.ReadFile(filepath.Join("testdata", "localcluster", fixture))
+	require.NoError(t, err)
+	var cjs []types.ContainerJSON
+	require.NoError(t, json.Unmarshal(data, &cjs))
+	return cjs
+}
+
+func TestLocalClusterDetectors(t *testing.T) {
+	minikubeExt := map[string]runtime2.Object{
+		"cluster_info": &runtime2.Unknown{Raw: []byte(`{"provider":"minikube.sigs.k8s.io","version":"v1.32.0"}`)},
+	}
+	tests := []struct {
+		name       string
+		fixture    string
+		cluster    string
+		extensions map[string]runtime2.Object
+		host       string
+		port       uint16
+		gateways   map[string]string
+		detector   string
+		access     *LocalClusterAccess
+	}{
+		{
+			name:     "kind",
+			fixture:  "kind.json",
+			cluster:  "kind-dev",
+			host:     "127.0.0.1",
+			port:     41237,
+			detector: "kind",
+			access:   &LocalClusterAccess{Server: "dev-control-plane:6443", Network: "kind"},
+		},
+		{
+			name:     "kind other cluster's port",
+			fixture:  "kind.json",
+			cluster:  "kind-dev",
+			host:     "127.0.0.1",
+			port:     41238,
+			detector: "",
+		},
+		{
+			name:       "minikube",
+			fixture:    "minikube.json",
+			cluster:    "minikube",
+			extensions: minikubeExt,
+			host:       "127.0.0.1",
+			port:       32769,
+			detector:   "minikube",
+			access:     &LocalClusterAccess{Server: "192.168.49.2:8443", Network: "minikube"},
+		},
+		{
+			name:     "k3d",
+			fixture:  "k3d.json",
+			cluster:  "k3d-dev",
+			host:     "0.0.0.0",
+			port:     38447,
+			detector: "k3d",
+			access:   &LocalClusterAccess{Server: "k3d-dev-serverlb:6443", Network: "k3d-dev"},
+		},
+		{
+			name:     "talos",
+			fixture:  "talos.json",
+			cluster:  "talos-default",
+			host:     "127.0.0.1",
+			port:     35201,
+			detector: "talos",
+			access:   &LocalClusterAccess{Server: "10.5.0.2:6443", Network: "talos-default"},
+		},
+		{
+			name:     "docker-desktop",
+			cluster:  "docker-desktop",
+			host:     "127.0.0.1",
+			port:     6443,
+			detector: "docker-desktop",
+			access:   &LocalClusterAccess{Server: "kubernetes.docker.internal:6443"},
+		},
+		{
+			name:     "rancher-desktop",
+			cluster:  "rancher-desktop",
+			host:     "127.0.0.1",
+			port:     6443,
+			gateways: map[string]string{"telepresence": "172.19.0.1"},
+			detector: "rancher-desktop",
+			access:   &LocalClusterAccess{Server: "172.19.0.1:6443", TLSServerName: "127.0.0.1"},
+		},
+		{
+			name:     "colima profile",
+			cluster:  "colima-dev",
+			host:     "127.0.0.1",
+			port:     6443,
+			gateways: map[string]string{"telepresence": "172.19.0.1"},
+			detector: "colima",
+			access:   &LocalClusterAccess{Server: "172.19.0.1:6443", TLSServerName: "127.0.0.1"},
+		},
+		{
+			name:     "remote cluster",
+			fixture:  "talos.json",
+			cluster:  "prod",
+			host:     "10.1.2.3",
+			port:     6443,
+			detector: "",
+		},
+	}
+	ctx := dlog.NewTestContext(t, false)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := &LocalCluster{
+				Context:  &api.Context{Cluster: tt.cluster},
+				Cluster:  &api.Cluster{Extensions: tt.extensions},
+				Host:     tt.host,
+				Port:     tt.port,
+				listed:   true,
+				gateways: tt.gateways,
+			}
+			if tt.fixture != "" {
+				lc.containers = loadContainers(t, tt.fixture)
+			}
+			detector, access := detectLocalCluster(ctx, lc)
+			assert.Equal(t, tt.detector, detector)
+			assert.Equal(t, tt.access, access)
+		})
+	}
+}
@@ -15,6 +15,12 @@ import (
 
 // EnsureNetwork checks if a network with the given name exists, and creates it if that is not the case.
 func EnsureNetwork(ctx context.Context, name string) error {
+	return GetEngine(ctx).EnsureNetwork(ctx, name)
+}
+
+// ensureNetworkAPI uses the Docker API to create an IPv6 enabled network unless it exists. An existing network
+// without IPv6 is recreated.
+func ensureNetworkAPI(ctx context.Context, name string) error {
 	cli, err := GetClient(ctx)
 	if err != nil {
 		return err
@@ -116,3 +122,19 @@ func EnsureNetwork(ctx context.Context, name string) error {
 	}
 	return err
 }
+
+// ensureNetworkCLI uses the command line of the engine to create the network unless it exists. An IPv6 enabled
+// network is preferred but not required, because its creation might require an explicit IPv6 subnet.
+func ensureNetworkCLI(ctx context.Context, name string) error {
+	if _, err := runCLI(ctx, "network", "inspect", name); err == nil {
+		dlog.Debugf(ctx, "found network %s", name)
+		return nil
+	}
+	_, err := runCLI(ctx, "network", "create", "--ipv6", name)
+	if err == nil {
+		return nil
+	}
+	dlog.Debug(ctx, err)
+	_, err = runCLI(ctx, "network", "create", name)
+	return err
+}
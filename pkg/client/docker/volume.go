@@ -12,6 +12,7 @@ import (
 	"github.com/docker/docker/client"
 
 	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 )
 
@@ -20,6 +21,9 @@ const TelemountPlugin = "datawire/telemount:" + runtime.GOARCH
 // EnsureVolumePlugin checks if the datawire/telemount plugin is installed and installs it if that is
 // not the case. The plugin is also enabled.
 func EnsureVolumePlugin(ctx context.Context) error {
+	if engine := GetEngine(ctx); !engine.VolumePlugins() {
+		return errcat.Config.Newf("the %s container engine doesn't support volume plugins", engine.Name())
+	}
 	cli, err := GetClient(ctx)
 	if err != nil {
 		return err
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// EnvTransform is a rule that transforms the environment of an intercepted container before it is used locally.
+// A rule performs exactly one of Drop, RewritePath, or Set. The rules are applied in order, so a rule sees the
+// result of the rules that precede it.
+type EnvTransform struct {
+	// Keys is a glob pattern that limits the variables that Drop and RewritePath apply to. All variables
+	// are affected when Keys is empty.
+	Keys string `json:"keys,omitempty" yaml:"keys,omitempty"`
+
+	// Drop removes the variables.
+	Drop bool `json:"drop,omitempty" yaml:"drop,omitempty"`
+
+	// RewritePath is an absolute path in the intercepted container. Values that are equal to, or located below,
+	// this path are prefixed with the local mount point of the remote volumes, i.e. $TELEPRESENCE_ROOT. This
+	// also applies to each element of a colon separated list of paths.
+	RewritePath string `json:"rewritePath,omitempty" yaml:"rewritePath,omitempty"`
+
+	// Set is the name of a variable that is added or overridden with Value.
+	Set string `json:"set,omitempty" yaml:"set,omitempty"`
+
+	// Value is a Go template that produces the value of the variable named by Set. The template data is the
+	// environment, so {{ .TELEPRESENCE_ROOT }} is the local mount point, and the localEnv function returns
+	// the value of a variable in the local environment, e.g. {{ localEnv "HOME" }}.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+func (et *EnvTransform) validate() error {
+	n := 0
+	if et.Drop {
+		n++
+	}
+	if et.RewritePath != "" {
+		n++
+		if !path.IsAbs(et.RewritePath) {
+			return fmt.Errorf("envTransforms: rewritePath %q is not an absolute path", et.RewritePath)
+		}
+	}
+	if et.Set != "" {
+		n++
+		if et.Keys != "" {
+			return fmt.Errorf("envTransforms: keys cannot be combined with set")
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("envTransforms: a rule must have exactly one of drop, rewritePath, or set")
+	}
+	if et.Keys != "" {
+		if _, err := path.Match(et.Keys, ""); err != nil {
+			return fmt.Errorf("envTransforms: invalid keys pattern %q: %w", et.Keys, err)
+		}
+	}
+	return nil
+}
+
+func (et *EnvTransform) matches(key string) bool {
+	if et.Keys == "" {
+		return true
+	}
+	ok, _ := path.Match(et.Keys, key)
+	return ok
+}
+
+// splitPathList splits the given colon separated list of paths. A colon that is part of the volume name at the
+// start of an element, such as the drive letter of a rewritten path on Windows, doesn't separate elements.
+func splitPathList(value string) []string {
+	var elems []string
+	for {
+		vn := len(filepath.VolumeName(value))
+		i := strings.IndexByte(value[vn:], ':')
+		if i < 0 {
+			return append(elems, value)
+		}
+		elems = append(elems, value[:vn+i])
+		value = value[vn+i+1:]
+	}
+}
+
+func rewritePath(value, prefix, root string) string {
+	elems := splitPathList(value)
+	for i, e := range elems {
+		if e == prefix || strings.HasPrefix(e, prefix+"/") {
+			elems[i] = root + e
+		}
+	}
+	return strings.Join(elems, ":")
+}
+
+// TransformEnv applies the given rules to a copy of the given environment and returns the result. The root is the
+// local mount point of the remote volumes, and paths aren't rewritten when it is empty.
+func TransformEnv(env map[string]string, root string, rules []EnvTransform) (map[string]string, error) {
+	result := make(map[string]string, len(env))
+	for k, v := range env {
+		result[k] = v
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		switch {
+		case rule.Drop:
+			for k := range result {
+				if rule.matches(k) {
+					delete(result, k)
+				}
+			}
+		case rule.RewritePath != "":
+			if root == "" {
+				continue
+			}
+			prefix := path.Clean(rule.RewritePath)
+			for k, v := range result {
+				if rule.matches(k) {
+					result[k] = rewritePath(v, prefix, root)
+				}
+			}
+		default:
+			tpl, err := template.New(rule.Set).
+				Option("missingkey=zero").
+				Funcs(template.FuncMap{"localEnv": os.Getenv}).
+				Parse(rule.Value)
+			if err != nil {
+				return nil, fmt.Errorf("envTransforms: invalid value for %s: %w", rule.Set, err)
+			}
+			sb := strings.Builder{}
+			if err = tpl.Execute(&sb, result); err != nil {
+				return nil, fmt.Errorf("envTransforms: unable to compute value for %s: %w", rule.Set, err)
+			}
+			result[rule.Set] = sb.String()
+		}
+	}
+	return result, nil
+}
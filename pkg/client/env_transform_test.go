@@ -0,0 +1,78 @@
+package client
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformEnv(t *testing.T) {
+	t.Setenv("TEL_TEST_LOCAL", "local-value")
+	env := map[string]string{
+		"TELEPRESENCE_ROOT":   "/tmp/telfs-1",
+		"CA_FILE":             "/var/run/secrets/kubernetes.io/ca.crt",
+		"CONFIG_PATH":         "/etc/config:/var/run/secrets/app:/var/run/secretsx",
+		"SECRETS_DIR":         "/var/run/secrets",
+		"KUBERNETES_SERVICE":  "10.96.0.1",
+		"KUBERNETES_PORT":     "tcp://10.96.0.1:443",
+		"DB_HOST":             "postgres.db.svc.cluster.local",
+		"UNTOUCHED_SECRETDIR": "/var/run/secrets",
+	}
+	rules := []EnvTransform{
+		{Keys: "*_FILE", RewritePath: "/var/run/secrets"},
+		{Keys: "*_PATH", RewritePath: "/var/run/secrets/"},
+		{Keys: "SECRETS_*", RewritePath: "/var/run/secrets"},
+		{Keys: "KUBERNETES_*", Drop: true},
+		{Set: "DB_HOST", Value: "localhost"},
+		{Set: "DB_URL", Value: "postgres://{{ .DB_HOST }}:5432/{{ .MISSING }}"},
+		{Set: "LOCAL", Value: `{{ localEnv "TEL_TEST_LOCAL" }}`},
+	}
+	result, err := TransformEnv(env, "/tmp/telfs-1", rules)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"TELEPRESENCE_ROOT":   "/tmp/telfs-1",
+		"CA_FILE":             "/tmp/telfs-1/var/run/secrets/kubernetes.io/ca.crt",
+		"CONFIG_PATH":         "/etc/config:/tmp/telfs-1/var/run/secrets/app:/var/run/secretsx",
+		"SECRETS_DIR":         "/tmp/telfs-1/var/run/secrets",
+		"DB_HOST":             "localhost",
+		"DB_URL":              "postgres://localhost:5432/",
+		"LOCAL":               "local-value",
+		"UNTOUCHED_SECRETDIR": "/var/run/secrets",
+	}, result)
+	assert.Equal(t, "/var/run/secrets/kubernetes.io/ca.crt", env["CA_FILE"], "original must not be modified")
+
+	result, err = TransformEnv(env, "", rules[:1])
+	require.NoError(t, err)
+	assert.Equal(t, env["CA_FILE"], result["CA_FILE"], "paths are not rewritten without a mount")
+}
+
+func TestTransformEnv_volumeName(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("volume names are only used on windows")
+	}
+	env := map[string]string{"CONFIG_PATH": "/etc/config:/var/run/secrets/app"}
+	rules := []EnvTransform{
+		{RewritePath: "/var/run/secrets"},
+		{RewritePath: "/var/run"},
+	}
+	result, err := TransformEnv(env, "T:", rules)
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/config:T:/var/run/secrets/app", result["CONFIG_PATH"])
+}
+
+func TestTransformEnv_invalid(t *testing.T) {
+	invalid := []EnvTransform{
+		{},
+		{Drop: true, Set: "X"},
+		{RewritePath: "var/run"},
+		{Keys: "[", Drop: true},
+		{Keys: "X*", Set: "X"},
+		{Set: "X", Value: "{{ .X "},
+	}
+	for _, rule := range invalid {
+		_, err := TransformEnv(map[string]string{}, "/tmp", []EnvTransform{rule})
+		assert.Error(t, err, "%+v", rule)
+	}
+}
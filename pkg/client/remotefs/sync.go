@@ -0,0 +1,463 @@
+package remotefs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+const (
+	// MountModeFUSE mounts the remote volumes using a FUSE file system. This is the default.
+	MountModeFUSE = "fuse"
+
+	// MountModeSync pulls the remote volumes into a local directory and keeps that directory in
+	// sync with remote changes.
+	MountModeSync = "sync"
+
+	// MountModeSyncRW is like MountModeSync, but also pushes local changes to the remote volumes.
+	MountModeSyncRW = "sync-rw"
+)
+
+const (
+	// SyncCleanupRemove removes the local directory of a sync mount when the intercept ends.
+	SyncCleanupRemove = "remove"
+
+	// SyncCleanupClear removes the content of the local directory of a sync mount when the intercept ends, but
+	// retains the directory itself.
+	SyncCleanupClear = "clear"
+
+	// SyncCleanupKeep retains the local directory of a sync mount and its content when the intercept ends.
+	SyncCleanupKeep = "keep"
+)
+
+// syncInterval is the minimum time between the starts of two consecutive synchronizations of a sync mount.
+const syncInterval = 2 * time.Second
+
+// syncThrottle is the factor that the duration of a synchronization is multiplied with to get the time until the
+// next one starts, unless that is less than the syncInterval. It limits the load that the walks of large remote
+// trees put on the traffic-agent to roughly 1/syncThrottle.
+const syncThrottle = 10
+
+// maxSyncDepth limits the depth of the synchronized directory tree, which guards against symlink loops.
+const maxSyncDepth = 32
+
+type syncMounter struct {
+	sync.Mutex
+	iceptCtx    context.Context
+	iceptWG     *sync.WaitGroup
+	podWG       *sync.WaitGroup
+	readWrite   bool
+	cleanup     string
+	cleanupOnce sync.Once
+}
+
+// NewSyncMounter returns a Mounter that doesn't mount anything. Instead, it copies the remote directory into the
+// local directory, and then polls the remote SFTP server and copies the files that change. Local changes are pushed
+// to the remote directory when readWrite is true. The Mounter doesn't need FUSE, and works on all platforms.
+//
+// The cleanup is one of SyncCleanupRemove, SyncCleanupClear, and SyncCleanupKeep, and determines what happens with
+// the local directory when the given intercept context is cancelled. An empty cleanup means SyncCleanupKeep.
+func NewSyncMounter(iceptCtx context.Context, iceptWG, podWG *sync.WaitGroup, readWrite bool, cleanup string) Mounter {
+	return &syncMounter{iceptCtx: iceptCtx, iceptWG: iceptWG, podWG: podWG, readWrite: readWrite, cleanup: cleanup}
+}
+
+func (m *syncMounter) Start(ctx context.Context, id, clientMountPoint, mountPoint string, podIP net.IP, port uint16) error {
+	addr := iputil.JoinIpPort(podIP, port)
+	ctx = dgroup.WithGoroutineName(ctx, addr)
+	m.cleanupOnce.Do(func() {
+		if m.cleanup == SyncCleanupRemove || m.cleanup == SyncCleanupClear {
+			m.iceptWG.Add(1)
+			go m.cleanupOnEnd(ctx, clientMountPoint)
+		}
+	})
+
+	// The sync is terminated and restarted when the intercept pod changes, so we
+	// must set up a wait/done pair here to ensure that this happens synchronously
+	m.podWG.Add(1)
+	m.iceptWG.Add(1)
+	go func() {
+		defer m.iceptWG.Done()
+		defer m.podWG.Done()
+
+		// Be really sure that the following doesn't happen in parallel using multiple
+		// pods for the same intercept. One must die before the next is created.
+		m.Lock()
+		defer m.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+
+		dlog.Infof(ctx, "Synchronizing remote file system for intercept %q (pod %s) with %q", id, podIP, clientMountPoint)
+		defer dlog.Infof(ctx, "Ending synchronization of remote file system for intercept %q (pod %s) with %q", id, podIP, clientMountPoint)
+
+		// The syncer retains its state between reconnects so that local changes made while the
+		// connection was down are pushed rather than overwritten.
+		s := newSyncer(mountPoint, clientMountPoint, m.readWrite)
+		err := client.Retry(ctx, "sftp sync", func(ctx context.Context) error {
+			conn, err := (&net.Dialer{Timeout: 10 * time.Second}).DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			sc, err := sftp.NewClientPipe(conn, conn)
+			if err != nil {
+				_ = conn.Close()
+				return err
+			}
+			defer sc.Close()
+			return s.run(ctx, sc)
+		}, 3*time.Second, 6*time.Second)
+		if err != nil {
+			dlog.Error(ctx, err)
+		}
+	}()
+	return nil
+}
+
+// cleanupOnEnd waits for the intercept to end and for the last synchronization to finish, and then removes the
+// synchronized files, and also the local directory itself when the cleanup is SyncCleanupRemove.
+func (m *syncMounter) cleanupOnEnd(ctx context.Context, localDir string) {
+	defer m.iceptWG.Done()
+	<-m.iceptCtx.Done()
+	m.Lock()
+	defer m.Unlock()
+	var err error
+	if m.cleanup == SyncCleanupRemove {
+		err = os.RemoveAll(localDir)
+	} else {
+		var des []os.DirEntry
+		if des, err = os.ReadDir(localDir); err == nil {
+			for _, de := range des {
+				if err = os.RemoveAll(filepath.Join(localDir, de.Name())); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil && !os.IsNotExist(err) {
+		dlog.Errorf(ctx, "failed to remove the synchronized files in %s: %v", localDir, err)
+	} else {
+		dlog.Debugf(ctx, "Removed the synchronized files in %s", localDir)
+	}
+}
+
+// syncEntry is the state of a synchronized file or directory.
+type syncEntry struct {
+	dir   bool
+	size  int64
+	mtime int64 // seconds, because that's the resolution of SFTP
+}
+
+func newSyncEntry(fi fs.FileInfo) syncEntry {
+	if fi.IsDir() {
+		return syncEntry{dir: true}
+	}
+	return syncEntry{size: fi.Size(), mtime: fi.ModTime().Unix()}
+}
+
+// syncedPair is the state of a remote and a local entry when they were last synchronized.
+type syncedPair struct {
+	remote syncEntry
+	local  syncEntry
+}
+
+type syncer struct {
+	remoteDir string
+	localDir  string
+	readWrite bool
+
+	// synced is keyed by slash separated paths relative to the remoteDir and localDir.
+	synced map[string]syncedPair
+}
+
+func newSyncer(remoteDir, localDir string, readWrite bool) *syncer {
+	return &syncer{
+		remoteDir: remoteDir,
+		localDir:  localDir,
+		readWrite: readWrite,
+		synced:    make(map[string]syncedPair),
+	}
+}
+
+// run synchronizes repeatedly until the context is cancelled or an error occurs. The time between the starts of
+// two synchronizations is syncThrottle times the duration of the first one, but never less than the syncInterval.
+func (s *syncer) run(ctx context.Context, sc *sftp.Client) error {
+	for {
+		start := time.Now()
+		if err := s.sync(ctx, sc); err != nil {
+			return err
+		}
+		elapsed := time.Since(start)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(max(syncInterval, syncThrottle*elapsed) - elapsed):
+		}
+	}
+}
+
+func (s *syncer) localPath(p string) string {
+	return filepath.Join(s.localDir, filepath.FromSlash(p))
+}
+
+func (s *syncer) remotePath(p string) string {
+	return path.Join(s.remoteDir, p)
+}
+
+// sync performs one synchronization. Errors that concern individual files are logged, and an error is
+// returned only when the remote directory cannot be read.
+func (s *syncer) sync(ctx context.Context, sc *sftp.Client) error {
+	remote := make(map[string]syncEntry)
+	if err := s.walkRemote(ctx, sc, "", 0, remote); err != nil {
+		return err
+	}
+	local := make(map[string]syncEntry)
+	if err := os.MkdirAll(s.localDir, 0o700); err != nil {
+		return err
+	}
+	s.walkLocal(ctx, local)
+
+	all := make(map[string]struct{}, len(remote)+len(s.synced))
+	for p := range remote {
+		all[p] = struct{}{}
+	}
+	for p := range local {
+		all[p] = struct{}{}
+	}
+	for p := range s.synced {
+		all[p] = struct{}{}
+	}
+	paths := make([]string, 0, len(all))
+	for p := range all {
+		paths = append(paths, p)
+	}
+	// Parents are sorted before their children.
+	sort.Strings(paths)
+
+	removed := ""
+	for _, p := range paths {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if removed != "" && strings.HasPrefix(p, removed+"/") {
+			// Already removed together with its parent directory.
+			delete(s.synced, p)
+			continue
+		}
+		r, rOk := remote[p]
+		l, lOk := local[p]
+		prev, known := s.synced[p]
+
+		var err error
+		switch {
+		case rOk && (!known || r != prev.remote):
+			// New or modified remote entry.
+			if known && lOk && l != prev.local && !l.dir {
+				dlog.Warnf(ctx, "%s was modified both locally and remotely. The remote version wins", s.localPath(p))
+			}
+			err = s.pull(sc, p, r)
+		case !rOk && known:
+			// Removed remotely.
+			if lOk && l != prev.local && s.readWrite {
+				err = s.push(sc, p, l)
+			} else {
+				delete(s.synced, p)
+				if lOk {
+					removed = p
+					err = os.RemoveAll(s.localPath(p))
+				}
+			}
+		case !s.readWrite:
+			// Local changes stay local.
+		case rOk && !lOk:
+			// Removed locally.
+			delete(s.synced, p)
+			removed = p
+			err = sc.RemoveAll(s.remotePath(p))
+		case lOk && (!known || l != prev.local):
+			// New or modified local entry.
+			err = s.push(sc, p, l)
+		}
+		if err != nil {
+			dlog.Errorf(ctx, "failed to synchronize %s: %v", s.localPath(p), err)
+		}
+	}
+	return nil
+}
+
+// walkRemote adds all entries found in the given remote directory to the entries map. Symbolic links are followed.
+// Names that start with ".." are skipped, because those are used for the internal files of Kubernetes secrets and
+// config maps.
+func (s *syncer) walkRemote(ctx context.Context, sc *sftp.Client, dir string, depth int, entries map[string]syncEntry) error {
+	fis, err := sc.ReadDir(s.remotePath(dir))
+	if err != nil {
+		if dir != "" {
+			// Probably removed during the walk. It will be picked up later.
+			dlog.Debugf(ctx, "failed to read remote directory %s: %v", s.remotePath(dir), err)
+			return nil
+		}
+		return err
+	}
+	for _, fi := range fis {
+		name := fi.Name()
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+		p := path.Join(dir, name)
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			if fi, err = sc.Stat(s.remotePath(p)); err != nil {
+				continue
+			}
+		}
+		if !(fi.IsDir() || fi.Mode().IsRegular()) {
+			continue
+		}
+		entries[p] = newSyncEntry(fi)
+		if fi.IsDir() && depth < maxSyncDepth {
+			if err = s.walkRemote(ctx, sc, p, depth+1, entries); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkLocal adds all regular files and directories found in the local directory to the entries map.
+func (s *syncer) walkLocal(ctx context.Context, entries map[string]syncEntry) {
+	_ = filepath.WalkDir(s.localDir, func(lp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			dlog.Debugf(ctx, "failed to read local directory %s: %v", lp, err)
+			return nil
+		}
+		if lp == s.localDir {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil || !(fi.IsDir() || fi.Mode().IsRegular()) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.localDir, lp)
+		if err != nil {
+			return nil
+		}
+		p := filepath.ToSlash(rel)
+		entries[p] = newSyncEntry(fi)
+		if fi.IsDir() && strings.Count(p, "/") >= maxSyncDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// pull copies the remote entry to the local directory.
+func (s *syncer) pull(sc *sftp.Client, p string, r syncEntry) error {
+	lp := s.localPath(p)
+	if r.dir {
+		if fi, err := os.Lstat(lp); err == nil && !fi.IsDir() {
+			if err = os.Remove(lp); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(lp, 0o700); err != nil {
+			return err
+		}
+		s.synced[p] = syncedPair{remote: r, local: r}
+		return nil
+	}
+
+	rf, err := sc.Open(s.remotePath(p))
+	if err != nil {
+		return err
+	}
+	defer rf.Close()
+	fi, err := rf.Stat()
+	if err != nil {
+		return err
+	}
+	// The remote file might have changed since the walk.
+	r = newSyncEntry(fi)
+
+	if err = os.RemoveAll(lp); err != nil {
+		return err
+	}
+	lf, err := os.OpenFile(lp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(lf, rf)
+	if cErr := lf.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+	mt := time.Unix(r.mtime, 0)
+	if err = os.Chtimes(lp, mt, mt); err != nil {
+		return err
+	}
+	if fi, err = os.Stat(lp); err != nil {
+		return err
+	}
+	s.synced[p] = syncedPair{remote: r, local: newSyncEntry(fi)}
+	return nil
+}
+
+// push copies the local entry to the remote directory.
+func (s *syncer) push(sc *sftp.Client, p string, l syncEntry) error {
+	rp := s.remotePath(p)
+	if l.dir {
+		if err := sc.MkdirAll(rp); err != nil {
+			return err
+		}
+		s.synced[p] = syncedPair{remote: l, local: l}
+		return nil
+	}
+
+	lp := s.localPath(p)
+	lf, err := os.Open(lp)
+	if err != nil {
+		return err
+	}
+	defer lf.Close()
+	fi, err := lf.Stat()
+	if err != nil {
+		return err
+	}
+	l = newSyncEntry(fi)
+
+	rf, err := sc.OpenFile(rp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(rf, lf)
+	if cErr := rf.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write remote file %s: %w", rp, err)
+	}
+	mt := time.Unix(l.mtime, 0)
+	if err = sc.Chtimes(rp, mt, mt); err != nil {
+		return err
+	}
+	if fi, err = sc.Stat(rp); err != nil {
+		return err
+	}
+	s.synced[p] = syncedPair{remote: newSyncEntry(fi), local: l}
+	return nil
+}
@@ -0,0 +1,135 @@
+package remotefs
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// sftpClient returns a client that is connected to an in-process SFTP server.
+func sftpClient(t *testing.T) *sftp.Client {
+	sConn, cConn := net.Pipe()
+	srv, err := sftp.NewServer(sConn)
+	require.NoError(t, err)
+	go func() { _ = srv.Serve() }()
+	sc, err := sftp.NewClientPipe(cConn, cConn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sc.Close()
+		_ = srv.Close()
+	})
+	return sc
+}
+
+func writeFile(t *testing.T, name, content string, mtime time.Time) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o700))
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	require.NoError(t, os.Chtimes(name, mtime, mtime))
+}
+
+func assertContent(t *testing.T, name, content string) {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if assert.NoError(t, err) {
+		assert.Equal(t, content, string(data))
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	for _, readWrite := range []bool{false, true} {
+		name := MountModeSync
+		if readWrite {
+			name = MountModeSyncRW
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := dlog.NewTestContext(t, false)
+			sc := sftpClient(t)
+			remoteDir := t.TempDir()
+			localDir := filepath.Join(t.TempDir(), "mnt")
+			then := time.Now().Add(-time.Hour)
+
+			writeFile(t, filepath.Join(remoteDir, "a.txt"), "a", then)
+			writeFile(t, filepath.Join(remoteDir, "sub", "b.txt"), "b", then)
+			writeFile(t, filepath.Join(remoteDir, "..data", "secret"), "internal", then)
+			require.NoError(t, os.Mkdir(filepath.Join(remoteDir, "empty"), 0o700))
+			require.NoError(t, os.Symlink("a.txt", filepath.Join(remoteDir, "link")))
+
+			s := newSyncer(remoteDir, localDir, readWrite)
+			require.NoError(t, s.sync(ctx, sc))
+			assertContent(t, filepath.Join(localDir, "a.txt"), "a")
+			assertContent(t, filepath.Join(localDir, "sub", "b.txt"), "b")
+			assertContent(t, filepath.Join(localDir, "link"), "a")
+			assert.DirExists(t, filepath.Join(localDir, "empty"))
+			assert.NoDirExists(t, filepath.Join(localDir, "..data"))
+
+			// Remote changes are pulled.
+			writeFile(t, filepath.Join(remoteDir, "a.txt"), "aa", then.Add(time.Minute))
+			writeFile(t, filepath.Join(remoteDir, "c.txt"), "c", then)
+			require.NoError(t, os.RemoveAll(filepath.Join(remoteDir, "sub")))
+			require.NoError(t, s.sync(ctx, sc))
+			assertContent(t, filepath.Join(localDir, "a.txt"), "aa")
+			assertContent(t, filepath.Join(localDir, "c.txt"), "c")
+			assert.NoDirExists(t, filepath.Join(localDir, "sub"))
+
+			// Local changes are pushed when readWrite is true.
+			writeFile(t, filepath.Join(localDir, "c.txt"), "cc", then.Add(time.Minute))
+			writeFile(t, filepath.Join(localDir, "new", "d.txt"), "d", then)
+			require.NoError(t, os.Remove(filepath.Join(localDir, "a.txt")))
+			require.NoError(t, s.sync(ctx, sc))
+			if readWrite {
+				assertContent(t, filepath.Join(remoteDir, "c.txt"), "cc")
+				assertContent(t, filepath.Join(remoteDir, "new", "d.txt"), "d")
+				assert.NoFileExists(t, filepath.Join(remoteDir, "a.txt"))
+			} else {
+				assertContent(t, filepath.Join(remoteDir, "c.txt"), "c")
+				assert.NoDirExists(t, filepath.Join(remoteDir, "new"))
+				assert.FileExists(t, filepath.Join(remoteDir, "a.txt"))
+			}
+
+			// Remote wins when both sides have changed.
+			writeFile(t, filepath.Join(remoteDir, "c.txt"), "remote", then.Add(2*time.Minute))
+			writeFile(t, filepath.Join(localDir, "c.txt"), "local", then.Add(3*time.Minute))
+			require.NoError(t, s.sync(ctx, sc))
+			assertContent(t, filepath.Join(localDir, "c.txt"), "remote")
+		})
+	}
+}
+
+func TestSyncMounterCleanup(t *testing.T) {
+	for _, cleanup := range []string{SyncCleanupRemove, SyncCleanupClear, SyncCleanupKeep} {
+		t.Run(cleanup, func(t *testing.T) {
+			ctx := dlog.NewTestContext(t, false)
+			iceptCtx, cancel := context.WithCancel(ctx)
+			iceptWG := &sync.WaitGroup{}
+			podWG := &sync.WaitGroup{}
+			localDir := filepath.Join(t.TempDir(), "mnt")
+			writeFile(t, filepath.Join(localDir, "sub", "a.txt"), "a", time.Now())
+
+			// Nothing listens on the port, so the mounter just retries until the intercept ends.
+			m := NewSyncMounter(iceptCtx, iceptWG, podWG, false, cleanup)
+			require.NoError(t, m.Start(iceptCtx, "id", localDir, "/", net.IP{127, 0, 0, 1}, 1))
+			cancel()
+			iceptWG.Wait()
+
+			switch cleanup {
+			case SyncCleanupRemove:
+				assert.NoDirExists(t, localDir)
+			case SyncCleanupClear:
+				assert.DirExists(t, localDir)
+				assert.NoDirExists(t, filepath.Join(localDir, "sub"))
+			default:
+				assertContent(t, filepath.Join(localDir, "sub", "a.txt"), "a")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package rootd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+const managerRoute = "traffic-manager"
+
+func agentRoute(agent tunnel.Provider) string {
+	return fmt.Sprintf("traffic-agent %s", agent)
+}
+
+// connection is an active tunnel.Stream created by the Session's stream creator.
+type connection struct {
+	id       tunnel.ConnID
+	route    string
+	started  time.Time
+	rtt      time.Duration
+	bytesIn  uint64
+	bytesOut uint64
+}
+
+// trackedStream is a tunnel.Stream that counts the payload bytes of the messages that passes through it.
+type trackedStream struct {
+	tunnel.Stream
+	conn *connection
+}
+
+func (s *trackedStream) Receive(ctx context.Context) (tunnel.Message, error) {
+	m, err := s.Stream.Receive(ctx)
+	if err == nil && m.Code() == tunnel.Normal {
+		atomic.AddUint64(&s.conn.bytesIn, uint64(len(m.Payload())))
+	}
+	return m, err
+}
+
+func (s *trackedStream) Send(ctx context.Context, m tunnel.Message) error {
+	err := s.Stream.Send(ctx, m)
+	if err == nil && m.Code() == tunnel.Normal {
+		atomic.AddUint64(&s.conn.bytesOut, uint64(len(m.Payload())))
+	}
+	return err
+}
+
+// connections keeps track of the connections that are active in a Session.
+type connections struct {
+	seq   uint64
+	conns *xsync.MapOf[uint64, *connection]
+}
+
+func newConnections() *connections {
+	return &connections{conns: xsync.NewMapOf[uint64, *connection]()}
+}
+
+// track returns a tunnel.Stream that will be tracked until the given context is cancelled. The rtt is the
+// round-trip latency measured when the stream performed its handshake with the peer.
+func (cs *connections) track(ctx context.Context, s tunnel.Stream, route string, started time.Time, rtt time.Duration) tunnel.Stream {
+	c := &connection{
+		id:      s.ID(),
+		route:   route,
+		started: started,
+		rtt:     rtt,
+	}
+	key := atomic.AddUint64(&cs.seq, 1)
+	cs.conns.Store(key, c)
+	context.AfterFunc(ctx, func() { cs.conns.Delete(key) })
+	return &trackedStream{Stream: s, conn: c}
+}
+
+// getConnections returns the active connections of this session, oldest first.
+func (s *Session) getConnections() *rpc.Connections {
+	var cl []*rpc.Connection
+	s.connections.conns.Range(func(_ uint64, c *connection) bool {
+		id := c.id
+		rc := &rpc.Connection{
+			Protocol:         ipproto.String(id.Protocol()),
+			SourceIp:         id.Source(),
+			SourcePort:       int32(id.SourcePort()),
+			DestinationIp:    id.Destination(),
+			DestinationPort:  int32(id.DestinationPort()),
+			Route:            c.route,
+			BytesIn:          atomic.LoadUint64(&c.bytesIn),
+			BytesOut:         atomic.LoadUint64(&c.bytesOut),
+			Started:          timestamppb.New(c.started),
+			RoundTripLatency: durationpb.New(c.rtt),
+		}
+		cl = append(cl, rc)
+		return true
+	})
+	sort.Slice(cl, func(i, j int) bool {
+		return cl[i].Started.AsTime().Before(cl[j].Started.AsTime())
+	})
+	return &rpc.Connections{Connections: cl}
+}
@@ -502,6 +502,34 @@ func (s *Server) flushDNS() {
 	})
 }
 
+// NameOf returns the name that a previously resolved A or AAAA record in the DNS cache
+// associates with the given IP, or an empty string if no such record exists.
+func (s *Server) NameOf(ip net.IP) (name string) {
+	s.cache.Range(func(key cacheKey, dv *cacheEntry) bool {
+		select {
+		case <-dv.wait:
+		default:
+			// Entry is still being resolved.
+			return true
+		}
+		for _, rr := range dv.answer {
+			var rrIP net.IP
+			switch rr := rr.(type) {
+			case *dns.A:
+				rrIP = rr.A
+			case *dns.AAAA:
+				rrIP = rr.AAAA
+			}
+			if rrIP != nil && rrIP.Equal(ip) {
+				name = strings.TrimSuffix(rr.Header().Name, ".")
+				return false
+			}
+		}
+		return true
+	})
+	return name
+}
+
 // splitToUDPAddr splits the given address into an UDPAddr. It's
 // an  error if the address is based on a hostname rather than an IP.
 func splitToUDPAddr(netAddr net.Addr) (*net.UDPAddr, error) {
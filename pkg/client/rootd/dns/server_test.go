@@ -1,6 +1,7 @@
 package dns
 
 import (
+	"net"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
 )
 
 type suiteServer struct {
@@ -96,6 +98,23 @@ func (s *suiteServer) TestIsExcluded() {
 	assert.False(s.T(), s.server.isExcluded("something-else."))
 }
 
+func (s *suiteServer) TestNameOf() {
+	// given
+	rr := &dns.A{
+		Hdr: dns.RR_Header{Name: "echo-easy.blue.svc.cluster.local.", Rrtype: dns.TypeA, Class: dns.ClassINET},
+		A:   net.ParseIP("10.96.0.12"),
+	}
+	resolved := &cacheEntry{wait: make(chan struct{}), created: time.Now(), answer: dnsproxy.RRs{rr}}
+	resolved.close()
+	pending := &cacheEntry{wait: make(chan struct{}), created: time.Now()}
+	s.server.cache.Store(cacheKey{name: rr.Hdr.Name, qType: dns.TypeA}, resolved)
+	s.server.cache.Store(cacheKey{name: "pending.", qType: dns.TypeA}, pending)
+
+	// when & then
+	s.Equal("echo-easy.blue.svc.cluster.local", s.server.NameOf(net.ParseIP("10.96.0.12")))
+	s.Empty(s.server.NameOf(net.ParseIP("10.96.0.13")))
+}
+
 func TestServerTestSuite(t *testing.T) {
 	suite.Run(t, new(suiteServer))
 }
@@ -112,6 +112,21 @@ func (rd *InProcSession) WaitForAgentIP(ctx context.Context, request *rpc.WaitFo
 	return rd.waitForAgentIP(ctx, request)
 }
 
+func (rd *InProcSession) GetConnections(context.Context, *empty.Empty, ...grpc.CallOption) (*rpc.Connections, error) {
+	return rd.getConnections(), nil
+}
+
+func (rd *InProcSession) SetTrafficShaping(ctx context.Context, rules *rpc.TrafficShapingRules, _ ...grpc.CallOption) (*empty.Empty, error) {
+	if err := rd.setTrafficShaping(ctx, rules); err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+func (rd *InProcSession) GetTrafficShaping(context.Context, *empty.Empty, ...grpc.CallOption) (*rpc.TrafficShapingRules, error) {
+	return rd.getTrafficShaping(), nil
+}
+
 // NewInProcSession returns a root daemon session suitable to use in-process (from the user daemon) and is primarily intended for
 // when the user daemon runs in a docker container with NET_ADMIN capabilities.
 func NewInProcSession(
@@ -278,6 +278,32 @@ func (s *Service) WaitForAgentIP(ctx context.Context, request *rpc.WaitForAgentI
 	return &emptypb.Empty{}, nil
 }
 
+func (s *Service) GetConnections(ctx context.Context, _ *emptypb.Empty) (cs *rpc.Connections, err error) {
+	err = s.WithSession(func(ctx context.Context, session *Session) error {
+		cs = session.getConnections()
+		return nil
+	})
+	return cs, err
+}
+
+func (s *Service) SetTrafficShaping(ctx context.Context, rules *rpc.TrafficShapingRules) (*emptypb.Empty, error) {
+	err := s.WithSession(func(ctx context.Context, session *Session) error {
+		return session.setTrafficShaping(ctx, rules)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Service) GetTrafficShaping(ctx context.Context, _ *emptypb.Empty) (rules *rpc.TrafficShapingRules, err error) {
+	err = s.WithSession(func(ctx context.Context, session *Session) error {
+		rules = session.getTrafficShaping()
+		return nil
+	})
+	return rules, err
+}
+
 func (s *Service) SetLogLevel(ctx context.Context, request *manager.LogLevelRequest) (*emptypb.Empty, error) {
 	duration := time.Duration(0)
 	if request.Duration != nil {
@@ -47,6 +47,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/shaper"
 	"github.com/telepresenceio/telepresence/v2/pkg/subnet"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
@@ -94,6 +95,12 @@ type Session struct {
 	// are obtained using a connpool.ConnID.
 	handlers *tunnel.Pool
 
+	// connections are the streams created by the TUN-device that are currently active
+	connections *connections
+
+	// shaper degrades the streams created by the TUN-device according to the traffic shaping rules
+	shaper *shaper.Shaper
+
 	// The local dns server
 	dnsServer *dns.Server
 
@@ -318,6 +325,7 @@ func newSession(c context.Context, mi *rpc.OutboundInfo, mc connector.ManagerPro
 	allow := iputil.ConvertSubnets(mi.AllowConflictingSubnets)
 	s := &Session{
 		handlers:                tunnel.NewPool(),
+		connections:             newConnections(),
 		rndSource:               rand.NewSource(time.Now().UnixNano()),
 		session:                 mi.Session,
 		namespace:               mi.Namespace,
@@ -334,9 +342,18 @@ func newSession(c context.Context, mi *rpc.OutboundInfo, mc connector.ManagerPro
 	}
 
 	s.dnsServer = dns.NewServer(mi.Dns, s.clusterLookup, false)
+	s.shaper = shaper.NewShaper(s.dnsServer.NameOf)
+	if rules, err := shaper.RulesFromRPC(mi.TrafficShaping); err != nil {
+		dlog.Errorf(c, "Traffic shaping disabled: %v", err)
+	} else {
+		s.shaper.SetRules(rules)
+	}
 	s.SetSearchPath(c, nil, nil)
 	dlog.Infof(c, "also-proxy subnets %v", as)
 	dlog.Infof(c, "never-proxy subnets %v", ns)
+	if rules := s.shaper.Rules(); len(rules) > 0 {
+		dlog.Infof(c, "traffic shaping %v", rules)
+	}
 	return s
 }
 
@@ -387,6 +404,7 @@ func (s *Session) getNetworkConfig() *rpc.NetworkConfig {
 			info.AllowConflictingSubnets[i] = iputil.IPNetToRPC(np)
 		}
 	}
+	info.TrafficShaping = s.getTrafficShaping().Rules
 	if s.tunVif != nil {
 		curSubnets := s.tunVif.Router.GetRoutedSubnets()
 		nc.Subnets = make([]*manager.IPNet, len(curSubnets))
@@ -559,7 +577,7 @@ func (s *Session) onFirstClusterInfo(ctx context.Context, mgrInfo *manager.Clust
 
 	// Do we need a VIF? A darwin system with full cluster access doesn't.
 	if willProxy || s.dnsServerSubnet != nil {
-		if s.tunVif, err = vif.NewTunnelingDevice(ctx, s.streamCreator()); err != nil {
+		if s.tunVif, err = vif.NewTunnelingDevice(ctx, s.streamCreator(ctx)); err != nil {
 			return fmt.Errorf("NewTunnelVIF: %v", err)
 		}
 	}
@@ -6,7 +6,10 @@ import (
 	"net"
 	"time"
 
+	"google.golang.org/grpc"
+
 	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
@@ -18,7 +21,21 @@ func (s *Session) isForDNS(ip net.IP, port uint16) bool {
 	return s.remoteDnsIP != nil && port == 53 && s.remoteDnsIP.Equal(ip)
 }
 
-func (s *Session) streamCreator() tunnel.StreamCreator {
+// mgrProxyProvider is a tunnel.Provider that creates tunnels to the traffic-manager using the
+// user daemon's manager proxy.
+type mgrProxyProvider struct {
+	connector.ManagerProxyClient
+}
+
+func (p mgrProxyProvider) Tunnel(ctx context.Context, opts ...grpc.CallOption) (tunnel.Client, error) {
+	return p.ManagerProxyClient.Tunnel(ctx, opts...)
+}
+
+func (s *Session) streamCreator(ctx context.Context) tunnel.StreamCreator {
+	var muxProvider *tunnel.MuxProvider
+	if client.GetConfig(ctx).Grpc().MultiplexTunnels {
+		muxProvider = tunnel.NewMuxProvider(ctx, mgrProxyProvider{s.managerClient})
+	}
 	return func(c context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
 		p := id.Protocol()
 		if p == ipproto.UDP {
@@ -43,9 +60,15 @@ func (s *Session) streamCreator() tunnel.StreamCreator {
 		}
 		var err error
 		var ct tunnel.GRPCClientStream
+		started := time.Now()
+		route := managerRoute
 		if agentClient := s.getAgentClient(id.Destination()); agentClient != nil {
 			dlog.Debugf(c, "Opening traffic-agent tunnel for id %s", id)
+			route = agentRoute(agentClient)
 			ct, err = agentClient.Tunnel(c)
+		} else if muxProvider != nil {
+			dlog.Debugf(c, "Opening multiplexed traffic-manager tunnel flow for id %s", id)
+			ct, err = muxProvider.Tunnel(c, id)
 		} else {
 			dlog.Debugf(c, "Opening traffic-manager tunnel for id %s", id)
 			ct, err = s.managerClient.Tunnel(c)
@@ -53,8 +76,16 @@ func (s *Session) streamCreator() tunnel.StreamCreator {
 		if err != nil {
 			return nil, err
 		}
-		tc := client.GetConfig(c).Timeouts()
-		return tunnel.NewClientStream(c, ct, id, s.session.SessionId, tc.Get(client.TimeoutRoundtripLatency), tc.Get(client.TimeoutEndpointDial))
+		cfg := client.GetConfig(c)
+		tc := cfg.Timeouts()
+		c = tunnel.WithCompression(c, cfg.Grpc().Compression)
+		// The stream's handshake is one StreamInfo/StreamOK exchange, so its duration is the tunnel's round-trip latency.
+		handshake := time.Now()
+		st, err := tunnel.NewClientStream(c, ct, id, s.session.SessionId, tc.Get(client.TimeoutRoundtripLatency), tc.Get(client.TimeoutEndpointDial))
+		if err != nil {
+			return nil, err
+		}
+		return s.shaper.Stream(c, s.connections.track(c, st, route, started, time.Since(handshake))), nil
 	}
 }
 
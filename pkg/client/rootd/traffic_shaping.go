@@ -0,0 +1,33 @@
+package rootd
+
+import (
+	"context"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/shaper"
+)
+
+func (s *Session) getTrafficShaping() *rpc.TrafficShapingRules {
+	rules := s.shaper.Rules()
+	rs := make([]*rpc.TrafficShaping, len(rules))
+	for i, r := range rules {
+		rs[i] = r.ToRPC()
+	}
+	return &rpc.TrafficShapingRules{Rules: rs}
+}
+
+func (s *Session) setTrafficShaping(ctx context.Context, rs *rpc.TrafficShapingRules) error {
+	rules, err := shaper.RulesFromRPC(rs.Rules)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	s.shaper.SetRules(rules)
+	if len(rules) > 0 {
+		dlog.Infof(ctx, "traffic shaping %v", rules)
+	} else {
+		dlog.Info(ctx, "traffic shaping disabled")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd/k8s"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// withAdminSession calls the given function with a context that carries a short-lived token for the given
+// service account in the traffic-manager's namespace, and the Kubernetes identity of the caller. The
+// traffic-manager uses the token to authenticate the call, and verifies that both the service account and the
+// caller have access to its privileged calls. The call is attributed to the caller. The credentials of the
+// session's Kubernetes configuration are never sent to the traffic-manager.
+func (s *service) withAdminSession(
+	ctx context.Context,
+	name, serviceAccount string,
+	f func(context.Context, manager.ManagerClient) error,
+) error {
+	return s.WithSession(ctx, name, func(ctx context.Context, session userd.Session) error {
+		kctx := session.WithK8sInterface(ctx)
+		caller, err := k8s.CallerIdentity(kctx)
+		if err != nil {
+			return err
+		}
+		token, err := k8s.AdminToken(kctx, session.GetManagerNamespace(), serviceAccount)
+		if err != nil {
+			return err
+		}
+		kvs := []string{
+			"authorization", "Bearer " + token,
+			agentmap.CallerUserKey, caller.Username,
+			agentmap.CallerUIDKey, caller.UID,
+		}
+		for _, g := range caller.Groups {
+			kvs = append(kvs, agentmap.CallerGroupsKey, g)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, kvs...)
+		err = f(ctx, session.ManagerClient())
+		if status.Code(err) == codes.Unimplemented {
+			err = errcat.User.Newf("traffic-manager v%s doesn't support admin calls", session.ManagerVersion())
+		}
+		return err
+	})
+}
+
+func (s *service) GetAdminSessions(ctx context.Context, e *empty.Empty) (r *manager.AdminSessionsResponse, err error) {
+	err = s.withAdminSession(ctx, "GetAdminSessions", agentmap.OperatorServiceAccount, func(ctx context.Context, mc manager.ManagerClient) error {
+		r, err = mc.GetAdminSessions(ctx, e)
+		return err
+	})
+	return r, err
+}
+
+func (s *service) GetAdminIntercepts(ctx context.Context, e *empty.Empty) (r *manager.InterceptInfoSnapshot, err error) {
+	err = s.withAdminSession(ctx, "GetAdminIntercepts", agentmap.OperatorServiceAccount, func(ctx context.Context, mc manager.ManagerClient) error {
+		r, err = mc.GetAdminIntercepts(ctx, e)
+		return err
+	})
+	return r, err
+}
+
+func (s *service) GetAdminAgents(ctx context.Context, e *empty.Empty) (r *manager.AgentInfoSnapshot, err error) {
+	err = s.withAdminSession(ctx, "GetAdminAgents", agentmap.OperatorServiceAccount, func(ctx context.Context, mc manager.ManagerClient) error {
+		r, err = mc.GetAdminAgents(ctx, e)
+		return err
+	})
+	return r, err
+}
+
+func (s *service) AdminRemoveIntercept(ctx context.Context, rq *manager.AdminRemoveRequest) (*empty.Empty, error) {
+	return &empty.Empty{}, s.withAdminSession(ctx, "AdminRemoveIntercept", agentmap.AdminServiceAccount, func(ctx context.Context, mc manager.ManagerClient) error {
+		_, err := mc.AdminRemoveIntercept(ctx, rq)
+		return err
+	})
+}
+
+func (s *service) AdminRemoveSession(ctx context.Context, rq *manager.AdminRemoveRequest) (*empty.Empty, error) {
+	return &empty.Empty{}, s.withAdminSession(ctx, "AdminRemoveSession", agentmap.AdminServiceAccount, func(ctx context.Context, mc manager.ManagerClient) error {
+		_, err := mc.AdminRemoveSession(ctx, rq)
+		return err
+	})
+}
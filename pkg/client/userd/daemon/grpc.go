@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -32,7 +33,9 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd/k8s"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 )
@@ -317,7 +320,15 @@ func (s *service) RemoveIntercept(c context.Context, rr *manager.RemoveIntercept
 
 func (s *service) UpdateIntercept(c context.Context, rr *manager.UpdateInterceptRequest) (result *manager.InterceptInfo, err error) {
 	err = s.WithSession(c, "UpdateIntercept", func(c context.Context, session userd.Session) error {
-		result, err = session.ManagerClient().UpdateIntercept(c, rr)
+		result, err = session.UpdateIntercept(c, rr)
+		return err
+	})
+	return
+}
+
+func (s *service) AcceptInterceptHandover(c context.Context, ar *manager.AcceptInterceptHandoverRequest) (result *manager.InterceptInfo, err error) {
+	err = s.WithSession(c, "AcceptInterceptHandover", func(c context.Context, session userd.Session) error {
+		result, err = session.AcceptInterceptHandover(c, ar)
 		return err
 	})
 	return
@@ -578,6 +589,40 @@ func (s *service) SetDNSMappings(ctx context.Context, req *daemon.SetDNSMappings
 	return s.session.RootDaemon().SetDNSMappings(ctx, req)
 }
 
+func (s *service) GetConnections(ctx context.Context, _ *emptypb.Empty) (cs *daemon.Connections, err error) {
+	err = s.WithSession(ctx, "GetConnections", func(ctx context.Context, session userd.Session) error {
+		if cs, err = session.RootDaemon().GetConnections(ctx, &emptypb.Empty{}); err != nil || len(cs.Connections) == 0 {
+			return err
+		}
+		nss := session.GetCurrentNamespaces(true)
+		if mns := session.GetManagerNamespace(); !slices.Contains(nss, mns) {
+			nss = append(nss, mns)
+		}
+		names := k8s.IPNames(session.WithK8sInterface(ctx), nss)
+		for _, c := range cs.Connections {
+			c.DestinationName = names[iputil.IPKey(c.DestinationIp)]
+		}
+		return nil
+	})
+	return cs, err
+}
+
+func (s *service) SetTrafficShaping(ctx context.Context, rules *daemon.TrafficShapingRules) (result *emptypb.Empty, err error) {
+	err = s.WithSession(ctx, "SetTrafficShaping", func(ctx context.Context, session userd.Session) error {
+		result, err = session.RootDaemon().SetTrafficShaping(ctx, rules)
+		return err
+	})
+	return result, err
+}
+
+func (s *service) GetTrafficShaping(ctx context.Context, _ *emptypb.Empty) (rules *daemon.TrafficShapingRules, err error) {
+	err = s.WithSession(ctx, "GetTrafficShaping", func(ctx context.Context, session userd.Session) error {
+		rules, err = session.RootDaemon().GetTrafficShaping(ctx, &emptypb.Empty{})
+		return err
+	})
+	return rules, err
+}
+
 func (s *service) withRootDaemon(ctx context.Context, f func(ctx context.Context, daemonClient daemon.DaemonClient) error) error {
 	if s.rootSessionInProc {
 		return status.Error(codes.Unavailable, "root daemon is embedded")
@@ -0,0 +1,72 @@
+package k8s
+
+import (
+	"context"
+
+	authn "k8s.io/api/authentication/v1"
+	authnv1beta1 "k8s.io/api/authentication/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// adminTokenExpiration is the requested lifetime of an admin token. It's the shortest lifetime that the API
+// server accepts.
+const adminTokenExpiration = int64(600)
+
+// AdminToken uses a TokenRequest to obtain a short-lived token for the given service account in the given
+// namespace. The token is bound to the agentmap.AdminTokenAudience, so the traffic-manager accepts it, but the
+// API server doesn't. The API server verifies that the identity of the current context, which may use any kind
+// of credentials, is allowed to create the token.
+func AdminToken(ctx context.Context, namespace, serviceAccount string) (string, error) {
+	expiration := adminTokenExpiration
+	tr, err := k8sapi.GetK8sInterface(ctx).CoreV1().ServiceAccounts(namespace).CreateToken(ctx, serviceAccount, &authn.TokenRequest{
+		Spec: authn.TokenRequestSpec{
+			Audiences:         []string{agentmap.AdminTokenAudience},
+			ExpirationSeconds: &expiration,
+		},
+	}, meta.CreateOptions{})
+	switch {
+	case err == nil:
+		return tr.Status.Token, nil
+	case k8serrors.IsNotFound(err):
+		return "", errcat.User.Newf(
+			"service account %s.%s not found. The traffic-manager must be installed with adminApi.enabled=true",
+			serviceAccount, namespace)
+	case k8serrors.IsForbidden(err):
+		return "", errcat.User.Newf(
+			"the current Kubernetes context isn't allowed to create tokens for service account %s.%s", serviceAccount, namespace)
+	default:
+		return "", err
+	}
+}
+
+// CallerIdentity uses a SelfSubjectReview to obtain the identity of the current context, as seen by the API
+// server. The traffic-manager authorizes the admin calls against that identity, and attributes the calls to it,
+// because the admin tokens belong to service accounts that are shared by all operators and admins.
+func CallerIdentity(ctx context.Context) (*authn.UserInfo, error) {
+	ki := k8sapi.GetK8sInterface(ctx)
+	ssr, err := ki.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authn.SelfSubjectReview{}, meta.CreateOptions{})
+	if err == nil {
+		return &ssr.Status.UserInfo, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// Kubernetes 1.27 only serves the beta version.
+	bssr, err := ki.AuthenticationV1beta1().SelfSubjectReviews().Create(ctx, &authnv1beta1.SelfSubjectReview{}, meta.CreateOptions{})
+	switch {
+	case err == nil:
+		return &bssr.Status.UserInfo, nil
+	case k8serrors.IsNotFound(err):
+		return nil, errcat.User.New(
+			"unable to determine the identity of the current Kubernetes context, because the cluster doesn't serve " +
+				"SelfSubjectReviews. The admin commands require Kubernetes 1.27 or later")
+	default:
+		return nil, err
+	}
+}
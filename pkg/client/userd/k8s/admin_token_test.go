@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authn "k8s.io/api/authentication/v1"
+	authnv1beta1 "k8s.io/api/authentication/v1beta1"
+	core "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+func TestAdminToken(t *testing.T) {
+	cs := fake.NewSimpleClientset(&core.ServiceAccount{
+		ObjectMeta: meta.ObjectMeta{Name: "traffic-manager-operator", Namespace: "ambassador"},
+	})
+	var requested *authn.TokenRequest
+	cs.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ca := action.(k8stesting.CreateAction)
+		if ca.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		switch ca.(k8stesting.CreateActionImpl).Name {
+		case "traffic-manager-operator":
+			requested = ca.GetObject().(*authn.TokenRequest)
+			tr := requested.DeepCopy()
+			tr.Status.Token = "bound-token"
+			return true, tr, nil
+		case "traffic-manager-admin":
+			return true, nil, k8serrors.NewForbidden(schema.GroupResource{Resource: "serviceaccounts/token"}, "traffic-manager-admin", nil)
+		default:
+			return true, nil, k8serrors.NewNotFound(schema.GroupResource{Resource: "serviceaccounts"}, ca.(k8stesting.CreateActionImpl).Name)
+		}
+	})
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+
+	token, err := AdminToken(ctx, "ambassador", "traffic-manager-operator")
+	require.NoError(t, err)
+	assert.Equal(t, "bound-token", token)
+	require.NotNil(t, requested)
+	assert.Equal(t, []string{"telepresence-traffic-manager"}, requested.Spec.Audiences)
+	require.NotNil(t, requested.Spec.ExpirationSeconds)
+	assert.Equal(t, int64(600), *requested.Spec.ExpirationSeconds)
+
+	_, err = AdminToken(ctx, "ambassador", "traffic-manager-admin")
+	assert.ErrorContains(t, err, "isn't allowed to create tokens")
+
+	_, err = AdminToken(ctx, "ambassador", "other")
+	assert.ErrorContains(t, err, "adminApi.enabled=true")
+}
+
+func TestCallerIdentity(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	servesV1 := true
+	cs.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ca := action.(k8stesting.CreateAction)
+		switch obj := ca.GetObject().(type) {
+		case *authn.SelfSubjectReview:
+			if !servesV1 {
+				return true, nil, k8serrors.NewNotFound(ca.GetResource().GroupResource(), "")
+			}
+			obj = obj.DeepCopy()
+			obj.Status.UserInfo = authn.UserInfo{Username: "alice", UID: "a1", Groups: []string{"operators"}}
+			return true, obj, nil
+		case *authnv1beta1.SelfSubjectReview:
+			obj = obj.DeepCopy()
+			obj.Status.UserInfo = authn.UserInfo{Username: "bob", Groups: []string{"admins"}}
+			return true, obj, nil
+		}
+		return false, nil, nil
+	})
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+
+	ui, err := CallerIdentity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", ui.Username)
+	assert.Equal(t, "a1", ui.UID)
+	assert.Equal(t, []string{"operators"}, ui.Groups)
+
+	// Kubernetes 1.27 only serves the beta version
+	servesV1 = false
+	ui, err = CallerIdentity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", ui.Username)
+	assert.Equal(t, []string{"admins"}, ui.Groups)
+}
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// IPNames returns the names of the services and pods in the given namespaces, keyed by their cluster IPs and pod
+// IPs. Services are named "svc/<name>.<namespace>" and pods "pod/<name>.<namespace>". Headless services and pods
+// that use the host network are omitted, because their IPs don't identify them. A namespace that can't be listed
+// is logged and skipped.
+func IPNames(ctx context.Context, namespaces []string) map[iputil.IPKey]string {
+	ki := k8sapi.GetK8sInterface(ctx).CoreV1()
+	names := make(map[iputil.IPKey]string)
+	add := func(ipStr, name string) {
+		if ip := iputil.Parse(ipStr); ip != nil {
+			names[iputil.IPKey(ip)] = name
+		}
+	}
+	for _, ns := range namespaces {
+		pods, err := ki.Pods(ns).List(ctx, meta.ListOptions{})
+		if err != nil {
+			dlog.Debugf(ctx, "unable to list pods in namespace %s: %v", ns, err)
+		} else {
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				if pod.Spec.HostNetwork || pod.Status.Phase != core.PodRunning {
+					continue
+				}
+				for _, pip := range pod.Status.PodIPs {
+					add(pip.IP, fmt.Sprintf("pod/%s.%s", pod.Name, ns))
+				}
+			}
+		}
+
+		// Services are added after the pods, so that a service wins in the unlikely event of a clash.
+		svcs, err := ki.Services(ns).List(ctx, meta.ListOptions{})
+		if err != nil {
+			dlog.Debugf(ctx, "unable to list services in namespace %s: %v", ns, err)
+			continue
+		}
+		for i := range svcs.Items {
+			svc := &svcs.Items[i]
+			for _, cip := range svc.Spec.ClusterIPs {
+				if cip != core.ClusterIPNone {
+					add(cip, fmt.Sprintf("svc/%s.%s", svc.Name, ns))
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func TestIPNames(t *testing.T) {
+	pod := func(name, ns, ip string, hostNetwork bool) *core.Pod {
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       core.PodSpec{HostNetwork: hostNetwork},
+			Status:     core.PodStatus{Phase: core.PodRunning, PodIPs: []core.PodIP{{IP: ip}}},
+		}
+	}
+	svc := func(name, ns string, ips ...string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       core.ServiceSpec{ClusterIPs: ips},
+		}
+	}
+	cs := fake.NewSimpleClientset(
+		pod("echo-abc", "blue", "10.1.0.5", false),
+		pod("node-agent", "blue", "192.168.0.2", true),
+		pod("other", "green", "10.1.0.6", false),
+		svc("echo", "blue", "10.96.0.12", "fd00::12"),
+		svc("headless", "blue", core.ClusterIPNone),
+	)
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+
+	names := IPNames(ctx, []string{"blue"})
+	assert.Equal(t, map[iputil.IPKey]string{
+		iputil.IPKey(iputil.Parse("10.1.0.5")):   "pod/echo-abc.blue",
+		iputil.IPKey(iputil.Parse("10.96.0.12")): "svc/echo.blue",
+		iputil.IPKey(iputil.Parse("fd00::12")):   "svc/echo.blue",
+	}, names)
+	assert.Equal(t, "svc/echo.blue", names[iputil.IPKey(net.IP{10, 96, 0, 12})])
+}
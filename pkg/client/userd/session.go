@@ -48,6 +48,8 @@ type Session interface {
 	InterceptProlog(context.Context, *manager.CreateInterceptRequest) *rpc.InterceptResult
 	InterceptEpilog(context.Context, *rpc.CreateInterceptRequest, *rpc.InterceptResult) *rpc.InterceptResult
 	RemoveIntercept(context.Context, string) error
+	UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error)
+	AcceptInterceptHandover(context.Context, *manager.AcceptInterceptHandoverRequest) (*manager.InterceptInfo, error)
 	NewCreateInterceptRequest(*manager.InterceptSpec) *manager.CreateInterceptRequest
 
 	AddInterceptor(string, *rpc.Interceptor) error
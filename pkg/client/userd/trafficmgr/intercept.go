@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -70,6 +71,12 @@ type intercept struct {
 
 	// Use bridged ftp/sftp mount through this local port
 	localMountPort int32
+
+	// How the remote file system is made available in the ClientMountPoint. Empty means FUSE.
+	mountMode string
+
+	// What happens with the ClientMountPoint of a sync mount when the intercept ends.
+	syncCleanup string
 }
 
 // interceptResult is what gets written to the awaitIntercept's waitCh channel when the
@@ -90,6 +97,12 @@ type awaitIntercept struct {
 	// the mount to take place in a host
 	mountPort int32
 
+	// mountMode is optional and tells how the remote file system is made available in the mountPoint
+	mountMode string
+
+	// syncCleanup is optional and tells what happens with the mountPoint of a sync mount when the intercept ends
+	syncCleanup string
+
 	waitCh chan<- interceptResult
 }
 
@@ -302,12 +315,16 @@ func (s *session) handleInterceptSnapshot(ctx context.Context, podIcepts *podInt
 		s.currentInterceptsLock.Unlock()
 
 		var err error
-		if ii.Disposition == manager.InterceptDispositionType_ACTIVE {
+		switch ii.Disposition {
+		case manager.InterceptDispositionType_QUEUED:
+			// The intercept has been created but it has no agent until it leaves the queue.
+		case manager.InterceptDispositionType_ACTIVE, manager.InterceptDispositionType_PAUSED:
+			// A paused intercept retains its port forwards and mounts.
 			ns := ii.Spec.Namespace
 			if s.Namespace != ns {
 				err = errcat.User.Newf("active intercepts in both namespace %s and %s", ns, s.Namespace)
 			}
-		} else {
+		default:
 			err = fmt.Errorf("intercept in error state %v: %v", ii.Disposition, ii.Message)
 		}
 
@@ -323,7 +340,7 @@ func (s *session) handleInterceptSnapshot(ctx context.Context, podIcepts *podInt
 				// Channel was closed
 			}
 		}
-		if err != nil {
+		if err != nil || ii.Disposition == manager.InterceptDispositionType_QUEUED {
 			continue
 		}
 
@@ -366,6 +383,12 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 	sb.WriteByte('[')
 	for i, ii := range iis {
 		ic, ok := s.currentIntercepts[ii.Id]
+		if ok && ic.Disposition == manager.InterceptDispositionType_QUEUED && ii.Disposition != manager.InterceptDispositionType_QUEUED {
+			dlog.Infof(ctx, "Intercept %s left the queue and is now %s", ii.Spec.Name, ii.Disposition)
+		}
+		if ii.Expiring && !(ok && ic.Expiring) {
+			dlog.Warnf(ctx, "Intercept %s expires at %s", ii.Spec.Name, ii.ExpiresAt.AsTime().Local().Format(time.DateTime))
+		}
 		if ok {
 			// retain ClientMountPoint, it's assigned in the client and never passed from the traffic-manager
 			ii.ClientMountPoint = ic.ClientMountPoint
@@ -377,6 +400,8 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 			if aw, ok := s.interceptWaiters[ii.Spec.Name]; ok {
 				ic.ClientMountPoint = aw.mountPoint
 				ic.localMountPort = aw.mountPort
+				ic.mountMode = aw.mountMode
+				ic.syncCleanup = aw.syncCleanup
 			}
 		}
 		intercepts[ii.Id] = ic
@@ -393,6 +418,9 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 	// Cancel those that no longer exists
 	for id, ic := range s.currentIntercepts {
 		if _, ok := intercepts[id]; !ok {
+			if ic.ExpiresAt != nil && !time.Now().Before(ic.ExpiresAt.AsTime()) {
+				dlog.Infof(ctx, "Intercept %s has expired", ic.Spec.Name)
+			}
 			dlog.Debugf(ctx, "Cancelling context for intercept %s", ic.Spec.Name)
 			ic.cancel()
 		}
@@ -423,13 +451,16 @@ func (s *interceptInfo) InterceptResult() *rpc.InterceptResult {
 }
 
 func (s *interceptInfo) PortIdentifier() (agentconfig.PortIdentifier, error) {
-	var spi string
-	if s.preparedIntercept.ServicePortName == "" {
-		spi = strconv.Itoa(int(s.preparedIntercept.ServicePort))
-	} else {
-		spi = s.preparedIntercept.ServicePortName
+	pi := s.preparedIntercept
+	return portIdentifier(pi.Protocol, pi.ServicePortName, pi.ServicePort)
+}
+
+func portIdentifier(protocol, portName string, port int32) (agentconfig.PortIdentifier, error) {
+	spi := portName
+	if spi == "" {
+		spi = strconv.Itoa(int(port))
 	}
-	return agentconfig.NewPortIdentifier(s.preparedIntercept.Protocol, spi)
+	return agentconfig.NewPortIdentifier(protocol, spi)
 }
 
 func (s *interceptInfo) PreparedIntercept() *manager.PreparedIntercept {
@@ -444,7 +475,7 @@ func (s *session) ensureNoInterceptConflict(ir *rpc.CreateInterceptRequest) *rpc
 		switch {
 		case iCept.Spec.Name == spec.Name:
 			return InterceptError(common.InterceptError_ALREADY_EXISTS, errcat.User.New(spec.Name))
-		case iCept.Spec.TargetPort == spec.TargetPort && iCept.Spec.TargetHost == spec.TargetHost:
+		case targetsConflict(iCept.Spec, spec):
 			return &rpc.InterceptResult{
 				Error:         common.InterceptError_LOCAL_TARGET_IN_USE,
 				ErrorText:     spec.Name,
@@ -463,6 +494,33 @@ func (s *session) ensureNoInterceptConflict(ir *rpc.CreateInterceptRequest) *rpc
 	return nil
 }
 
+// targetPorts returns the local ports that the given spec redirects traffic to.
+func targetPorts(spec *manager.InterceptSpec) []int32 {
+	if spec.Ingest {
+		return nil
+	}
+	tps := make([]int32, 1+len(spec.AdditionalPorts))
+	tps[0] = spec.TargetPort
+	for i, ap := range spec.AdditionalPorts {
+		tps[i+1] = ap.TargetPort
+	}
+	return tps
+}
+
+// targetsConflict returns true if the given specs redirect traffic to the same local port.
+func targetsConflict(a, b *manager.InterceptSpec) bool {
+	if a.TargetHost != b.TargetHost {
+		return false
+	}
+	bps := targetPorts(b)
+	for _, ap := range targetPorts(a) {
+		if slices.Contains(bps, ap) {
+			return true
+		}
+	}
+	return false
+}
+
 // CanIntercept checks if it is possible to create an intercept for the given request. The intercept can proceed
 // only if the returned rpc.InterceptResult is nil. The returned runtime.Object is either nil, indicating a local
 // intercept, or the workload for the intercept.
@@ -532,16 +590,27 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	// iInfo.preparedIntercept == nil means that we're using an older traffic-manager, incapable
 	// of using PrepareIntercept.
 	pi := iInfo.PreparedIntercept()
-	// Make spec port identifier unambiguous.
-	spec.ServiceName = pi.ServiceName
-	spec.ServicePortName = pi.ServicePortName
-	spec.ServicePort = pi.ServicePort
-	spec.Protocol = pi.Protocol
-	pti, err := iInfo.PortIdentifier()
-	if err != nil {
-		return InterceptError(common.InterceptError_MISCONFIGURED_WORKLOAD, err)
+	spec.ContainerName = pi.ContainerName
+	if !spec.Ingest {
+		// Make spec port identifier unambiguous.
+		spec.ServiceName = pi.ServiceName
+		spec.ServicePortName = pi.ServicePortName
+		spec.ServicePort = pi.ServicePort
+		spec.Protocol = pi.Protocol
+		spec.AgentPort = pi.AgentPort
+		pti, err := iInfo.PortIdentifier()
+		if err != nil {
+			return InterceptError(common.InterceptError_MISCONFIGURED_WORKLOAD, err)
+		}
+		spec.ServicePortIdentifier = pti.String()
+		spec.AdditionalPorts = pi.AdditionalPorts
+		for _, ap := range spec.AdditionalPorts {
+			if pti, err = portIdentifier(ap.Protocol, ap.ServicePortName, ap.ServicePort); err != nil {
+				return InterceptError(common.InterceptError_MISCONFIGURED_WORKLOAD, err)
+			}
+			ap.ServicePortIdentifier = pti.String()
+		}
 	}
-	spec.ServicePortIdentifier = pti.String()
 	result = iInfo.InterceptResult()
 
 	spec.ServiceUid = result.ServiceUid
@@ -559,9 +628,11 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	waitCh := make(chan interceptResult, 2) // Need a buffer because reply can come before we're reading the channel,
 	s.currentInterceptsLock.Lock()
 	s.interceptWaiters[spec.Name] = &awaitIntercept{
-		mountPoint: ir.MountPoint,
-		mountPort:  ir.LocalMountPort,
-		waitCh:     waitCh,
+		mountPoint:  ir.MountPoint,
+		mountPort:   ir.LocalMountPort,
+		mountMode:   ir.MountMode,
+		syncCleanup: ir.SyncCleanup,
+		waitCh:      waitCh,
 	}
 	s.currentInterceptsLock.Unlock()
 	defer func() {
@@ -608,11 +679,19 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 			}
 			ic := wr.intercept
 			ii = ic.InterceptInfo
+			if ii.Disposition == manager.InterceptDispositionType_QUEUED {
+				// The intercept is activated by the traffic-manager when the intercepts before it
+				// in the queue have ended. Its port forwards and mounts are started then.
+				dlog.Infof(c, "Intercept %s is queued: %s", ii.Spec.Name, ii.Message)
+				result.InterceptInfo = ii
+				success = true
+				return result
+			}
 			if ii.Disposition != manager.InterceptDispositionType_ACTIVE {
 				continue
 			}
 			result.InterceptInfo = ii
-			if !waitForDNS(c, spec.ServiceName) {
+			if spec.ServiceName != "" && !waitForDNS(c, spec.ServiceName) {
 				dlog.Warningf(c, "DNS cannot resolve name of intercepted %q service", spec.ServiceName)
 			}
 			if er := self.InterceptEpilog(c, ir, result); er != nil {
@@ -695,6 +774,71 @@ func (s *session) removeIntercept(c context.Context, ic *intercept) error {
 	return err
 }
 
+// UpdateIntercept changes the target or the mechanism arguments of an existing intercept without removing it.
+func (s *session) UpdateIntercept(c context.Context, ur *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error) {
+	ic := s.getInterceptByName(ur.Name)
+	if ic == nil {
+		return nil, grpcStatus.Errorf(grpcCodes.NotFound, "Intercept named %q not found", ur.Name)
+	}
+	if su := ur.SpecUpdate; su != nil && (su.TargetHost != nil || su.TargetPort != nil || len(su.TargetPorts) > 0) {
+		host, port := ic.Spec.TargetHost, ic.Spec.TargetPort
+		if su.TargetHost != nil {
+			host = *su.TargetHost
+		}
+		if su.TargetPort != nil {
+			port = *su.TargetPort
+		}
+		// The traffic-manager resolves which port each of the TargetPorts refers to, so all of them, and all
+		// current target ports of this intercept, are checked.
+		ports := append(targetPorts(ic.Spec), port)
+		for _, tp := range su.TargetPorts {
+			ports = append(ports, tp)
+		}
+		for _, port := range ports {
+			if err := s.ensureNoTargetConflict(ic.Id, host, port); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dlog.Debugf(c, "telling manager to update intercept %s", ur.Name)
+	c, cancel := client.GetConfig(c).Timeouts().TimeoutContext(c, client.TimeoutTrafficManagerAPI)
+	defer cancel()
+	ur.Session = s.SessionInfo()
+	return s.managerClient.UpdateIntercept(c, ur)
+}
+
+// AcceptInterceptHandover takes over an intercept that another client has handed over to this client.
+func (s *session) AcceptInterceptHandover(c context.Context, ar *manager.AcceptInterceptHandoverRequest) (*manager.InterceptInfo, error) {
+	if s.getInterceptByName(ar.Name) != nil {
+		return nil, grpcStatus.Errorf(grpcCodes.AlreadyExists, "Intercept named %q already exists", ar.Name)
+	}
+	if su := ar.SpecUpdate; su != nil && su.TargetHost != nil && su.TargetPort != nil {
+		if err := s.ensureNoTargetConflict("", *su.TargetHost, *su.TargetPort); err != nil {
+			return nil, err
+		}
+	}
+
+	dlog.Debugf(c, "telling manager to hand over intercept %s", ar.Name)
+	c, cancel := client.GetConfig(c).Timeouts().TimeoutContext(c, client.TimeoutTrafficManagerAPI)
+	defer cancel()
+	ar.Session = s.SessionInfo()
+	return s.managerClient.AcceptInterceptHandover(c, ar)
+}
+
+// ensureNoTargetConflict returns an error if an intercept other than the one with the given ID sends its
+// traffic to the given host and port.
+func (s *session) ensureNoTargetConflict(id, host string, port int32) error {
+	s.currentInterceptsLock.Lock()
+	defer s.currentInterceptsLock.Unlock()
+	for _, other := range s.currentIntercepts {
+		if other.Id != id && other.Spec.TargetHost == host && slices.Contains(targetPorts(other.Spec), port) {
+			return grpcStatus.Errorf(grpcCodes.FailedPrecondition, "%s:%d is already used by intercept %s", host, port, other.Spec.Name)
+		}
+	}
+	return nil
+}
+
 // AddInterceptor associates the given intercept with a running process. This ensures that
 // the running process will be signalled when the intercept is removed.
 func (s *session) AddInterceptor(id string, ih *rpc.Interceptor) error {
@@ -20,7 +20,8 @@ func (ic *intercept) shouldMount() bool {
 // It assumes that the user has called shouldMount and is sure that something will be started.
 func (ic *intercept) startMount(ctx context.Context, iceptWG, podWG *sync.WaitGroup) {
 	var fuseftp rpc.FuseFTPClient
-	useFtp := client.GetConfig(ctx).Intercept().UseFtp
+	syncMode := ic.mountMode == remotefs.MountModeSync || ic.mountMode == remotefs.MountModeSyncRW
+	useFtp := client.GetConfig(ctx).Intercept().UseFtp && !syncMode
 	var port int32
 	mountCtx := ctx
 	if useFtp {
@@ -53,6 +54,8 @@ func (ic *intercept) startMount(ctx context.Context, iceptWG, podWG *sync.WaitGr
 		case ic.localMountPort != 0:
 			session := userd.GetSession(ctx)
 			m = remotefs.NewBridgeMounter(session.SessionInfo().SessionId, session.ManagerClient(), uint16(ic.localMountPort))
+		case syncMode:
+			m = remotefs.NewSyncMounter(ic.ctx, iceptWG, podWG, ic.mountMode == remotefs.MountModeSyncRW, ic.syncCleanup)
 		case useFtp:
 			m = remotefs.NewFTPMounter(fuseftp, iceptWG)
 		default:
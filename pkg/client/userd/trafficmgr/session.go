@@ -57,6 +57,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/shaper"
 )
 
 type apiServer struct {
@@ -124,6 +125,9 @@ type session struct {
 
 	isPodDaemon bool
 
+	// trafficShaping are the traffic shaping rules given to the connect command
+	trafficShaping []*rootdRpc.TrafficShaping
+
 	sessionConfig client.Config
 
 	// done is closed when the session ends
@@ -434,6 +438,11 @@ func connectMgr(
 	cluster.NeverProxy = append(cluster.NeverProxy, extraNeverProxy...)
 	cluster.AllowConflictingSubnets = append(cluster.AllowConflictingSubnets, extraAllow...)
 
+	trafficShaping, err := shaper.ParseRules(cr.GetTrafficShaping())
+	if err != nil {
+		return nil, errcat.User.New(err)
+	}
+
 	sess := &session{
 		Cluster:          cluster,
 		installID:        installID,
@@ -448,6 +457,7 @@ func connectMgr(
 		interceptWaiters: make(map[string]*awaitIntercept),
 		wlWatcher:        newWASWatcher(),
 		isPodDaemon:      cr.IsPodDaemon,
+		trafficShaping:   trafficShaping,
 		done:             make(chan struct{}),
 	}
 	sess.self = sess
@@ -1101,6 +1111,7 @@ func (s *session) getOutboundInfo(ctx context.Context) *rootdRpc.OutboundInfo {
 			info.AllowConflictingSubnets[i] = iputil.IPNetToRPC((*net.IPNet)(ap))
 		}
 	}
+	info.TrafficShaping = s.trafficShaping
 	return info
 }
 
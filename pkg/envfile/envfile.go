@@ -0,0 +1,251 @@
+// Package envfile writes and reads environment variables using the syntax of the files that various tools use
+// to declare environments, such as Docker, POSIX shells, fish, PowerShell, systemd, direnv, and IntelliJ.
+package envfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/shellquote"
+)
+
+// Syntax is the syntax of an environment file.
+type Syntax int
+
+const (
+	// Docker is the syntax understood by "docker run --env-file" and Docker Compose, i.e. KEY=value without quotes.
+	Docker = Syntax(iota)
+
+	// Sh is the syntax of a POSIX shell script, i.e. KEY='value', that is loaded using "source".
+	Sh
+
+	// Fish is the syntax of a fish shell script, i.e. set -gx KEY 'value'.
+	Fish
+
+	// PowerShell is the syntax of a PowerShell script, i.e. $Env:KEY = 'value'.
+	PowerShell
+
+	// Systemd is the syntax of a systemd EnvironmentFile, i.e. KEY="value".
+	Systemd
+
+	// Direnv is the syntax of a direnv .envrc file, i.e. export KEY='value'.
+	Direnv
+
+	// IntelliJ is the syntax of a dotenv file as understood by IntelliJ, i.e. KEY="value" with backslash escapes.
+	IntelliJ
+
+	// JSON is a JSON object where each property is a variable.
+	JSON
+)
+
+var syntaxNames = [...]string{ //nolint:gochecknoglobals // constant
+	Docker:     "docker",
+	Sh:         "sh",
+	Fish:       "fish",
+	PowerShell: "powershell",
+	Systemd:    "systemd",
+	Direnv:     "direnv",
+	IntelliJ:   "intellij",
+	JSON:       "json",
+}
+
+// SyntaxNames returns the names of all syntaxes.
+func SyntaxNames() []string {
+	return syntaxNames[:]
+}
+
+// ParseSyntax returns the Syntax with the given name.
+func ParseSyntax(name string) (Syntax, error) {
+	for i, n := range syntaxNames {
+		if n == name {
+			return Syntax(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid env syntax %q, must be one of %s", name, strings.Join(syntaxNames[:], ", "))
+}
+
+func (s Syntax) String() string {
+	if s >= 0 && int(s) < len(syntaxNames) {
+		return syntaxNames[s]
+	}
+	return fmt.Sprintf("Syntax(%d)", int(s))
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Write writes the given environment to the given writer, sorted by key. An error is returned if a key cannot
+// be expressed in this syntax.
+func (s Syntax) Write(w io.Writer, env map[string]string) error {
+	if s == JSON {
+		data, err := json.MarshalIndent(env, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		if s != Docker && s != Systemd && !identifier.MatchString(k) {
+			return fmt.Errorf("environment variable %q cannot be expressed in %s syntax", k, s)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	for _, k := range keys {
+		v := env[k]
+		switch s {
+		case Docker:
+			_, _ = fmt.Fprintf(bw, "%s=%s\n", k, v)
+		case Sh:
+			_, _ = fmt.Fprintf(bw, "%s=%s\n", k, shellquote.QuotePosix(v))
+		case Direnv:
+			_, _ = fmt.Fprintf(bw, "export %s=%s\n", k, shellquote.QuotePosix(v))
+		case Fish:
+			_, _ = fmt.Fprintf(bw, "set -gx %s %s\n", k, quoteFish(v))
+		case PowerShell:
+			_, _ = fmt.Fprintf(bw, "$Env:%s = '%s'\n", k, strings.ReplaceAll(v, "'", "''"))
+		case Systemd:
+			_, _ = fmt.Fprintf(bw, "%s=\"%s\"\n", k, systemdEscaper.Replace(v))
+		case IntelliJ:
+			_, _ = fmt.Fprintf(bw, "%s=\"%s\"\n", k, dotenvEscaper.Replace(v))
+		default:
+			return fmt.Errorf("unknown env syntax %s", s)
+		}
+	}
+	return bw.Flush()
+}
+
+// Read reads an environment that was written using this syntax.
+func (s Syntax) Read(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	switch s {
+	case JSON:
+		if err = json.Unmarshal(data, &env); err != nil {
+			return nil, err
+		}
+	case Docker:
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			k, v, _ := strings.Cut(line, "=")
+			env[k] = v
+		}
+	case Sh, Direnv:
+		args, err := shellquote.SplitPosix(string(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, arg := range args {
+			if k, v, ok := strings.Cut(arg, "="); ok {
+				env[k] = v
+			} else if arg != "export" {
+				return nil, fmt.Errorf("unexpected %q in %s file", arg, s)
+			}
+		}
+	case Fish:
+		args, err := splitFish(string(data))
+		if err != nil {
+			return nil, err
+		}
+		for len(args) >= 4 && args[0] == "set" && args[1] == "-gx" {
+			env[args[2]] = args[3]
+			args = args[4:]
+		}
+		if len(args) > 0 {
+			return nil, fmt.Errorf("unexpected %q in %s file", args[0], s)
+		}
+	case PowerShell:
+		for _, m := range powerShellAssignment.FindAllStringSubmatch(string(data), -1) {
+			env[m[1]] = strings.ReplaceAll(m[2], "''", "'")
+		}
+	case Systemd, IntelliJ:
+		for _, m := range quotedAssignment.FindAllStringSubmatch(string(data), -1) {
+			if s == Systemd {
+				env[m[1]] = systemdUnescaper.Replace(m[2])
+			} else {
+				env[m[1]] = dotenvUnescaper.Replace(m[2])
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown env syntax %s", s)
+	}
+	return env, nil
+}
+
+var (
+	powerShellAssignment = regexp.MustCompile(`(?m)^\$Env:(\w+) = '((?:[^']|'')*)'`)
+	quotedAssignment     = regexp.MustCompile(`(?m)^([^=\s]+)="((?:[^"\\]|\\.|\\\n)*)"`)
+
+	// systemd retains backslashes that don't precede a character that must be escaped.
+	systemdEscaper   = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	systemdUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, "\\`", "`", `\$`, `$`)
+
+	dotenvEscaper   = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	dotenvUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\r`, "\r")
+)
+
+// quoteFish quotes the given string using single quotes, in which fish recognizes the escapes \' and \\.
+func quoteFish(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// splitFish splits the given string into an array using the fish shell quote semantics for single quotes.
+func splitFish(s string) ([]string, error) {
+	var args []string
+	var sb strings.Builder
+	inArg, inQuote, escaped := false, false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != '\'' && r != '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+			escaped = false
+		case inQuote:
+			switch r {
+			case '\\':
+				escaped = true
+			case '\'':
+				inQuote = false
+			default:
+				sb.WriteRune(r)
+			}
+		case r == '\'':
+			inQuote, inArg = true, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		default:
+			sb.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inQuote {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if inArg {
+		args = append(args, sb.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,78 @@
+package envfile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyntax(t *testing.T) {
+	for _, name := range SyntaxNames() {
+		s, err := ParseSyntax(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, s.String())
+	}
+	_, err := ParseSyntax("cmd")
+	assert.Error(t, err)
+}
+
+func TestSyntax_RoundTrip(t *testing.T) {
+	simple := map[string]string{
+		"EMPTY":  "",
+		"PLAIN":  "hello",
+		"SPACES": "hello  world ",
+		"QUOTES": `it's a "quote"`,
+		"SHELL":  "$HOME `pwd` $(ls) ; & | * ? ~ # !",
+		"SLASH":  `C:\Users\me\ \\ \n \'`,
+		"EQUALS": "a=b=c",
+		"UNI":    "räksmörgås ✓",
+	}
+	multiline := map[string]string{
+		"LINES": "line 1\nline 2\n",
+		"CERT":  "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+		"CRLF":  "a\r\nb",
+	}
+	for _, name := range SyntaxNames() {
+		t.Run(name, func(t *testing.T) {
+			s, err := ParseSyntax(name)
+			require.NoError(t, err)
+			env := make(map[string]string)
+			for k, v := range simple {
+				env[k] = v
+			}
+			if s != Docker {
+				for k, v := range multiline {
+					env[k] = v
+				}
+			}
+			buf := bytes.Buffer{}
+			require.NoError(t, s.Write(&buf, env))
+			result, err := s.Read(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, env, result)
+		})
+	}
+}
+
+func TestSyntax_Write(t *testing.T) {
+	env := map[string]string{"B": "it's", "A": "x y"}
+	tests := map[Syntax]string{
+		Docker:     "A=x y\nB=it's\n",
+		Sh:         "A='x y'\nB=it\\'s\n",
+		Direnv:     "export A='x y'\nexport B=it\\'s\n",
+		Fish:       "set -gx A 'x y'\nset -gx B 'it\\'s'\n",
+		PowerShell: "$Env:A = 'x y'\n$Env:B = 'it''s'\n",
+		Systemd:    "A=\"x y\"\nB=\"it's\"\n",
+		IntelliJ:   "A=\"x y\"\nB=\"it's\"\n",
+	}
+	for s, expected := range tests {
+		buf := bytes.Buffer{}
+		require.NoError(t, s.Write(&buf, env))
+		assert.Equal(t, expected, buf.String(), s.String())
+	}
+
+	assert.Error(t, Sh.Write(&bytes.Buffer{}, map[string]string{"A.B": "x"}))
+	assert.NoError(t, Docker.Write(&bytes.Buffer{}, map[string]string{"A.B": "x"}))
+}
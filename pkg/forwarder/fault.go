@@ -0,0 +1,288 @@
+package forwarder
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// ParseFault parses a fault on the form <type>[=<value>][:<percentage>], where the type is "abort",
+// "delay", or "reset". The value of an abort is an HTTP status and defaults to 503, and the value of
+// a delay is a duration. The percentage defaults to 100, e.g. "abort=500:10%", "delay=2s:50", or "reset:5".
+func ParseFault(s string) (*manager.FaultInjection, error) {
+	fi := &manager.FaultInjection{Percentage: 100}
+	spec, pct, hasPct := strings.Cut(s, ":")
+	if hasPct {
+		p, err := strconv.ParseFloat(strings.TrimSuffix(pct, "%"), 32)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("invalid fault %q: percentage must be a number greater than 0 and max 100", s)
+		}
+		fi.Percentage = float32(p)
+	}
+	ft, value, hasValue := strings.Cut(spec, "=")
+	switch strings.ToLower(ft) {
+	case "abort":
+		fi.Type = manager.FaultInjection_ABORT
+		fi.HttpStatus = http.StatusServiceUnavailable
+		if hasValue {
+			st, err := strconv.Atoi(value)
+			if err != nil || st < 100 || st > 599 {
+				return nil, fmt.Errorf("invalid fault %q: %q is not a valid HTTP status", s, value)
+			}
+			fi.HttpStatus = int32(st)
+		}
+	case "delay":
+		fi.Type = manager.FaultInjection_DELAY
+		if !hasValue {
+			return nil, fmt.Errorf("invalid fault %q: delay requires a duration, e.g. delay=2s", s)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid fault %q: %q is not a positive duration", s, value)
+		}
+		fi.Delay = durationpb.New(d)
+	case "reset":
+		fi.Type = manager.FaultInjection_RESET
+		if hasValue {
+			return nil, fmt.Errorf("invalid fault %q: reset doesn't take a value", s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid fault %q: type must be one of abort, delay, or reset", s)
+	}
+	return fi, nil
+}
+
+// ParseFaults parses each of the given strings using ParseFault.
+func ParseFaults(ss []string) ([]*manager.FaultInjection, error) {
+	if len(ss) == 0 {
+		return nil, nil
+	}
+	fis := make([]*manager.FaultInjection, len(ss))
+	for i, s := range ss {
+		fi, err := ParseFault(s)
+		if err != nil {
+			return nil, err
+		}
+		fis[i] = fi
+	}
+	return fis, nil
+}
+
+// DescribeFault returns the fault in the form accepted by ParseFault.
+func DescribeFault(fi *manager.FaultInjection) string {
+	var s string
+	switch fi.Type {
+	case manager.FaultInjection_ABORT:
+		s = fmt.Sprintf("abort=%d", fi.HttpStatus)
+	case manager.FaultInjection_DELAY:
+		s = "delay=" + fi.Delay.AsDuration().String()
+	case manager.FaultInjection_RESET:
+		s = "reset"
+	default:
+		s = fi.Type.String()
+	}
+	return s + ":" + strconv.FormatFloat(float64(fi.Percentage), 'g', -1, 32) + "%"
+}
+
+// chooseFault decides which of the given faults apply to a request or connection. Each fault applies to its
+// percentage of the requests. Delays accumulate, and the first abort or reset that applies is returned, in
+// which case no more faults are considered. The chance function must return a random number in the half-open
+// interval [0.0,1.0).
+func chooseFault(faults []*manager.FaultInjection, chance func() float32) (time.Duration, *manager.FaultInjection) {
+	var delay time.Duration
+	for _, fi := range faults {
+		if chance()*100 >= fi.Percentage {
+			continue
+		}
+		switch fi.Type {
+		case manager.FaultInjection_DELAY:
+			delay += fi.Delay.AsDuration()
+		case manager.FaultInjection_ABORT, manager.FaultInjection_RESET:
+			return delay, fi
+		}
+	}
+	return delay, nil
+}
+
+func sleepFault(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	dlog.Debugf(ctx, "Injecting fault: delaying %s", d)
+	tm := time.NewTimer(d)
+	defer tm.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-tm.C:
+		return true
+	}
+}
+
+func resetConn(ctx context.Context, conn *net.TCPConn) {
+	dlog.Debug(ctx, "Injecting fault: resetting connection")
+	_ = conn.SetLinger(0)
+	_ = conn.Close()
+}
+
+// injectFaults applies the given faults to a connection that doesn't carry HTTP. The connection is consumed
+// by an abort or a reset, or when the context is cancelled during a delay, in which case injectFaults returns
+// true. The chance function must return a random number in the half-open interval [0.0,1.0).
+func injectFaults(ctx context.Context, conn *net.TCPConn, faults []*manager.FaultInjection, chance func() float32) bool {
+	delay, fi := chooseFault(faults, chance)
+	if !sleepFault(ctx, delay) {
+		_ = conn.Close()
+		return true
+	}
+	if fi == nil {
+		return false
+	}
+	if fi.Type == manager.FaultInjection_RESET {
+		resetConn(ctx, conn)
+	} else {
+		dlog.Debug(ctx, "Injecting fault: aborting connection")
+		_ = conn.Close()
+	}
+	return true
+}
+
+// httpMethods are the methods that identify a connection as carrying HTTP/1.x.
+var httpMethods = map[string]struct{}{ //nolint:gochecknoglobals // constant
+	http.MethodGet: {}, http.MethodHead: {}, http.MethodPost: {}, http.MethodPut: {}, http.MethodPatch: {},
+	http.MethodDelete: {}, http.MethodConnect: {}, http.MethodOptions: {}, http.MethodTrace: {},
+}
+
+// looksLikeHTTP returns true if the first bytes that can be read from the given reader are an HTTP/1.x method
+// followed by a space. It blocks until the first bytes arrive.
+func looksLikeHTTP(br *bufio.Reader) bool {
+	if _, err := br.Peek(1); err != nil {
+		return false
+	}
+	buf, _ := br.Peek(min(br.Buffered(), 8))
+	m, _, ok := strings.Cut(string(buf), " ")
+	if !ok {
+		return false
+	}
+	_, ok = httpMethods[m]
+	return ok
+}
+
+// detectHTTP is like looksLikeHTTP but gives up and returns false when the client hasn't sent anything within
+// the given timeout, which is the case with protocols where the server speaks first. The read deadline of the
+// connection is cleared before the function returns.
+func detectHTTP(conn net.Conn, br *bufio.Reader, timeout time.Duration) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return false
+	}
+	defer func() { _ = conn.SetReadDeadline(time.Time{}) }()
+	return looksLikeHTTP(br)
+}
+
+// serveHTTPFaults forwards the HTTP requests read from the client to the upstream connection, one at a time,
+// and the responses back to the client. The faults are chosen anew for each request. It returns when either
+// side closes the connection, or when a fault consumes it. The chance function must return a random number
+// in the half-open interval [0.0,1.0).
+func serveHTTPFaults(
+	ctx context.Context,
+	client *net.TCPConn,
+	cr *bufio.Reader,
+	upstream net.Conn,
+	faults []*manager.FaultInjection,
+	chance func() float32,
+) {
+	ur := bufio.NewReader(upstream)
+	for {
+		rq, err := http.ReadRequest(cr)
+		if err != nil {
+			return
+		}
+		delay, fi := chooseFault(faults, chance)
+		if !sleepFault(ctx, delay) {
+			return
+		}
+		if fi != nil {
+			if fi.Type == manager.FaultInjection_RESET {
+				resetConn(ctx, client)
+				return
+			}
+			dlog.Debugf(ctx, "Injecting fault: aborting %s %s with HTTP status %d", rq.Method, rq.URL, fi.HttpStatus)
+			_, _ = io.Copy(io.Discard, rq.Body)
+			_ = rq.Body.Close()
+			if err = writeAbort(client, int(fi.HttpStatus), rq.Close); err != nil || rq.Close {
+				return
+			}
+			continue
+		}
+		if _, ok := rq.Header["User-Agent"]; !ok {
+			// Prevent Request.Write from adding a default User-Agent.
+			rq.Header["User-Agent"] = []string{""}
+		}
+		if err = rq.Write(upstream); err != nil {
+			return
+		}
+		rs, err := http.ReadResponse(ur, rq)
+		if err != nil {
+			return
+		}
+		if rs.StatusCode == http.StatusSwitchingProtocols {
+			// The connection no longer carries HTTP once the response has been delivered.
+			if err = rs.Write(client); err == nil {
+				go func() {
+					_, _ = io.Copy(upstream, cr)
+					_ = upstream.Close()
+				}()
+				_, _ = io.Copy(client, ur)
+			}
+			return
+		}
+		err = rs.Write(client)
+		_ = rs.Body.Close()
+		if err != nil || rq.Close || rs.Close {
+			return
+		}
+	}
+}
+
+// writeAbort writes a response with the given status to the given connection.
+func writeAbort(conn net.Conn, status int, closeConn bool) error {
+	body := "fault injected by traffic-agent\n"
+	connection := "keep-alive"
+	if closeConn {
+		connection = "close"
+	}
+	_, err := fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Type: text/plain\r\nContent-Length: %d\r\nConnection: %s\r\n\r\n%s",
+		status, http.StatusText(status), len(body), connection, body)
+	return err
+}
+
+// bufferedConn is a net.Conn that reads from a bufio.Reader that wraps the connection, so that data that
+// has been peeked at is not lost.
+type bufferedConn struct {
+	*net.TCPConn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// pipeConn is one end of a net.Pipe that reports the remote address of the connection that it serves.
+type pipeConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
@@ -0,0 +1,196 @@
+package forwarder
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestParseFault(t *testing.T) {
+	tests := []struct {
+		fault string
+		desc  string
+		err   bool
+	}{
+		{fault: "abort", desc: "abort=503:100%"},
+		{fault: "abort=500:10%", desc: "abort=500:10%"},
+		{fault: "delay=2s:50", desc: "delay=2s:50%"},
+		{fault: "reset:0.5", desc: "reset:0.5%"},
+		{fault: "abort=99", err: true},
+		{fault: "abort=teapot", err: true},
+		{fault: "delay", err: true},
+		{fault: "delay=-1s", err: true},
+		{fault: "reset=1", err: true},
+		{fault: "reset:0", err: true},
+		{fault: "reset:101", err: true},
+		{fault: "drop", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fault, func(t *testing.T) {
+			fi, err := ParseFault(tt.fault)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.desc, DescribeFault(fi))
+		})
+	}
+}
+
+// faultConn returns the server and client sides of a TCP connection.
+func faultConn(t *testing.T) (*net.TCPConn, net.Conn) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer l.Close()
+	cc, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	sc, err := l.AcceptTCP()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = cc.Close()
+		_ = sc.Close()
+	})
+	return sc, cc
+}
+
+func parseFaults(t *testing.T, faults ...string) []*manager.FaultInjection {
+	fis, err := ParseFaults(faults)
+	require.NoError(t, err)
+	return fis
+}
+
+func always() float32 { return 0 }
+
+func never() float32 { return 0.9999 }
+
+func TestInjectFaults(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	t.Run("abort", func(t *testing.T) {
+		sc, cc := faultConn(t)
+		assert.True(t, injectFaults(ctx, sc, parseFaults(t, "abort=502"), always))
+		_ = cc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err := cc.Read(make([]byte, 1))
+		assert.ErrorIs(t, err, io.EOF, "expected a graceful close")
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		sc, cc := faultConn(t)
+		assert.True(t, injectFaults(ctx, sc, parseFaults(t, "reset"), always))
+		_ = cc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err := cc.Read(make([]byte, 1))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, io.EOF, "expected a reset, not a graceful close")
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		sc, _ := faultConn(t)
+		start := time.Now()
+		assert.False(t, injectFaults(ctx, sc, parseFaults(t, "delay=100ms:50", "reset:50"), never))
+		assert.Less(t, time.Since(start), 100*time.Millisecond, "faults should not apply")
+
+		start = time.Now()
+		assert.False(t, injectFaults(ctx, sc, parseFaults(t, "delay=100ms"), always))
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+		cc, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.True(t, injectFaults(cc, sc, parseFaults(t, "delay=1h"), always), "cancelled delay consumes the connection")
+	})
+}
+
+func TestLooksLikeHTTP(t *testing.T) {
+	for in, expected := range map[string]bool{
+		"GET / HTTP/1.1\r\n":       true,
+		"DELETE /x HTTP/1.1\r\n":   true,
+		"PRI * HTTP/2.0\r\n":       false,
+		"\x16\x03\x01\x02\x00\x01": false,
+		"":                         false,
+	} {
+		assert.Equal(t, expected, looksLikeHTTP(bufio.NewReader(strings.NewReader(in))), "%q", in)
+	}
+}
+
+func TestDetectHTTP(t *testing.T) {
+	// A client that waits for the server to speak first is given up on, and the connection remains usable.
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	br := bufio.NewReader(server)
+	start := time.Now()
+	assert.False(t, detectHTTP(server, br, 50*time.Millisecond))
+	assert.Less(t, time.Since(start), time.Second)
+	go func() { _, _ = io.WriteString(server, "220 ready\r\n") }()
+	line, err := bufio.NewReader(client).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "220 ready\r\n", line)
+	go func() { _, _ = io.WriteString(client, "GET / HTTP/1.1\r\n") }()
+	assert.True(t, detectHTTP(server, br, time.Second))
+}
+
+// okUpstream responds with 200 OK to each request read from the given connection, and returns
+// the number of requests it served on the given channel when the connection is closed.
+func okUpstream(conn net.Conn, served chan<- int) {
+	n := 0
+	defer func() { served <- n }()
+	br := bufio.NewReader(conn)
+	for {
+		rq, err := http.ReadRequest(br)
+		if err != nil {
+			return
+		}
+		_ = rq.Body.Close()
+		n++
+		if _, err = io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"); err != nil {
+			return
+		}
+	}
+}
+
+func TestServeHTTPFaults(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	// Each request is subject to the faults, so a keep-alive connection sees both aborts and successes.
+	sc, cc := faultConn(t)
+	local, remote := net.Pipe()
+	served := make(chan int, 1)
+	go okUpstream(remote, served)
+	chances := []float32{0, 0.9999, 0}
+	chance := func() float32 {
+		c := chances[0]
+		chances = chances[1:]
+		return c
+	}
+	go func() {
+		serveHTTPFaults(ctx, sc, bufio.NewReader(sc), local, parseFaults(t, "abort=503:50"), chance)
+		_ = local.Close()
+		_ = sc.Close()
+	}()
+
+	br := bufio.NewReader(cc)
+	for _, expected := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusServiceUnavailable} {
+		rq, err := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+		require.NoError(t, err)
+		require.NoError(t, rq.Write(cc))
+		rs, err := http.ReadResponse(br, rq)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, rs.Body)
+		_ = rs.Body.Close()
+		assert.Equal(t, expected, rs.StatusCode)
+		assert.False(t, rs.Close, "the connection should be kept alive")
+	}
+	_ = cc.Close()
+	assert.Equal(t, 1, <-served, "only the request that wasn't aborted should reach the upstream")
+}
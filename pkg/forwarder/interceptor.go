@@ -36,6 +36,10 @@ type interceptor struct {
 	targetPort     uint16
 	streamProvider tunnel.ClientStreamProvider
 
+	// packetBased is true when the intercept is captured for the lifetime of the target, which means that
+	// a change of the intercept's target requires that the target is restarted.
+	packetBased bool
+
 	intercept *manager.InterceptInfo
 }
 
@@ -106,6 +110,7 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 			dlog.Debugf(f.lCtx, "Forward target changed from %s:%d to intercept %s", f.targetHost, f.targetPort, iceptInfo(intercept))
 		} else {
 			if f.intercept.Id == intercept.Id {
+				f.updateIntercepting(intercept)
 				return
 			}
 			dlog.Debugf(f.lCtx, "Forward target changed from intercept %s to intercept %q", iceptInfo(f.intercept), iceptInfo(intercept))
@@ -119,3 +124,24 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 	f.tCtx, f.tCancel = context.WithCancel(f.lCtx)
 	f.intercept = intercept
 }
+
+// updateIntercepting replaces the current intercept with an updated version of the same intercept. Connections
+// that are in progress are retained and will continue to use the previous target. New connections will use
+// the updated one, which might belong to another client when the intercept has been handed over.
+func (f *interceptor) updateIntercepting(intercept *manager.InterceptInfo) {
+	oldSpec, newSpec := f.intercept.Spec, intercept.Spec
+	handedOver := f.intercept.ClientSession.GetSessionId() != intercept.ClientSession.GetSessionId()
+	retargeted := oldSpec.TargetHost != newSpec.TargetHost || oldSpec.TargetPort != newSpec.TargetPort
+	f.intercept = intercept
+	if handedOver {
+		dlog.Debugf(f.lCtx, "Intercept '%s' handed over from %s to %s", newSpec.Name, oldSpec.Client, newSpec.Client)
+	}
+	if retargeted {
+		dlog.Debugf(f.lCtx, "Intercept '%s' target changed from %s:%d to %s:%d",
+			newSpec.Name, oldSpec.TargetHost, oldSpec.TargetPort, newSpec.TargetHost, newSpec.TargetPort)
+	}
+	if f.packetBased && (handedOver || retargeted) {
+		f.tCancel()
+		f.tCtx, f.tCancel = context.WithCancel(f.lCtx)
+	}
+}
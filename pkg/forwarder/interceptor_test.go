@@ -0,0 +1,63 @@
+package forwarder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestSetIntercepting_update(t *testing.T) {
+	cept := func(port int32) *manager.InterceptInfo {
+		return &manager.InterceptInfo{
+			Id:   "session:echo",
+			Spec: &manager.InterceptSpec{Name: "echo", TargetHost: "127.0.0.1", TargetPort: port},
+		}
+	}
+	newInterceptor := func(packetBased bool) *interceptor {
+		f := &interceptor{packetBased: packetBased}
+		f.lCtx, f.lCancel = context.WithCancel(dlog.NewTestContext(t, false))
+		f.tCtx, f.tCancel = context.WithCancel(f.lCtx)
+		t.Cleanup(f.lCancel)
+		return f
+	}
+
+	t.Run("tcp retains connections", func(t *testing.T) {
+		f := newInterceptor(false)
+		f.SetIntercepting(cept(8080))
+		tCtx := f.tCtx
+		updated := cept(8081)
+		f.SetIntercepting(updated)
+		assert.Same(t, updated, f.intercept)
+		assert.NoError(t, tCtx.Err(), "connections must not be dropped")
+	})
+
+	t.Run("udp restarts on target change", func(t *testing.T) {
+		f := newInterceptor(true)
+		f.SetIntercepting(cept(8080))
+		tCtx := f.tCtx
+		f.SetIntercepting(cept(8080))
+		require.NoError(t, tCtx.Err(), "target must not restart when the target is unchanged")
+		f.SetIntercepting(cept(8081))
+		assert.Error(t, tCtx.Err())
+		assert.NoError(t, f.tCtx.Err())
+		assert.Equal(t, int32(8081), f.intercept.Spec.TargetPort)
+	})
+
+	t.Run("handover", func(t *testing.T) {
+		for _, packetBased := range []bool{false, true} {
+			f := newInterceptor(packetBased)
+			f.SetIntercepting(cept(8080))
+			tCtx := f.tCtx
+			handedOver := cept(8080)
+			handedOver.ClientSession = &manager.SessionInfo{SessionId: "other-session"}
+			f.SetIntercepting(handedOver)
+			assert.Same(t, handedOver, f.intercept)
+			assert.Equal(t, packetBased, tCtx.Err() != nil, "only packet based targets are restarted")
+		}
+	})
+}
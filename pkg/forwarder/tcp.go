@@ -1,9 +1,11 @@
 package forwarder
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"time"
 
@@ -100,6 +102,9 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	intercept := f.intercept
 	f.mu.Unlock()
 	if intercept != nil {
+		if faults := intercept.Spec.Faults; len(faults) > 0 {
+			return f.interceptWithFaults(ctx, clientConn, intercept, faults)
+		}
 		return f.interceptConn(ctx, clientConn, intercept)
 	}
 
@@ -155,6 +160,32 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	return nil
 }
 
+// httpDetectTimeout is how long interceptWithFaults waits for the first bytes from the client before it
+// concludes that the connection doesn't carry HTTP.
+const httpDetectTimeout = 500 * time.Millisecond
+
+// interceptWithFaults intercepts a connection while injecting the given faults. The faults apply to each
+// request when the connection carries HTTP, and to the connection as a whole when it doesn't, or when the
+// client doesn't send anything within the httpDetectTimeout.
+func (f *tcp) interceptWithFaults(ctx context.Context, conn *net.TCPConn, iCept *manager.InterceptInfo, faults []*manager.FaultInjection) error {
+	br := bufio.NewReader(conn)
+	if !detectHTTP(conn, br, httpDetectTimeout) {
+		if injectFaults(ctx, conn, faults, rand.Float32) {
+			return nil
+		}
+		return f.interceptConn(ctx, &bufferedConn{TCPConn: conn, r: br}, iCept)
+	}
+	defer conn.Close()
+	local, remote := net.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f.interceptConn(ctx, &pipeConn{Conn: remote, remoteAddr: conn.RemoteAddr()}, iCept)
+	}()
+	serveHTTPFaults(ctx, conn, br, local, faults, rand.Float32)
+	_ = local.Close()
+	return <-errCh
+}
+
 func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.InterceptInfo) error {
 	ctx, span := otel.Tracer("").Start(ctx, "interceptConn")
 	defer span.End()
@@ -193,9 +224,11 @@ func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.I
 	<-d.Done()
 
 	sp.ReportMetrics(ctx, &manager.TunnelMetrics{
-		ClientSessionId: clientSession,
-		IngressBytes:    ingressBytes.GetValue(),
-		EgressBytes:     egressBytes.GetValue(),
+		ClientSessionId:  clientSession,
+		IngressBytes:     ingressBytes.GetValue(),
+		EgressBytes:      egressBytes.GetValue(),
+		IngressWireBytes: ingressBytes.GetCompressedValue(),
+		EgressWireBytes:  egressBytes.GetCompressedValue(),
 	})
 	return nil
 }
@@ -23,9 +23,10 @@ type udp struct {
 func newUDP(listen *net.UDPAddr, targetHost string, targetPort uint16) Interceptor {
 	return &udp{
 		interceptor: interceptor{
-			listenAddr: listen,
-			targetHost: targetHost,
-			targetPort: targetPort,
+			listenAddr:  listen,
+			targetHost:  targetHost,
+			targetPort:  targetPort,
+			packetBased: true,
 		},
 	}
 }
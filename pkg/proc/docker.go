@@ -2,8 +2,13 @@ package proc
 
 import "os"
 
-// RunningInContainer returns true if the current process runs from inside a docker container.
+// RunningInContainer returns true if the current process runs from inside a container. Docker creates the
+// file /.dockerenv in its containers, and Podman creates the file /run/.containerenv.
 func RunningInContainer() bool {
-	_, err := os.Stat("/.dockerenv")
-	return err == nil
+	for _, f := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(f); err == nil {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,196 @@
+package shaper
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+// Rule describes how connections to a destination are degraded.
+type Rule struct {
+	// Destination is a CIDR, an IP address, or the name of a service or pod. An empty
+	// Destination matches all connections.
+	Destination string
+
+	// Latency is added to each message in each direction.
+	Latency time.Duration
+
+	// Jitter is the max random deviation from the Latency.
+	Jitter time.Duration
+
+	// Bandwidth is the max number of bytes per second in each direction. Zero means unlimited.
+	Bandwidth uint64
+
+	// PacketLoss is the percentage of messages that are lost.
+	PacketLoss float64
+
+	subnet *net.IPNet
+}
+
+// ParseRule parses a rule on the form "key=value,...". Valid keys are "destination" (or "dest"),
+// "latency", "jitter", "bandwidth", and "loss". The bandwidth is given in bytes per second using
+// a Kubernetes quantity, e.g. "512Ki" or "1M", and the loss is a percentage, e.g. "2.5" or "2.5%".
+func ParseRule(s string) (*Rule, error) {
+	r := &Rule{}
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid traffic shaping rule %q: %q is not a key=value pair", s, kv)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		var err error
+		switch k {
+		case "destination", "dest":
+			r.Destination = v
+		case "latency":
+			r.Latency, err = time.ParseDuration(v)
+		case "jitter":
+			r.Jitter, err = time.ParseDuration(v)
+		case "bandwidth":
+			var q resource.Quantity
+			if q, err = resource.ParseQuantity(v); err == nil {
+				if q.Sign() < 0 {
+					err = fmt.Errorf("negative value %s", v)
+				} else {
+					r.Bandwidth = uint64(q.Value())
+				}
+			}
+		case "loss":
+			r.PacketLoss, err = strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		default:
+			return nil, fmt.Errorf("invalid traffic shaping rule %q: unknown key %q", s, k)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid traffic shaping rule %q: invalid %s: %w", s, k, err)
+		}
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("invalid traffic shaping rule %q: %w", s, err)
+	}
+	return r, nil
+}
+
+func (r *Rule) validate() error {
+	switch {
+	case r.Latency < 0:
+		return fmt.Errorf("latency %s is negative", r.Latency)
+	case r.Jitter < 0:
+		return fmt.Errorf("jitter %s is negative", r.Jitter)
+	case r.Jitter > r.Latency:
+		return fmt.Errorf("jitter %s is greater than latency %s", r.Jitter, r.Latency)
+	case r.PacketLoss < 0 || r.PacketLoss > 100:
+		return fmt.Errorf("loss %g is not a percentage between 0 and 100", r.PacketLoss)
+	}
+	if d := r.Destination; d != "" {
+		if _, sn, err := net.ParseCIDR(d); err == nil {
+			r.subnet = sn
+		} else if ip := net.ParseIP(d); ip != nil {
+			bits := len(ip) * 8
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			r.subnet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+	}
+	return nil
+}
+
+// String returns the Rule in the form that is accepted by ParseRule.
+func (r *Rule) String() string {
+	var parts []string
+	if r.Destination != "" {
+		parts = append(parts, "destination="+r.Destination)
+	}
+	if r.Latency > 0 {
+		parts = append(parts, "latency="+r.Latency.String())
+	}
+	if r.Jitter > 0 {
+		parts = append(parts, "jitter="+r.Jitter.String())
+	}
+	if r.Bandwidth > 0 {
+		parts = append(parts, "bandwidth="+resource.NewQuantity(int64(r.Bandwidth), resource.BinarySI).String())
+	}
+	if r.PacketLoss > 0 {
+		parts = append(parts, "loss="+strconv.FormatFloat(r.PacketLoss, 'g', -1, 64)+"%")
+	}
+	return strings.Join(parts, ",")
+}
+
+// Matches returns true if this Rule applies to connections to the given IP, which is known by the
+// given name. The name of a service or pod matches when the Destination is equal to it, or to the
+// beginning of it, so that the destination "echo.default" matches "echo.default.svc.cluster.local".
+func (r *Rule) Matches(ip net.IP, name string) bool {
+	switch {
+	case r.Destination == "":
+		return true
+	case r.subnet != nil:
+		return r.subnet.Contains(ip)
+	default:
+		return name != "" && (name == r.Destination || strings.HasPrefix(name, r.Destination+"."))
+	}
+}
+
+// ToRPC converts this Rule to its gRPC representation.
+func (r *Rule) ToRPC() *rpc.TrafficShaping {
+	return &rpc.TrafficShaping{
+		Destination: r.Destination,
+		Latency:     durationpb.New(r.Latency),
+		Jitter:      durationpb.New(r.Jitter),
+		Bandwidth:   r.Bandwidth,
+		PacketLoss:  float32(r.PacketLoss),
+	}
+}
+
+// RuleFromRPC creates a Rule from its gRPC representation.
+func RuleFromRPC(ts *rpc.TrafficShaping) (*Rule, error) {
+	r := &Rule{
+		Destination: ts.Destination,
+		Latency:     ts.Latency.AsDuration(),
+		Jitter:      ts.Jitter.AsDuration(),
+		Bandwidth:   ts.Bandwidth,
+		PacketLoss:  float64(ts.PacketLoss),
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("invalid traffic shaping rule %q: %w", r, err)
+	}
+	return r, nil
+}
+
+// ParseRules parses each of the given strings into a rule and returns their gRPC representation.
+func ParseRules(ss []string) ([]*rpc.TrafficShaping, error) {
+	if len(ss) == 0 {
+		return nil, nil
+	}
+	rs := make([]*rpc.TrafficShaping, len(ss))
+	for i, s := range ss {
+		r, err := ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		rs[i] = r.ToRPC()
+	}
+	return rs, nil
+}
+
+// RulesFromRPC creates Rules from their gRPC representation.
+func RulesFromRPC(tss []*rpc.TrafficShaping) ([]*Rule, error) {
+	if len(tss) == 0 {
+		return nil, nil
+	}
+	rs := make([]*Rule, len(tss))
+	for i, ts := range tss {
+		r, err := RuleFromRPC(ts)
+		if err != nil {
+			return nil, err
+		}
+		rs[i] = r
+	}
+	return rs, nil
+}
@@ -0,0 +1,314 @@
+// Package shaper simulates slow or unreliable networks by adding latency, jitter, bandwidth limits, and
+// packet loss to the messages of a tunnel.Stream.
+package shaper
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// retransmitTimeout is the minimum time that a lost TCP message is delayed, and corresponds to the
+// minimum retransmission timeout of a typical TCP stack.
+const retransmitTimeout = 200 * time.Millisecond
+
+// queueSize is the max number of messages that can wait in a delay line.
+const queueSize = 50
+
+// Shaper applies traffic shaping Rules to tunnel.Streams.
+type Shaper struct {
+	rules  atomic.Pointer[[]*Rule]
+	nameOf func(net.IP) string
+}
+
+// NewShaper returns a new Shaper. The nameOf function is used when matching rules that
+// use a service or pod name as their destination.
+func NewShaper(nameOf func(net.IP) string) *Shaper {
+	s := &Shaper{nameOf: nameOf}
+	s.SetRules(nil)
+	return s
+}
+
+// SetRules replaces the rules of this Shaper. The new rules will apply to all streams that
+// have been shaped by this Shaper, including those that are already active.
+func (s *Shaper) SetRules(rules []*Rule) {
+	s.rules.Store(&rules)
+}
+
+// Rules returns the current rules of this Shaper.
+func (s *Shaper) Rules() []*Rule {
+	return *s.rules.Load()
+}
+
+func (s *Shaper) match(rules []*Rule, ip net.IP) *Rule {
+	name, nameResolved := "", false
+	for _, r := range rules {
+		if r.subnet == nil && r.Destination != "" && !nameResolved && s.nameOf != nil {
+			name, nameResolved = s.nameOf(ip), true
+		}
+		if r.Matches(ip, name) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Stream returns a tunnel.Stream that shapes the messages of the given stream until the given
+// context is cancelled. Messages pass straight through until the Shaper gets its first rules, so
+// that streams created while there are no rules are shaped once rules are added.
+func (s *Shaper) Stream(ctx context.Context, st tunnel.Stream) tunnel.Stream {
+	return &shapedStream{
+		Stream: st,
+		ctx:    ctx,
+		shaper: s,
+		udp:    st.ID().Protocol() == ipproto.UDP,
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		in:     make(chan *delayed, queueSize),
+		out:    make(chan *delayed, queueSize),
+	}
+}
+
+// delayed is a message, error, or close-send request that waits in a delay line.
+type delayed struct {
+	at   time.Time
+	msg  tunnel.Message
+	err  error
+	done chan error // only set for close-send requests
+}
+
+// delayLine computes when a message is delivered.
+type delayLine struct {
+	linkFree time.Time // when the simulated link has finished transmitting the previous message
+	last     time.Time // when the previous message is delivered
+}
+
+type shapedStream struct {
+	tunnel.Stream
+	ctx    context.Context
+	shaper *Shaper
+
+	// The pumps are started when the stream first sees rules. They then own the reading from,
+	// respectively the writing to, the underlying stream.
+	inOnce    sync.Once
+	outOnce   sync.Once
+	inPumped  atomic.Bool
+	outPumped atomic.Bool
+
+	udp bool
+	in  chan *delayed
+	out chan *delayed
+
+	// sendErr is the first error returned from a delayed Send
+	sendErr atomic.Pointer[error]
+
+	lock      sync.Mutex
+	rnd       *rand.Rand
+	rules     *[]*Rule
+	rule      *Rule
+	inLine    delayLine
+	outLine   delayLine
+	closeSent bool
+}
+
+// schedule returns the time when a message of the given size, that's ready now, is delivered by the
+// given delay line. The message is a data message unless size is negative. The returned boolean is
+// false when the message is lost.
+func (s *shapedStream) schedule(d *delayLine, size int) (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	at := now
+	if size >= 0 {
+		if rules := s.shaper.rules.Load(); rules != s.rules {
+			s.rules = rules
+			s.rule = s.shaper.match(*rules, s.ID().Destination())
+		}
+		if r := s.rule; r != nil {
+			if r.PacketLoss > 0 && s.rnd.Float64()*100 < r.PacketLoss {
+				if s.udp {
+					return time.Time{}, false
+				}
+				at = at.Add(max(retransmitTimeout, 2*r.Latency))
+			}
+			if r.Bandwidth > 0 {
+				start := now
+				if d.linkFree.After(start) {
+					start = d.linkFree
+				}
+				d.linkFree = start.Add(time.Duration(uint64(size) * uint64(time.Second) / r.Bandwidth))
+				at = at.Add(d.linkFree.Sub(now))
+			}
+			delay := r.Latency
+			if r.Jitter > 0 {
+				delay += time.Duration(s.rnd.Int63n(int64(2*r.Jitter+1))) - r.Jitter
+			}
+			at = at.Add(delay)
+		}
+	}
+	// Messages are never reordered.
+	if at.Before(d.last) {
+		at = d.last
+	}
+	d.last = at
+	return at, true
+}
+
+// pumping returns true if the pump guarded by the given once and flag is running, and starts it
+// if it isn't, but the Shaper has rules.
+func (s *shapedStream) pumping(once *sync.Once, flag *atomic.Bool, pump func(context.Context)) bool {
+	if flag.Load() {
+		return true
+	}
+	if len(s.shaper.Rules()) == 0 {
+		return false
+	}
+	once.Do(func() {
+		flag.Store(true)
+		go pump(s.ctx)
+	})
+	return true
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+	tm := time.NewTimer(d)
+	defer tm.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-tm.C:
+		return nil
+	}
+}
+
+func dataSize(m tunnel.Message) int {
+	if m.Code() == tunnel.Normal {
+		return len(m.Payload())
+	}
+	return -1
+}
+
+func (s *shapedStream) readPump(ctx context.Context) {
+	defer close(s.in)
+	for {
+		m, err := s.Stream.Receive(ctx)
+		d := &delayed{msg: m, err: err}
+		size := -1
+		if err == nil {
+			size = dataSize(m)
+		}
+		var ok bool
+		if d.at, ok = s.schedule(&s.inLine, size); !ok {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case s.in <- d:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *shapedStream) Receive(ctx context.Context) (tunnel.Message, error) {
+	if !s.pumping(&s.inOnce, &s.inPumped, s.readPump) {
+		return s.Stream.Receive(ctx)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case d, ok := <-s.in:
+		if !ok {
+			return nil, net.ErrClosed
+		}
+		if err := sleepUntil(ctx, d.at); err != nil {
+			return nil, err
+		}
+		return d.msg, d.err
+	}
+}
+
+func (s *shapedStream) writePump(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-s.out:
+			if err := sleepUntil(ctx, d.at); err != nil {
+				if d.done != nil {
+					d.done <- err
+				}
+				return
+			}
+			if d.done != nil {
+				d.done <- s.Stream.CloseSend(ctx)
+				return
+			}
+			if s.sendErr.Load() != nil {
+				continue
+			}
+			if err := s.Stream.Send(ctx, d.msg); err != nil {
+				s.sendErr.Store(&err)
+			}
+		}
+	}
+}
+
+func (s *shapedStream) enqueue(ctx context.Context, d *delayed) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.out <- d:
+		return nil
+	}
+}
+
+func (s *shapedStream) Send(ctx context.Context, m tunnel.Message) error {
+	if errp := s.sendErr.Load(); errp != nil {
+		return *errp
+	}
+	if !s.pumping(&s.outOnce, &s.outPumped, s.writePump) {
+		return s.Stream.Send(ctx, m)
+	}
+	at, ok := s.schedule(&s.outLine, dataSize(m))
+	if !ok {
+		return nil
+	}
+	return s.enqueue(ctx, &delayed{at: at, msg: m})
+}
+
+// CloseSend closes the underlying stream once all messages that were sent before it have been delivered.
+func (s *shapedStream) CloseSend(ctx context.Context) error {
+	s.lock.Lock()
+	closeSent := s.closeSent
+	s.closeSent = true
+	s.lock.Unlock()
+	if closeSent {
+		return nil
+	}
+	if !s.pumping(&s.outOnce, &s.outPumped, s.writePump) {
+		return s.Stream.CloseSend(ctx)
+	}
+	at, _ := s.schedule(&s.outLine, -1)
+	d := &delayed{at: at, done: make(chan error, 1)}
+	if err := s.enqueue(ctx, d); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-d.done:
+		return err
+	}
+}
@@ -0,0 +1,205 @@
+package shaper
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected Rule
+		str      string
+		err      bool
+	}{
+		{
+			rule:     "destination=echo.default,latency=100ms,jitter=20ms",
+			expected: Rule{Destination: "echo.default", Latency: 100 * time.Millisecond, Jitter: 20 * time.Millisecond},
+			str:      "destination=echo.default,latency=100ms,jitter=20ms",
+		},
+		{
+			rule:     "dest=10.96.0.0/12, bandwidth=256Ki, loss=2.5%",
+			expected: Rule{Destination: "10.96.0.0/12", Bandwidth: 256 * 1024, PacketLoss: 2.5},
+			str:      "destination=10.96.0.0/12,bandwidth=256Ki,loss=2.5%",
+		},
+		{
+			rule:     "loss=10",
+			expected: Rule{PacketLoss: 10},
+			str:      "loss=10%",
+		},
+		{rule: "latency", err: true},
+		{rule: "speed=10", err: true},
+		{rule: "latency=10", err: true},
+		{rule: "latency=10ms,jitter=20ms", err: true},
+		{rule: "loss=101", err: true},
+		{rule: "bandwidth=-1", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRule(tt.rule)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.Destination, r.Destination)
+			assert.Equal(t, tt.expected.Latency, r.Latency)
+			assert.Equal(t, tt.expected.Jitter, r.Jitter)
+			assert.Equal(t, tt.expected.Bandwidth, r.Bandwidth)
+			assert.Equal(t, tt.expected.PacketLoss, r.PacketLoss)
+			assert.Equal(t, tt.str, r.String())
+
+			rr, err := RuleFromRPC(r.ToRPC())
+			require.NoError(t, err)
+			assert.Equal(t, r, rr)
+		})
+	}
+}
+
+func TestRule_Matches(t *testing.T) {
+	parse := func(s string) *Rule {
+		r, err := ParseRule(s)
+		require.NoError(t, err)
+		return r
+	}
+	ip := net.ParseIP("10.96.0.12")
+	assert.True(t, parse("latency=1ms").Matches(ip, ""))
+	assert.True(t, parse("destination=10.96.0.0/12").Matches(ip, ""))
+	assert.False(t, parse("destination=10.0.0.0/16").Matches(ip, ""))
+	assert.True(t, parse("destination=10.96.0.12").Matches(ip, ""))
+	assert.True(t, parse("destination=echo").Matches(ip, "echo.default.svc.cluster.local"))
+	assert.True(t, parse("destination=echo.default").Matches(ip, "echo.default.svc.cluster.local"))
+	assert.False(t, parse("destination=echo.other").Matches(ip, "echo.default.svc.cluster.local"))
+	assert.False(t, parse("destination=ech").Matches(ip, "echo.default.svc.cluster.local"))
+	assert.False(t, parse("destination=echo").Matches(ip, ""))
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func shapedPipe(ctx context.Context, t *testing.T, proto int, rules ...string) (*Shaper, tunnel.Stream, tunnel.Stream) {
+	s := NewShaper(func(net.IP) string { return "echo.default.svc.cluster.local" })
+	rs := make([]*Rule, len(rules))
+	for i, rule := range rules {
+		r, err := ParseRule(rule)
+		require.NoError(t, err)
+		rs[i] = r
+	}
+	s.SetRules(rs)
+	id := tunnel.NewConnID(proto, iputil.Parse("127.0.0.1"), iputil.Parse("10.96.0.12"), 1001, 8080)
+	a, b := tunnel.NewPipe(id, "session")
+	return s, s.Stream(ctx, a), b
+}
+
+func TestShaper_Unshaped(t *testing.T) {
+	ctx := testContext(t)
+	s, a, b := shapedPipe(ctx, t, ipproto.TCP)
+
+	require.NoError(t, a.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("hello"))))
+	m, err := b.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(m.Payload()))
+	ss := a.(*shapedStream)
+	assert.False(t, ss.outPumped.Load(), "no pump is needed while there are no rules")
+
+	// Rules that are added later apply to the existing stream.
+	s.SetRules([]*Rule{{Latency: 100 * time.Millisecond}})
+	start := time.Now()
+	require.NoError(t, a.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("world"))))
+	m, err = b.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(m.Payload()))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.True(t, ss.outPumped.Load())
+}
+
+func TestShaper_Latency(t *testing.T) {
+	ctx := testContext(t)
+	_, a, b := shapedPipe(ctx, t, ipproto.TCP, "destination=echo,latency=100ms")
+
+	start := time.Now()
+	require.NoError(t, a.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("hello"))))
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "send must not block")
+	m, err := b.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(m.Payload()))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	start = time.Now()
+	require.NoError(t, b.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("world"))))
+	m, err = a.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(m.Payload()))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// CloseSend waits for queued messages.
+	require.NoError(t, a.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("last"))))
+	require.NoError(t, a.CloseSend(ctx))
+	m, err = b.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "last", string(m.Payload()))
+	_, err = b.Receive(ctx)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestShaper_Bandwidth(t *testing.T) {
+	ctx := testContext(t)
+	_, a, b := shapedPipe(ctx, t, ipproto.TCP, "bandwidth=10Ki")
+
+	payload := make([]byte, 1024)
+	start := time.Now()
+	go func() {
+		for i := 0; i < 5; i++ {
+			_ = a.Send(ctx, tunnel.NewMessage(tunnel.Normal, payload))
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		_, err := b.Receive(ctx)
+		require.NoError(t, err)
+	}
+	// 5KiB at 10KiB/s takes 500ms
+	assert.GreaterOrEqual(t, time.Since(start), 450*time.Millisecond)
+}
+
+func TestShaper_PacketLoss(t *testing.T) {
+	ctx := testContext(t)
+	_, a, b := shapedPipe(ctx, t, ipproto.UDP, "loss=100")
+	require.NoError(t, a.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("lost"))))
+	require.NoError(t, a.CloseSend(ctx))
+	_, err := b.Receive(ctx)
+	assert.ErrorIs(t, err, io.EOF, "UDP message should have been dropped")
+
+	// TCP messages are delayed, not dropped
+	_, a, b = shapedPipe(ctx, t, ipproto.TCP, "loss=100")
+	start := time.Now()
+	require.NoError(t, a.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("retransmitted"))))
+	m, err := b.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "retransmitted", string(m.Payload()))
+	assert.GreaterOrEqual(t, time.Since(start), retransmitTimeout)
+}
+
+func TestShaper_SetRules(t *testing.T) {
+	ctx := testContext(t)
+	s, a, b := shapedPipe(ctx, t, ipproto.TCP, "destination=echo,latency=200ms")
+	s.SetRules([]*Rule{{Destination: "other"}})
+
+	start := time.Now()
+	require.NoError(t, a.Send(ctx, tunnel.NewMessage(tunnel.Normal, []byte("fast"))))
+	_, err := b.Receive(ctx)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 200*time.Millisecond, "rule change should apply to active streams")
+}
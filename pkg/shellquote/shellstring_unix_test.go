@@ -113,6 +113,12 @@ func TestSplit(t *testing.T) {
 			want:    []string{`one quotedtwo quoted`},
 			wantErr: false,
 		},
+		{
+			name:    "escaped single quote",
+			line:    `it\'s`,
+			want:    []string{`it's`},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -19,7 +19,7 @@ func NewClientStream(ctx context.Context, grpcStream GRPCClientStream, id ConnID
 	s.dialTimeout = dialTimeout
 	s.sessionID = sessionID
 
-	if err := s.Send(ctx, StreamInfoMessage(id, sessionID, callDelay, dialTimeout)); err != nil {
+	if err := s.Send(ctx, StreamInfoMessage(id, sessionID, callDelay, dialTimeout, GetCompression(ctx))); err != nil {
 		_ = s.CloseSend(ctx)
 		return nil, err
 	}
@@ -33,6 +33,7 @@ func NewClientStream(ctx context.Context, grpcStream GRPCClientStream, id ConnID
 		return nil, errors.New("initial message was not StreamOK")
 	}
 	s.peerVersion = getVersion(m)
+	s.compression = getCompression(m)
 	return s, nil
 }
 
@@ -0,0 +1,194 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm used when compressing the payload of the messages that are sent
+// on a Stream. The algorithm is proposed by the client in the StreamInfo message and confirmed
+// by the server in the StreamOK message. A peer that doesn't know about compression will ignore
+// the proposal and respond without confirmation, and the stream will then remain uncompressed.
+type Compression byte
+
+const (
+	CompressionNone = Compression(iota)
+	CompressionZstd
+	CompressionSnappy
+)
+
+const (
+	// minCompressSize is the minimum size of a payload that is subject to compression. Smaller
+	// payloads are sent as is.
+	minCompressSize = 256
+
+	// maxDecompressedSize is the maximum size that a compressed payload may have once it has been
+	// decompressed. Connection data is read in chunks of at most ioBufferSize bytes, so anything
+	// bigger than twice that is rejected rather than allocated.
+	maxDecompressedSize = 2 * ioBufferSize
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionZstd:
+		return "zstd"
+	case CompressionSnappy:
+		return "snappy"
+	default:
+		return fmt.Sprintf("** unknown compression: %d **", c)
+	}
+}
+
+// ParseCompression returns the Compression that corresponds to the given string. An empty string
+// is considered to be equal to "none".
+func ParseCompression(s string) (Compression, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return CompressionNone, nil
+	case "zstd":
+		return CompressionZstd, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	default:
+		return CompressionNone, fmt.Errorf("invalid compression %q, valid values are none, zstd, and snappy", s)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Compression) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Compression) UnmarshalText(text []byte) (err error) {
+	*c, err = ParseCompression(string(text))
+	return err
+}
+
+// supported returns true if this is a known Compression.
+func (c Compression) supported() bool {
+	return c <= CompressionSnappy
+}
+
+type compressionKey struct{}
+
+// WithCompression returns a context that will cause client streams created with it to propose the
+// given Compression to their server peer.
+func WithCompression(ctx context.Context, c Compression) context.Context {
+	return context.WithValue(ctx, compressionKey{}, c)
+}
+
+// GetCompression returns the Compression that has been assigned to the context, or CompressionNone
+// if no Compression has been assigned.
+func GetCompression(ctx context.Context) Compression {
+	if c, ok := ctx.Value(compressionKey{}).(Compression); ok {
+		return c
+	}
+	return CompressionNone
+}
+
+//nolint:gochecknoglobals // initialized once, then read-only. EncodeAll and DecodeAll are safe for concurrent use.
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		// Errors are impossible here since no invalid options are given.
+		zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	})
+}
+
+// compressMessage returns a message with code compressed that wraps the given message, or the given
+// message if it isn't subject to compression or if compression doesn't make it smaller.
+func compressMessage(c Compression, m Message) Message {
+	if c == CompressionNone {
+		return m
+	}
+	if code := m.Code(); code != Normal && code != DialOK {
+		return m
+	}
+	pl := m.Payload()
+	if len(pl) < minCompressSize {
+		return m
+	}
+	var cm msg
+	dst := make([]byte, 2, 2+len(pl))
+	dst[0] = byte(compressed)
+	dst[1] = byte(m.Code())
+	switch c {
+	case CompressionZstd:
+		initZstd()
+		cm = zstdEncoder.EncodeAll(pl, dst)
+	case CompressionSnappy:
+		cm = append(dst, s2.EncodeSnappy(nil, pl)...)
+	default:
+		return m
+	}
+	if len(cm) >= len(m.Payload())+1 {
+		// Not worth it.
+		return m
+	}
+	return cm
+}
+
+// decompressMessage returns the message that was wrapped by the given compressed message.
+func decompressMessage(c Compression, m Message) (Message, error) {
+	pl := m.Payload()
+	if len(pl) < 1 {
+		return nil, fmt.Errorf("malformed %s message", compressed)
+	}
+	code := MessageCode(pl[0])
+	var err error
+	var data []byte
+	switch c {
+	case CompressionZstd:
+		initZstd()
+		data, err = zstdDecoder.DecodeAll(pl[1:], []byte{byte(code)})
+	case CompressionSnappy:
+		var dl int
+		if dl, err = s2.DecodedLen(pl[1:]); err == nil && dl > maxDecompressedSize {
+			err = fmt.Errorf("decoded length %d exceeds the maximum of %d", dl, maxDecompressedSize)
+		}
+		if err == nil {
+			data = make([]byte, 1+dl)
+			data[0] = byte(code)
+			_, err = s2.Decode(data[1:], pl[1:])
+		}
+	default:
+		err = fmt.Errorf("received %s message on a stream using compression %s", compressed, c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	return &inflatedMsg{msg: data, wireSize: len(pl) - 1}, nil
+}
+
+// inflatedMsg is a Message that has been decompressed. It retains the size that its payload had
+// on the wire.
+type inflatedMsg struct {
+	msg
+	wireSize int
+}
+
+// wirePayloadSize returns the size of the payload of the given message in the form that it has
+// (or will have) on the wire.
+func wirePayloadSize(m Message) int {
+	if im, ok := m.(*inflatedMsg); ok {
+		return im.wireSize
+	}
+	if m.Code() == compressed {
+		return len(m.Payload()) - 1
+	}
+	return len(m.Payload())
+}
@@ -26,6 +26,10 @@ const (
 	udpConnTTL = 1 * time.Minute
 )
 
+// ioBufferSize is the size of the buffer used when reading data from a connection, and hence the
+// maximum size of the payload of the Normal messages that a dialer sends.
+const ioBufferSize = 0x100000
+
 const (
 	notConnected = int32(iota)
 	connecting
@@ -207,7 +211,7 @@ func (h *dialer) connToStreamLoop(ctx context.Context, wg *sync.WaitGroup) {
 	wg.Add(1)
 	WriteLoop(ctx, h.stream, outgoing, wg, h.egressBytesProbe)
 
-	buf := make([]byte, 0x100000)
+	buf := make([]byte, ioBufferSize)
 	dlog.Tracef(ctx, "   CONN %s conn-to-stream loop started", id)
 	for {
 		n, err := h.conn.Read(buf)
@@ -57,6 +57,17 @@ const (
 
 	KeepAlive
 	Session
+
+	// compressed is sent instead of a Normal or DialOK message when the stream uses compression and
+	// compressing the payload of the message makes it smaller. The first byte of its payload is the
+	// code of the wrapped message, and the rest is the compressed payload of that message.
+	compressed
+
+	// muxInfo is sent instead of streamInfo by a client that wants the tunnel to be multiplexed.
+	muxInfo
+
+	// muxOK is the server's response to a muxInfo.
+	muxOK
 )
 
 func (c MessageCode) String() string {
@@ -77,6 +88,12 @@ func (c MessageCode) String() string {
 		return "KEEP_ALIVE"
 	case Session:
 		return "SESSION"
+	case compressed:
+		return "COMPRESSED"
+	case muxInfo:
+		return "MUX_INFO"
+	case muxOK:
+		return "MUX_OK"
 	default:
 		return fmt.Sprintf("** unknown control code: %d **", c)
 	}
@@ -119,7 +136,7 @@ func NewMessage(code MessageCode, payload []byte) Message {
 	return msg{byte(code)}
 }
 
-func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.Duration) Message {
+func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.Duration, compression Compression) Message {
 	b := bytes.Buffer{}
 	b.WriteByte(byte(streamInfo))
 
@@ -142,12 +159,20 @@ func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.
 	n = binary.PutUvarint(buf, uint64(len(sb)))
 	b.Write(buf[:n])
 	b.Write(sb)
+
+	// Peers prior to version 3 will ignore this.
+	n = binary.PutUvarint(buf, uint64(compression))
+	b.Write(buf[:n])
 	return msg(b.Bytes())
 }
 
-func StreamOKMessage() Message {
-	m := makeMessage(streamOK, 4)
-	n := binary.PutUvarint(m.Payload(), uint64(Version))
+// StreamOKMessage returns the message that a server sends in response to a StreamInfo message. It
+// confirms the compression that the stream will use.
+func StreamOKMessage(compression Compression) Message {
+	m := makeMessage(streamOK, 8)
+	pl := m.Payload()
+	n := binary.PutUvarint(pl, uint64(Version))
+	n += binary.PutUvarint(pl[n:], uint64(compression))
 	return m[:n+1]
 }
 
@@ -171,6 +196,20 @@ func getVersion(m Message) uint16 {
 	return uint16(v)
 }
 
+// getCompression returns the compression that this StreamOK Message confirms. Peers prior to version 3
+// don't send a confirmation, and CompressionNone is returned for them.
+func getCompression(m Message) Compression {
+	pl := m.Payload()
+	if _, n := binary.Uvarint(pl); n > 0 {
+		if v, n := binary.Uvarint(pl[n:]); n > 0 {
+			if c := Compression(v); c.supported() {
+				return c
+			}
+		}
+	}
+	return CompressionNone
+}
+
 var errMalformedConnect = errors.New("malformed Connect message")
 
 // connectInfo returns the connectInfo that this Message represents.
@@ -212,5 +251,14 @@ func setConnectInfo(m Message, s *stream) error {
 	}
 	pl = pl[n:]
 	s.sessionID = string(pl[:v])
+	pl = pl[v:]
+
+	// Peers prior to version 3 will not propose a compression. Proposals that
+	// aren't understood are declined.
+	if v, n = binary.Uvarint(pl); n > 0 {
+		if c := Compression(v); c.supported() {
+			s.compression = c
+		}
+	}
 	return nil
 }
@@ -0,0 +1,571 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// A Multiplexer carries many flows over one single gRPC Tunnel stream. Each flow takes the role of
+// the GRPCClientStream (on the client side) or GRPCStream (on the server side) that is normally
+// created for each connection, so the Stream created on top of a flow using NewClientStream or
+// NewServerStream is unaware of the multiplexing. A flow is only ever opened by the client.
+//
+// Each TunnelMessage that is sent on a multiplexed tunnel, except for the initial handshake, is a
+// frame that starts with the frame type and a uvarint flow id, followed by type dependent data:
+//
+//	muxOpen:   the priority of the flow
+//	muxData:   the payload of a TunnelMessage sent on the flow
+//	muxClose:  nothing. The sender will not send more data on the flow
+//	muxReset:  a uvarint gRPC status code followed by an error message. The flow is aborted.
+//	muxWindow: a uvarint count of bytes that the receiver has consumed on the flow
+//
+// Flow control is credit based. The sender of a flow may send muxWindow bytes before it must wait
+// for the receiver to acknowledge consumption. Frames are written in priority order, but the frames of
+// one flow are always written in the order they were sent.
+type Multiplexer struct {
+	grpcStream GRPCStream
+	closeSend  func() error
+	window     uint64
+
+	// onOpen is called when the peer opens a new flow. It is nil on the client side.
+	onOpen func(*muxFlow)
+
+	lock   sync.Mutex
+	flows  map[uint64]*muxFlow
+	nextID uint64
+
+	queues [2]chan *rpc.TunnelMessage // indexed by FlowPriority
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// FlowPriority is the priority of a multiplexed flow.
+type FlowPriority byte
+
+const (
+	PriorityNormal = FlowPriority(iota)
+	PriorityHigh
+)
+
+const (
+	muxOpen = byte(iota)
+	muxData
+	muxClose
+	muxReset
+	muxWindow
+)
+
+// DefaultMuxWindow is the number of payload bytes that can be sent on a flow before the receiver
+// must acknowledge that they have been consumed.
+const DefaultMuxWindow = 256 * 1024
+
+var (
+	errMalformedMux = errors.New("malformed multiplexed tunnel message")
+
+	// ErrMuxUnsupported is returned by NewMuxClient when the peer doesn't support multiplexed tunnels.
+	ErrMuxUnsupported = errors.New("peer does not support multiplexed tunnels")
+)
+
+func muxInfoMessage(window uint64) Message {
+	m := makeMessage(muxInfo, 16)
+	pl := m.Payload()
+	n := binary.PutUvarint(pl, uint64(Version))
+	n += binary.PutUvarint(pl[n:], window)
+	return m[:n+1]
+}
+
+func muxOKMessage() Message {
+	m := makeMessage(muxOK, 8)
+	n := binary.PutUvarint(m.Payload(), uint64(Version))
+	return m[:n+1]
+}
+
+func newMultiplexer(grpcStream GRPCStream, window uint64) *Multiplexer {
+	return &Multiplexer{
+		grpcStream: grpcStream,
+		window:     window,
+		flows:      make(map[uint64]*muxFlow),
+		queues:     [2]chan *rpc.TunnelMessage{make(chan *rpc.TunnelMessage, 50), make(chan *rpc.TunnelMessage, 50)},
+		done:       make(chan struct{}),
+	}
+}
+
+// NewMuxClient performs the multiplexing handshake on the given gRPC client stream and returns the
+// Multiplexer that will serve it until the context is cancelled, Close is called, or the stream fails.
+// ErrMuxUnsupported is returned if the peer doesn't support multiplexing.
+func NewMuxClient(ctx context.Context, grpcStream GRPCClientStream) (*Multiplexer, error) {
+	m := newMultiplexer(grpcStream, DefaultMuxWindow)
+	m.closeSend = grpcStream.CloseSend
+	if err := grpcStream.Send(muxInfoMessage(m.window).TunnelMessage()); err != nil {
+		_ = grpcStream.CloseSend()
+		return nil, err
+	}
+	tm, err := grpcStream.Recv()
+	if err != nil {
+		_ = grpcStream.CloseSend()
+		if status.Code(err) == codes.FailedPrecondition || errors.Is(err, io.EOF) {
+			// A peer that doesn't know about multiplexing will reject the muxInfo message.
+			return nil, fmt.Errorf("%w: %v", ErrMuxUnsupported, err)
+		}
+		return nil, err
+	}
+	if rm := msg(tm.Payload); len(rm) == 0 || rm.Code() != muxOK {
+		_ = grpcStream.CloseSend()
+		return nil, ErrMuxUnsupported
+	}
+	m.start(ctx)
+	return m, nil
+}
+
+// serveMux performs the server side of the multiplexing handshake using the given muxInfo message, and then
+// serves the flows of the multiplexed tunnel by calling the handler with a Stream for each one of them. The
+// call blocks until the tunnel ends.
+func serveMux(ctx context.Context, grpcStream GRPCStream, m Message, handler func(context.Context, Stream) error) error {
+	pl := m.Payload()
+	_, n := binary.Uvarint(pl)
+	if n <= 0 {
+		return errMalformedMux
+	}
+	window, n := binary.Uvarint(pl[n:])
+	if n <= 0 || window == 0 {
+		return errMalformedMux
+	}
+	mx := newMultiplexer(grpcStream, window)
+	mx.onOpen = func(f *muxFlow) {
+		go func() {
+			defer f.CloseSend()
+			s, err := NewServerStream(f.ctx, f)
+			if err != nil {
+				dlog.Errorf(f.ctx, "!! MUX %d, failed to connect stream: %v", f.id, err)
+				f.reset(status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err))
+				return
+			}
+			if err = handler(f.ctx, s); err != nil {
+				f.reset(err)
+			}
+		}()
+	}
+	if err := grpcStream.Send(muxOKMessage().TunnelMessage()); err != nil {
+		return err
+	}
+	mx.start(ctx)
+	select {
+	case <-ctx.Done():
+	case <-mx.done:
+	}
+	if err := mx.error(); !(errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.Is(err, context.Canceled)) {
+		return err
+	}
+	return nil
+}
+
+func (m *Multiplexer) start(ctx context.Context) {
+	go m.readLoop(ctx)
+	go m.writeLoop(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.terminate(ctx.Err())
+		case <-m.done:
+		}
+	}()
+}
+
+// Done returns a channel that is closed when the Multiplexer has terminated.
+func (m *Multiplexer) Done() <-chan struct{} {
+	return m.done
+}
+
+// Close terminates the Multiplexer. All active flows will fail. The sending side of the underlying
+// gRPC stream is closed by the write loop, since gRPC doesn't permit concurrent calls to Send and
+// CloseSend.
+func (m *Multiplexer) Close() error {
+	m.terminate(net.ErrClosed)
+	return nil
+}
+
+// OpenFlow opens a new flow with the given priority. The flow is aborted when the given context is cancelled.
+func (m *Multiplexer) OpenFlow(ctx context.Context, priority FlowPriority) (GRPCClientStream, error) {
+	m.lock.Lock()
+	select {
+	case <-m.done:
+		m.lock.Unlock()
+		return nil, m.error()
+	default:
+	}
+	m.nextID++
+	f := m.newFlow(ctx, m.nextID, priority)
+	m.lock.Unlock()
+
+	if err := m.enqueue(ctx, priority, muxFrame(muxOpen, f.id, []byte{byte(priority)})); err != nil {
+		m.remove(f.id)
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			f.reset(ctx.Err())
+		case <-f.finished:
+		}
+	}()
+	return f, nil
+}
+
+// newFlow creates a new flow and registers it with the Multiplexer. The caller must hold the lock.
+func (m *Multiplexer) newFlow(ctx context.Context, id uint64, priority FlowPriority) *muxFlow {
+	f := &muxFlow{
+		mux:      m,
+		id:       id,
+		priority: priority,
+		ctx:      ctx,
+		credit:   int64(m.window),
+		readyCh:  make(chan struct{}, 1),
+		creditCh: make(chan struct{}, 1),
+		finished: make(chan struct{}),
+	}
+	m.flows[id] = f
+	return f
+}
+
+func (m *Multiplexer) getFlow(id uint64) *muxFlow {
+	m.lock.Lock()
+	f := m.flows[id]
+	m.lock.Unlock()
+	return f
+}
+
+func (m *Multiplexer) remove(id uint64) {
+	m.lock.Lock()
+	f, ok := m.flows[id]
+	if ok {
+		delete(m.flows, id)
+	}
+	m.lock.Unlock()
+	if ok {
+		close(f.finished)
+	}
+}
+
+func (m *Multiplexer) error() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.err
+}
+
+func (m *Multiplexer) terminate(err error) {
+	m.once.Do(func() {
+		m.lock.Lock()
+		m.err = err
+		close(m.done)
+		flows := make([]*muxFlow, 0, len(m.flows))
+		for id, f := range m.flows {
+			flows = append(flows, f)
+			delete(m.flows, id)
+		}
+		m.lock.Unlock()
+		for _, f := range flows {
+			f.fail(fmt.Errorf("multiplexed tunnel terminated: %w", err))
+			close(f.finished)
+		}
+	})
+}
+
+func muxFrame(frameType byte, id uint64, data []byte) *rpc.TunnelMessage {
+	pl := make([]byte, 1+binary.MaxVarintLen64+len(data))
+	pl[0] = frameType
+	n := 1 + binary.PutUvarint(pl[1:], id)
+	n += copy(pl[n:], data)
+	return &rpc.TunnelMessage{Payload: pl[:n]}
+}
+
+func (m *Multiplexer) enqueue(ctx context.Context, priority FlowPriority, tm *rpc.TunnelMessage) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.done:
+		return m.error()
+	case m.queues[priority] <- tm:
+		return nil
+	}
+}
+
+func (m *Multiplexer) writeLoop(ctx context.Context) {
+	hi, lo := m.queues[PriorityHigh], m.queues[PriorityNormal]
+	for {
+		var tm *rpc.TunnelMessage
+		select {
+		case tm = <-hi:
+		default:
+			select {
+			case <-m.done:
+				if m.closeSend != nil {
+					_ = m.closeSend()
+				}
+				return
+			case tm = <-hi:
+			case tm = <-lo:
+			}
+		}
+		if err := m.grpcStream.Send(tm); err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				dlog.Errorf(ctx, "!! MUX, send failed: %v", err)
+			}
+			m.terminate(err)
+			return
+		}
+	}
+}
+
+func (m *Multiplexer) readLoop(ctx context.Context) {
+	for {
+		tm, err := m.grpcStream.Recv()
+		if err != nil {
+			m.terminate(err)
+			return
+		}
+		if err = m.dispatch(ctx, tm.Payload); err != nil {
+			dlog.Errorf(ctx, "!! MUX, %v", err)
+			m.terminate(err)
+			return
+		}
+	}
+}
+
+func (m *Multiplexer) dispatch(ctx context.Context, pl []byte) error {
+	if len(pl) < 2 {
+		return errMalformedMux
+	}
+	frameType := pl[0]
+	id, n := binary.Uvarint(pl[1:])
+	if n <= 0 {
+		return errMalformedMux
+	}
+	data := pl[1+n:]
+	if frameType == muxOpen {
+		if m.onOpen == nil || len(data) != 1 || FlowPriority(data[0]) > PriorityHigh {
+			return errMalformedMux
+		}
+		m.lock.Lock()
+		if _, ok := m.flows[id]; ok {
+			m.lock.Unlock()
+			return fmt.Errorf("flow %d is already open", id)
+		}
+		fc, cancel := context.WithCancel(ctx)
+		f := m.newFlow(fc, id, FlowPriority(data[0]))
+		m.lock.Unlock()
+		go func() {
+			<-f.finished
+			cancel()
+		}()
+		m.onOpen(f)
+		return nil
+	}
+
+	f := m.getFlow(id)
+	if f == nil {
+		// The flow has been reset locally. Discard.
+		return nil
+	}
+	switch frameType {
+	case muxData:
+		f.deliver(data)
+	case muxClose:
+		f.remoteClose()
+	case muxReset:
+		code, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errMalformedMux
+		}
+		f.fail(status.Error(codes.Code(code), string(data[n:])))
+		m.remove(id)
+	case muxWindow:
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errMalformedMux
+		}
+		f.addCredit(v)
+	default:
+		return errMalformedMux
+	}
+	return nil
+}
+
+// muxFlow is one flow in a multiplexed tunnel. It implements GRPCClientStream.
+type muxFlow struct {
+	mux      *Multiplexer
+	id       uint64
+	priority FlowPriority
+	ctx      context.Context
+	readyCh  chan struct{}
+	creditCh chan struct{}
+	finished chan struct{}
+
+	lock         sync.Mutex
+	queue        [][]byte
+	consumed     uint64
+	credit       int64
+	err          error
+	localClosed  bool
+	remoteClosed bool
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Recv returns the next message sent by the peer on this flow, or io.EOF when the peer has closed it.
+func (f *muxFlow) Recv() (*rpc.TunnelMessage, error) {
+	for {
+		f.lock.Lock()
+		if len(f.queue) > 0 {
+			pl := f.queue[0]
+			f.queue = f.queue[1:]
+			f.consumed += uint64(len(pl))
+			var ack uint64
+			if f.consumed >= f.mux.window/2 {
+				ack = f.consumed
+				f.consumed = 0
+			}
+			f.lock.Unlock()
+			if ack > 0 {
+				buf := make([]byte, binary.MaxVarintLen64)
+				n := binary.PutUvarint(buf, ack)
+				if err := f.mux.enqueue(f.ctx, PriorityHigh, muxFrame(muxWindow, f.id, buf[:n])); err != nil {
+					return nil, err
+				}
+			}
+			return &rpc.TunnelMessage{Payload: pl}, nil
+		}
+		err := f.err
+		if err == nil && f.remoteClosed {
+			err = io.EOF
+		}
+		f.lock.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-f.ctx.Done():
+			return nil, f.ctx.Err()
+		case <-f.readyCh:
+		}
+	}
+}
+
+// Send sends the given message on this flow. It blocks while the flow is out of credit.
+func (f *muxFlow) Send(tm *rpc.TunnelMessage) error {
+	for {
+		f.lock.Lock()
+		err := f.err
+		if err == nil && f.localClosed {
+			err = net.ErrClosed
+		}
+		if err == nil && f.credit > 0 {
+			// Overdraft is permitted so that messages larger than the window can pass.
+			f.credit -= int64(len(tm.Payload))
+			f.lock.Unlock()
+			return f.mux.enqueue(f.ctx, f.priority, muxFrame(muxData, f.id, tm.Payload))
+		}
+		f.lock.Unlock()
+		if err != nil {
+			return err
+		}
+		select {
+		case <-f.ctx.Done():
+			return f.ctx.Err()
+		case <-f.creditCh:
+		}
+	}
+}
+
+// CloseSend tells the peer that no more messages will be sent on this flow.
+func (f *muxFlow) CloseSend() error {
+	f.lock.Lock()
+	if f.localClosed || f.err != nil {
+		f.lock.Unlock()
+		return nil
+	}
+	f.localClosed = true
+	remove := f.remoteClosed
+	f.lock.Unlock()
+	err := f.mux.enqueue(f.ctx, f.priority, muxFrame(muxClose, f.id, nil))
+	if remove {
+		f.mux.remove(f.id)
+	}
+	return err
+}
+
+// reset aborts the flow and tells the peer about it.
+func (f *muxFlow) reset(err error) {
+	f.lock.Lock()
+	if f.err != nil || f.localClosed && f.remoteClosed {
+		f.lock.Unlock()
+		return
+	}
+	f.err = err
+	f.lock.Unlock()
+	signal(f.readyCh)
+	signal(f.creditCh)
+	st := status.Convert(err)
+	buf := make([]byte, binary.MaxVarintLen64+len(st.Message()))
+	n := binary.PutUvarint(buf, uint64(st.Code()))
+	n += copy(buf[n:], st.Message())
+	// The flow's context might be cancelled at this point, so the mux context must be used.
+	select {
+	case f.mux.queues[f.priority] <- muxFrame(muxReset, f.id, buf[:n]):
+	case <-f.mux.done:
+	}
+	f.mux.remove(f.id)
+}
+
+func (f *muxFlow) fail(err error) {
+	f.lock.Lock()
+	if f.err == nil {
+		f.err = err
+	}
+	f.lock.Unlock()
+	signal(f.readyCh)
+	signal(f.creditCh)
+}
+
+func (f *muxFlow) deliver(data []byte) {
+	pl := make([]byte, len(data))
+	copy(pl, data)
+	f.lock.Lock()
+	f.queue = append(f.queue, pl)
+	f.lock.Unlock()
+	signal(f.readyCh)
+}
+
+func (f *muxFlow) remoteClose() {
+	f.lock.Lock()
+	f.remoteClosed = true
+	remove := f.localClosed
+	f.lock.Unlock()
+	signal(f.readyCh)
+	if remove {
+		f.mux.remove(f.id)
+	}
+}
+
+func (f *muxFlow) addCredit(v uint64) {
+	f.lock.Lock()
+	f.credit += int64(v)
+	f.lock.Unlock()
+	signal(f.creditCh)
+}
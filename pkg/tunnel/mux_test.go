@@ -0,0 +1,337 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/log"
+)
+
+type echoManager struct {
+	manager.UnimplementedManagerServer
+	legacy bool
+}
+
+// echo sends every Normal message back to the client until the client closes.
+func echo(ctx context.Context, s Stream) error {
+	if s.SessionID() == "reject" {
+		return status.Error(codes.NotFound, "session rejected")
+	}
+	for {
+		m, err := s.Receive(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return s.CloseSend(ctx)
+			}
+			return err
+		}
+		if m.Code() == Normal {
+			if err = s.Send(ctx, m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (m *echoManager) Tunnel(server manager.Manager_TunnelServer) error {
+	ctx := server.Context()
+	if m.legacy {
+		s, err := NewServerStream(ctx, server)
+		if err != nil {
+			return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
+		}
+		return echo(ctx, s)
+	}
+	return AcceptStreams(ctx, server, echo)
+}
+
+func startEchoManager(ctx context.Context, t testing.TB, legacy bool) manager.ManagerClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	manager.RegisterManagerServer(srv, &echoManager{legacy: legacy})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	return manager.NewManagerClient(conn)
+}
+
+func echoRoundtrip(ctx context.Context, gs GRPCClientStream, id ConnID, sessionID string, payload []byte, count int) error {
+	s, err := NewClientStream(ctx, gs, id, sessionID, 0, 0)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		if err = s.Send(ctx, NewMessage(Normal, payload)); err != nil {
+			return err
+		}
+		m, err := s.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(payload, m.Payload()) {
+			return fmt.Errorf("unexpected message content in message %d", i)
+		}
+	}
+	if err = s.CloseSend(ctx); err != nil {
+		return err
+	}
+	if _, err = s.Receive(ctx); !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("expected closeSend, got %v", err)
+	}
+	return nil
+}
+
+func TestMux_Flows(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	mc := startEchoManager(ctx, t, false)
+	mp := NewMuxProvider(ctx, ManagerProvider(mc))
+
+	small := []byte("hello")
+	large := bytes.Repeat([]byte{0x5a}, 3*DefaultMuxWindow/2)
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			proto, payload, count := ipproto.TCP, small, 20
+			switch {
+			case i%10 == 0:
+				payload, count = large, 3
+			case i%2 == 0:
+				proto = ipproto.UDP
+			}
+			id := NewConnID(proto, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), uint16(1000+i), 8080)
+			gs, err := mp.Tunnel(ctx, id)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err = echoRoundtrip(ctx, gs, id, "session", payload, count); err != nil {
+				errs <- fmt.Errorf("flow %d: %w", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	requireNoErrs(t, errs)
+
+	mux, err := mp.getMux(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, mux)
+	assert.Eventually(t, func() bool {
+		mux.lock.Lock()
+		defer mux.lock.Unlock()
+		return len(mux.flows) == 0
+	}, 5*time.Second, 10*time.Millisecond, "all flows should be released")
+}
+
+func TestMux_HandlerError(t *testing.T) {
+	ctx, cancel := testContext(t, 10*time.Second)
+	defer cancel()
+
+	mp := NewMuxProvider(ctx, ManagerProvider(startEchoManager(ctx, t, false)))
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	gs, err := mp.Tunnel(ctx, id)
+	require.NoError(t, err)
+	s, err := NewClientStream(ctx, gs, id, "reject", 0, 0)
+	require.NoError(t, err)
+	_, err = s.Receive(ctx)
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Other flows are unaffected.
+	gs, err = mp.Tunnel(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, echoRoundtrip(ctx, gs, id, "session", []byte("hello"), 1))
+}
+
+func TestMux_CancelFlow(t *testing.T) {
+	ctx, cancel := testContext(t, 10*time.Second)
+	defer cancel()
+
+	mp := NewMuxProvider(ctx, ManagerProvider(startEchoManager(ctx, t, false)))
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	fc, fCancel := context.WithCancel(ctx)
+	gs, err := mp.Tunnel(fc, id)
+	require.NoError(t, err)
+	_, err = NewClientStream(fc, gs, id, "session", 0, 0)
+	require.NoError(t, err)
+	fCancel()
+
+	mux, err := mp.getMux(ctx)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		mux.lock.Lock()
+		defer mux.lock.Unlock()
+		return len(mux.flows) == 0
+	}, 5*time.Second, 10*time.Millisecond, "cancelled flow should be released")
+
+	gs, err = mp.Tunnel(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, echoRoundtrip(ctx, gs, id, "session", []byte("hello"), 1))
+}
+
+func TestMux_Unsupported(t *testing.T) {
+	ctx, cancel := testContext(t, 10*time.Second)
+	defer cancel()
+
+	mp := NewMuxProvider(ctx, ManagerProvider(startEchoManager(ctx, t, true)))
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	for i := 0; i < 3; i++ {
+		gs, err := mp.Tunnel(ctx, id)
+		require.NoError(t, err)
+		require.NoError(t, echoRoundtrip(ctx, gs, id, "session", []byte("hello"), 2))
+	}
+	assert.False(t, mp.retryMuxAt.IsZero())
+}
+
+func benchmarkTunnel(b *testing.B, tunnelFunc func(context.Context, manager.ManagerClient, ConnID) (GRPCClientStream, error)) {
+	ctx, cancel := context.WithCancel(dlog.WithLogger(context.Background(), log.NewTestLogger(b, dlog.LogLevelError)))
+	defer cancel()
+	mc := startEchoManager(ctx, b, false)
+	payload := bytes.Repeat([]byte{0x5a}, 1024)
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+
+	// Warm up
+	gs, err := tunnelFunc(ctx, mc, id)
+	require.NoError(b, err)
+	require.NoError(b, echoRoundtrip(ctx, gs, id, "session", payload, 1))
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			gs, err := tunnelFunc(ctx, mc, id)
+			if err == nil {
+				err = echoRoundtrip(ctx, gs, id, "session", payload, 1)
+			}
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkTunnel_StreamPerConnection measures short-lived connections that each use a gRPC stream of their own.
+func BenchmarkTunnel_StreamPerConnection(b *testing.B) {
+	benchmarkTunnel(b, func(ctx context.Context, mc manager.ManagerClient, _ ConnID) (GRPCClientStream, error) {
+		return mc.Tunnel(ctx)
+	})
+}
+
+// BenchmarkTunnel_Multiplexed measures short-lived connections that are flows in one multiplexed gRPC stream.
+func BenchmarkTunnel_Multiplexed(b *testing.B) {
+	var mp *MuxProvider
+	var once sync.Once
+	benchmarkTunnel(b, func(ctx context.Context, mc manager.ManagerClient, id ConnID) (GRPCClientStream, error) {
+		once.Do(func() { mp = NewMuxProvider(ctx, ManagerProvider(mc)) })
+		return mp.Tunnel(ctx, id)
+	})
+}
+
+type stallingManager struct {
+	manager.UnimplementedManagerServer
+}
+
+// Tunnel never responds to the initial message.
+func (m *stallingManager) Tunnel(server manager.Manager_TunnelServer) error {
+	<-server.Context().Done()
+	return nil
+}
+
+func TestMux_StalledHandshake(t *testing.T) {
+	ctx, cancel := testContext(t, 10*time.Second)
+	defer cancel()
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	manager.RegisterManagerServer(srv, &stallingManager{})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	mp := NewMuxProvider(ctx, ManagerProvider(manager.NewManagerClient(conn)))
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+
+	// A caller that gives up doesn't block the others, and the handshake is retried by the next caller.
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			tc, tCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+			defer tCancel()
+			_, err := mp.Tunnel(tc, id)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-errs:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(5 * time.Second):
+			t.Fatal("stalled handshake blocked the caller")
+		}
+	}
+	mp.lock.Lock()
+	assert.Nil(t, mp.connecting)
+	assert.Nil(t, mp.mux)
+	mp.lock.Unlock()
+}
+
+func TestMux_CloseReleasesFlows(t *testing.T) {
+	ctx, cancel := testContext(t, 10*time.Second)
+	defer cancel()
+
+	mp := NewMuxProvider(ctx, ManagerProvider(startEchoManager(ctx, t, false)))
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	gs, err := mp.Tunnel(ctx, id)
+	require.NoError(t, err)
+	f := gs.(*muxFlow)
+	require.NoError(t, f.mux.Close())
+	select {
+	case <-f.finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("flow was not finished when the multiplexer terminated")
+	}
+
+	// A new multiplexer is created on demand.
+	gs, err = mp.Tunnel(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, echoRoundtrip(ctx, gs, id, "session", []byte("hello"), 1))
+}
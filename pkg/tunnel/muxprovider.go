@@ -0,0 +1,152 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+const (
+	// muxHandshakeTimeout is the maximum time that the MuxProvider waits for the peer to respond to
+	// the multiplexing handshake.
+	muxHandshakeTimeout = 10 * time.Second
+
+	// muxRetryInterval is the time that the MuxProvider uses one tunnel per stream after finding that
+	// the peer doesn't support multiplexing. A new handshake is attempted once it has passed, so that a
+	// peer that is upgraded during the session is detected.
+	muxRetryInterval = time.Minute
+)
+
+// MuxProvider creates GRPCClientStreams that are flows in a multiplexed tunnel. The tunnel is created
+// on demand using the given Provider, and recreated if it terminates. If the peer doesn't support
+// multiplexing, the MuxProvider falls back to creating one tunnel per stream.
+type MuxProvider struct {
+	ctx      context.Context
+	provider Provider
+
+	lock       sync.Mutex
+	mux        *Multiplexer
+	connecting chan struct{} // closed when an ongoing handshake completes
+	retryMuxAt time.Time     // the time of the next handshake attempt when multiplexing is unsupported
+}
+
+// NewMuxProvider creates a new MuxProvider. The multiplexed tunnels that it creates will
+// be closed when the given context is cancelled.
+func NewMuxProvider(ctx context.Context, provider Provider) *MuxProvider {
+	return &MuxProvider{ctx: ctx, provider: provider}
+}
+
+// Tunnel returns a GRPCClientStream suitable for a Stream with the given ConnID. UDP flows are given
+// a higher priority than TCP flows.
+func (p *MuxProvider) Tunnel(ctx context.Context, id ConnID) (GRPCClientStream, error) {
+	mux, err := p.getMux(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mux == nil {
+		return p.provider.Tunnel(ctx)
+	}
+	priority := PriorityNormal
+	if id.Protocol() == ipproto.UDP {
+		priority = PriorityHigh
+	}
+	return mux.OpenFlow(ctx, priority)
+}
+
+// getMux returns the current Multiplexer, or creates a new one if there is none. Only one handshake is
+// performed at a time. Other callers wait for it to complete, and then try again if it failed. A nil
+// Multiplexer is returned when the peer doesn't support multiplexing.
+func (p *MuxProvider) getMux(ctx context.Context) (*Multiplexer, error) {
+	for {
+		p.lock.Lock()
+		if time.Now().Before(p.retryMuxAt) {
+			p.lock.Unlock()
+			return nil, nil
+		}
+		if p.mux != nil {
+			select {
+			case <-p.mux.Done():
+				dlog.Debugf(p.ctx, "multiplexed tunnel terminated: %v", p.mux.error())
+				p.mux = nil
+			default:
+				mux := p.mux
+				p.lock.Unlock()
+				return mux, nil
+			}
+		}
+		if ch := p.connecting; ch != nil {
+			p.lock.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-ch:
+				continue
+			}
+		}
+		ch := make(chan struct{})
+		p.connecting = ch
+		p.lock.Unlock()
+
+		mux, err := p.connect(ctx)
+
+		p.lock.Lock()
+		p.connecting = nil
+		switch {
+		case err == nil:
+			p.mux = mux
+		case errors.Is(err, ErrMuxUnsupported):
+			dlog.Infof(p.ctx, "Using one tunnel per connection: %v", err)
+			p.retryMuxAt = time.Now().Add(muxRetryInterval)
+			err = nil
+		}
+		close(ch)
+		p.lock.Unlock()
+		return mux, err
+	}
+}
+
+// connect creates a new tunnel and performs the multiplexing handshake on it. The tunnel lives until the
+// MuxProvider's context is cancelled or the Multiplexer terminates, but the handshake is aborted if it
+// doesn't complete within muxHandshakeTimeout, or if the given context is cancelled.
+func (p *MuxProvider) connect(ctx context.Context) (*Multiplexer, error) {
+	tCtx, cancel := context.WithCancel(p.ctx)
+	gs, err := p.provider.Tunnel(tCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	type result struct {
+		mux *Multiplexer
+		err error
+	}
+	rc := make(chan result, 1)
+	go func() {
+		mux, err := NewMuxClient(tCtx, gs)
+		rc <- result{mux: mux, err: err}
+	}()
+	timer := time.NewTimer(muxHandshakeTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-rc:
+		if r.err != nil {
+			cancel()
+			return nil, r.err
+		}
+		go func() {
+			<-r.mux.Done()
+			cancel()
+		}()
+		return r.mux, nil
+	case <-ctx.Done():
+		cancel()
+		return nil, ctx.Err()
+	case <-timer.C:
+		cancel()
+		return nil, fmt.Errorf("multiplexing handshake timed out after %s", muxHandshakeTimeout)
+	}
+}
@@ -4,17 +4,33 @@ import (
 	"sync/atomic"
 )
 
+// CounterProbe counts bytes. The value is the number of uncompressed bytes, and the
+// compressed value is the number of bytes that were transferred on the wire.
 type CounterProbe struct {
-	name  string
-	value uint64
+	name            string
+	value           uint64
+	compressedValue uint64
+	parent          *CounterProbe
 }
 
 func NewCounterProbe(name string) *CounterProbe {
 	return &CounterProbe{name: name}
 }
 
+// NewChild returns a new probe with the same name as this probe. Values that are added to the
+// child are also added to this probe. A nil probe returns a probe without a parent.
+func (p *CounterProbe) NewChild() *CounterProbe {
+	if p == nil {
+		return &CounterProbe{}
+	}
+	return &CounterProbe{name: p.name, parent: p}
+}
+
 func (p *CounterProbe) Increment(v uint64) {
 	atomic.AddUint64(&p.value, v)
+	if p.parent != nil {
+		p.parent.Increment(v)
+	}
 }
 
 func (p *CounterProbe) GetName() string {
@@ -24,3 +40,14 @@ func (p *CounterProbe) GetName() string {
 func (p *CounterProbe) GetValue() uint64 {
 	return atomic.LoadUint64(&p.value)
 }
+
+func (p *CounterProbe) IncrementCompressed(v uint64) {
+	atomic.AddUint64(&p.compressedValue, v)
+	if p.parent != nil {
+		p.parent.IncrementCompressed(v)
+	}
+}
+
+func (p *CounterProbe) GetCompressedValue() uint64 {
+	return atomic.LoadUint64(&p.compressedValue)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func NewServerStream(ctx context.Context, grpcStream GRPCStream) (Stream, error) {
-	s := &stream{tag: "SRV", grpcStream: grpcStream, syncRatio: 8, ackWindow: 1}
+	s := newServerStream(grpcStream)
 	m, err := s.Receive(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read initial StreamInfo message: %w", err)
@@ -15,11 +18,46 @@ func NewServerStream(ctx context.Context, grpcStream GRPCStream) (Stream, error)
 	if m.Code() != streamInfo {
 		return nil, errors.New("initial message was not StreamInfo")
 	}
-	if err = setConnectInfo(m, s); err != nil {
-		return nil, fmt.Errorf("failed to parse StreamInfo message: %w", err)
-	}
-	if err = s.Send(ctx, StreamOKMessage()); err != nil {
+	if err = s.connect(ctx, m); err != nil {
 		return nil, err
 	}
 	return s, nil
 }
+
+// AcceptStreams reads the initial message from the given gRPC stream. If it is a StreamInfo message, then
+// a server Stream is created and passed to the handler. If it is a MuxInfo message, then the gRPC stream
+// is a multiplexed tunnel, and the handler is called with a server Stream for each of its flows. The call
+// blocks until the handler returns, or in case of a multiplexed tunnel, until the tunnel is closed.
+//
+// Failure to establish the connection is reported as a FailedPrecondition status error.
+func AcceptStreams(ctx context.Context, grpcStream GRPCStream, handler func(context.Context, Stream) error) error {
+	s := newServerStream(grpcStream)
+	m, err := s.Receive(ctx)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "failed to connect stream: failed to read initial StreamInfo message: %v", err)
+	}
+	switch m.Code() {
+	case streamInfo:
+		if err = s.connect(ctx, m); err != nil {
+			return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
+		}
+		return handler(ctx, s)
+	case muxInfo:
+		return serveMux(ctx, grpcStream, m, handler)
+	default:
+		return status.Error(codes.FailedPrecondition, "failed to connect stream: initial message was neither StreamInfo nor MuxInfo")
+	}
+}
+
+func newServerStream(grpcStream GRPCStream) *stream {
+	s := newStream("SRV", grpcStream)
+	return &s
+}
+
+// connect parses the given StreamInfo message and responds with StreamOK.
+func (s *stream) connect(ctx context.Context, m Message) error {
+	if err := setConnectInfo(m, s); err != nil {
+		return fmt.Errorf("failed to parse StreamInfo message: %w", err)
+	}
+	return s.Send(ctx, StreamOKMessage(s.compression))
+}
@@ -21,7 +21,9 @@ import (
 //
 //	0 which didn't report versions and didn't do synchronization
 //	1 used MuxTunnel instead of one tunnel per connection.
-const Version = uint16(2)
+//	2 used one tunnel per connection with synchronization.
+//	3 negotiates per stream compression of the message payload.
+const Version = uint16(3)
 
 // Endpoint is an endpoint for a Stream such as a Dialer or a bidirectional pipe.
 type Endpoint interface {
@@ -93,6 +95,7 @@ func ReadLoop(ctx context.Context, s Stream, p *CounterProbe) (<-chan Message, <
 			m, err := s.Receive(ctx)
 			if m != nil && p != nil {
 				p.Increment(uint64(len(m.Payload())))
+				p.IncrementCompressed(uint64(wirePayloadSize(m)))
 			}
 
 			switch {
@@ -155,9 +158,18 @@ func WriteLoop(
 					break
 				}
 
-				err := s.Send(ctx, m)
+				// Compress up front so that the probe can count the bytes sent on the wire.
+				var err error
+				wm := m
+				if ws, ok := s.(wireSender); ok {
+					wm = ws.compress(m)
+					err = ws.sendWire(ctx, wm)
+				} else {
+					err = s.Send(ctx, wm)
+				}
 				if m != nil && p != nil {
 					p.Increment(uint64(len(m.Payload())))
+					p.IncrementCompressed(uint64(wirePayloadSize(wm)))
 				}
 
 				switch {
@@ -185,6 +197,7 @@ type stream struct {
 	syncRatio        uint32 // send and check sync after each syncRatio message
 	ackWindow        uint32 // maximum permitted difference between sent and received ack
 	peerVersion      uint16
+	compression      Compression
 }
 
 func newStream(tag string, grpcStream GRPCStream) stream {
@@ -215,13 +228,30 @@ func (s *stream) SessionID() string {
 	return s.sessionID
 }
 
+// wireSender is implemented by streams that can compress messages up front and then send them
+// without compressing them again.
+type wireSender interface {
+	compress(Message) Message
+	sendWire(context.Context, Message) error
+}
+
+// compress returns the given message in the form that it will have on the wire.
+func (s *stream) compress(m Message) Message {
+	return compressMessage(s.compression, m)
+}
+
 func (s *stream) Receive(ctx context.Context) (Message, error) {
 	cm, err := s.grpcStream.Recv()
 	if err != nil {
 		return nil, err
 	}
-	m := msg(cm.Payload)
+	var m Message = msg(cm.Payload)
 	switch m.Code() {
+	case compressed:
+		if m, err = decompressMessage(s.compression, m); err != nil {
+			return nil, err
+		}
+		dlog.Tracef(ctx, "<- %s %s, %s (%s)", s.tag, s.id, m, s.compression)
 	case closeSend:
 		dlog.Tracef(ctx, "<- %s %s, close send", s.tag, s.id)
 		return nil, net.ErrClosed
@@ -234,6 +264,11 @@ func (s *stream) Receive(ctx context.Context) (Message, error) {
 }
 
 func (s *stream) Send(ctx context.Context, m Message) error {
+	return s.sendWire(ctx, s.compress(m))
+}
+
+// sendWire sends a message that already has the form that it will have on the wire.
+func (s *stream) sendWire(ctx context.Context, m Message) error {
 	if err := s.grpcStream.Send(m.TunnelMessage()); err != nil {
 		if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
 			dlog.Errorf(ctx, "!! %s %s, Send failed: %v", s.tag, s.id, err)
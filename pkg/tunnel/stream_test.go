@@ -3,6 +3,7 @@ package tunnel
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
@@ -338,3 +339,106 @@ func TestStream_Xfer(t *testing.T) {
 		errs = requireNoErrs(t, errs)
 	})
 }
+
+func TestStream_Compression(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	si := uuid.New().String()
+	b := bytes.Repeat([]byte("telepresence "), 0x200)
+	large := NewMessage(Normal, b)
+
+	for _, c := range []Compression{CompressionNone, CompressionZstd, CompressionSnappy} {
+		c := c
+		t.Run(c.String(), func(t *testing.T) {
+			tunnel := newBidi(10, ctx.Done())
+			errs := make(chan error, 10)
+			cCtx := WithCompression(ctx, c)
+			probe := NewCounterProbe("FromClientBytes")
+			wg := sync.WaitGroup{}
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if client, err := NewClientStream(cCtx, tunnel.clientSide(), id, si, 0, 0); err != nil {
+					errs <- err
+				} else {
+					produce(cCtx, client, large, errs)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				server, err := NewServerStream(ctx, tunnel.serverSide())
+				if err != nil {
+					errs <- err
+					return
+				}
+				assert.Equal(t, c, server.(*stream).compression)
+				rdCh, errCh := ReadLoop(ctx, server, probe)
+				for m := range rdCh {
+					if !bytes.Equal(b, m.Payload()) {
+						errs <- errors.New("unexpected message content")
+					}
+				}
+				if err, ok := <-errCh; ok {
+					errs <- err
+				}
+				_ = server.CloseSend(ctx)
+			}()
+			wg.Wait()
+			requireNoErrs(t, errs)
+
+			assert.Equal(t, uint64(100*len(b)), probe.GetValue())
+			if c == CompressionNone {
+				assert.Equal(t, probe.GetValue(), probe.GetCompressedValue())
+			} else {
+				assert.Less(t, probe.GetCompressedValue(), probe.GetValue()/10)
+			}
+		})
+	}
+
+	t.Run("peer without compression support", func(t *testing.T) {
+		tunnel := newBidi(10, ctx.Done())
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client, err := NewClientStream(WithCompression(ctx, CompressionZstd), tunnel.clientSide(), id, si, 0, 0)
+			require.NoError(t, err)
+			assert.Equal(t, uint16(2), client.PeerVersion())
+			assert.Equal(t, CompressionNone, client.(*clientStream).compression)
+			assert.NoError(t, client.Send(ctx, large))
+		}()
+		go func() {
+			defer wg.Done()
+			// Emulate a version 2 server that ignores the compression proposal.
+			ss := tunnel.serverSide()
+			tm, err := ss.Recv()
+			require.NoError(t, err)
+			require.Equal(t, streamInfo, msg(tm.Payload).Code())
+			m := makeMessage(streamOK, 4)
+			n := binary.PutUvarint(m.Payload(), 2)
+			require.NoError(t, ss.Send(m[:n+1].TunnelMessage()))
+			tm, err = ss.Recv()
+			require.NoError(t, err)
+			assert.Equal(t, Normal, msg(tm.Payload).Code())
+			assert.Equal(t, b, msg(tm.Payload).Payload())
+		}()
+		wg.Wait()
+	})
+}
+
+func Test_decompressMessage_limit(t *testing.T) {
+	bomb := NewMessage(Normal, make([]byte, 4*maxDecompressedSize))
+	for _, c := range []Compression{CompressionZstd, CompressionSnappy} {
+		cm := compressMessage(c, bomb)
+		require.Equal(t, compressed, cm.Code())
+		_, err := decompressMessage(c, cm)
+		assert.Error(t, err, c.String())
+
+		ok := NewMessage(Normal, make([]byte, ioBufferSize))
+		m, err := decompressMessage(c, compressMessage(c, ok))
+		require.NoError(t, err, c.String())
+		assert.Equal(t, ok.Payload(), m.Payload())
+	}
+}
@@ -35,6 +35,7 @@ const (
 	Connector_CreateIntercept_FullMethodName         = "/telepresence.connector.Connector/CreateIntercept"
 	Connector_RemoveIntercept_FullMethodName         = "/telepresence.connector.Connector/RemoveIntercept"
 	Connector_UpdateIntercept_FullMethodName         = "/telepresence.connector.Connector/UpdateIntercept"
+	Connector_AcceptInterceptHandover_FullMethodName = "/telepresence.connector.Connector/AcceptInterceptHandover"
 	Connector_Uninstall_FullMethodName               = "/telepresence.connector.Connector/Uninstall"
 	Connector_List_FullMethodName                    = "/telepresence.connector.Connector/List"
 	Connector_WatchWorkloads_FullMethodName          = "/telepresence.connector.Connector/WatchWorkloads"
@@ -49,6 +50,14 @@ const (
 	Connector_GetConfig_FullMethodName               = "/telepresence.connector.Connector/GetConfig"
 	Connector_SetDNSExcludes_FullMethodName          = "/telepresence.connector.Connector/SetDNSExcludes"
 	Connector_SetDNSMappings_FullMethodName          = "/telepresence.connector.Connector/SetDNSMappings"
+	Connector_GetConnections_FullMethodName          = "/telepresence.connector.Connector/GetConnections"
+	Connector_SetTrafficShaping_FullMethodName       = "/telepresence.connector.Connector/SetTrafficShaping"
+	Connector_GetTrafficShaping_FullMethodName       = "/telepresence.connector.Connector/GetTrafficShaping"
+	Connector_GetAdminSessions_FullMethodName        = "/telepresence.connector.Connector/GetAdminSessions"
+	Connector_GetAdminIntercepts_FullMethodName      = "/telepresence.connector.Connector/GetAdminIntercepts"
+	Connector_GetAdminAgents_FullMethodName          = "/telepresence.connector.Connector/GetAdminAgents"
+	Connector_AdminRemoveIntercept_FullMethodName    = "/telepresence.connector.Connector/AdminRemoveIntercept"
+	Connector_AdminRemoveSession_FullMethodName      = "/telepresence.connector.Connector/AdminRemoveSession"
 )
 
 // ConnectorClient is the client API for Connector service.
@@ -86,6 +95,8 @@ type ConnectorClient interface {
 	// Requires having already called Connect.
 	RemoveIntercept(ctx context.Context, in *manager.RemoveInterceptRequest2, opts ...grpc.CallOption) (*InterceptResult, error)
 	UpdateIntercept(ctx context.Context, in *manager.UpdateInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error)
+	// Takes over an intercept that another client has handed over to this client.
+	AcceptInterceptHandover(ctx context.Context, in *manager.AcceptInterceptHandoverRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error)
 	// Uninstalls traffic-agents from the cluster.
 	// Requires having already called Connect.
 	Uninstall(ctx context.Context, in *UninstallRequest, opts ...grpc.CallOption) (*common.Result, error)
@@ -120,6 +131,17 @@ type ConnectorClient interface {
 	SetDNSExcludes(ctx context.Context, in *daemon.SetDNSExcludesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(ctx context.Context, in *daemon.SetDNSMappingsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetConnections returns the connections that are currently routed to the cluster.
+	GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.Connections, error)
+	// SetTrafficShaping replaces the rules used when shaping the traffic that is routed to the cluster.
+	SetTrafficShaping(ctx context.Context, in *daemon.TrafficShapingRules, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetTrafficShaping returns the rules used when shaping the traffic that is routed to the cluster.
+	GetTrafficShaping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.TrafficShapingRules, error)
+	GetAdminSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AdminSessionsResponse, error)
+	GetAdminIntercepts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.InterceptInfoSnapshot, error)
+	GetAdminAgents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AgentInfoSnapshot, error)
+	AdminRemoveIntercept(ctx context.Context, in *manager.AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AdminRemoveSession(ctx context.Context, in *manager.AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type connectorClient struct {
@@ -238,6 +260,15 @@ func (c *connectorClient) UpdateIntercept(ctx context.Context, in *manager.Updat
 	return out, nil
 }
 
+func (c *connectorClient) AcceptInterceptHandover(ctx context.Context, in *manager.AcceptInterceptHandoverRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error) {
+	out := new(manager.InterceptInfo)
+	err := c.cc.Invoke(ctx, Connector_AcceptInterceptHandover_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *connectorClient) Uninstall(ctx context.Context, in *UninstallRequest, opts ...grpc.CallOption) (*common.Result, error) {
 	out := new(common.Result)
 	err := c.cc.Invoke(ctx, Connector_Uninstall_FullMethodName, in, out, opts...)
@@ -387,6 +418,78 @@ func (c *connectorClient) SetDNSMappings(ctx context.Context, in *daemon.SetDNSM
 	return out, nil
 }
 
+func (c *connectorClient) GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.Connections, error) {
+	out := new(daemon.Connections)
+	err := c.cc.Invoke(ctx, Connector_GetConnections_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) SetTrafficShaping(ctx context.Context, in *daemon.TrafficShapingRules, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_SetTrafficShaping_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetTrafficShaping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.TrafficShapingRules, error) {
+	out := new(daemon.TrafficShapingRules)
+	err := c.cc.Invoke(ctx, Connector_GetTrafficShaping_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetAdminSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AdminSessionsResponse, error) {
+	out := new(manager.AdminSessionsResponse)
+	err := c.cc.Invoke(ctx, Connector_GetAdminSessions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetAdminIntercepts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.InterceptInfoSnapshot, error) {
+	out := new(manager.InterceptInfoSnapshot)
+	err := c.cc.Invoke(ctx, Connector_GetAdminIntercepts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetAdminAgents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AgentInfoSnapshot, error) {
+	out := new(manager.AgentInfoSnapshot)
+	err := c.cc.Invoke(ctx, Connector_GetAdminAgents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) AdminRemoveIntercept(ctx context.Context, in *manager.AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_AdminRemoveIntercept_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) AdminRemoveSession(ctx context.Context, in *manager.AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_AdminRemoveSession_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ConnectorServer is the server API for Connector service.
 // All implementations must embed UnimplementedConnectorServer
 // for forward compatibility
@@ -422,6 +525,8 @@ type ConnectorServer interface {
 	// Requires having already called Connect.
 	RemoveIntercept(context.Context, *manager.RemoveInterceptRequest2) (*InterceptResult, error)
 	UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error)
+	// Takes over an intercept that another client has handed over to this client.
+	AcceptInterceptHandover(context.Context, *manager.AcceptInterceptHandoverRequest) (*manager.InterceptInfo, error)
 	// Uninstalls traffic-agents from the cluster.
 	// Requires having already called Connect.
 	Uninstall(context.Context, *UninstallRequest) (*common.Result, error)
@@ -456,6 +561,17 @@ type ConnectorServer interface {
 	SetDNSExcludes(context.Context, *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error)
+	// GetConnections returns the connections that are currently routed to the cluster.
+	GetConnections(context.Context, *emptypb.Empty) (*daemon.Connections, error)
+	// SetTrafficShaping replaces the rules used when shaping the traffic that is routed to the cluster.
+	SetTrafficShaping(context.Context, *daemon.TrafficShapingRules) (*emptypb.Empty, error)
+	// GetTrafficShaping returns the rules used when shaping the traffic that is routed to the cluster.
+	GetTrafficShaping(context.Context, *emptypb.Empty) (*daemon.TrafficShapingRules, error)
+	GetAdminSessions(context.Context, *emptypb.Empty) (*manager.AdminSessionsResponse, error)
+	GetAdminIntercepts(context.Context, *emptypb.Empty) (*manager.InterceptInfoSnapshot, error)
+	GetAdminAgents(context.Context, *emptypb.Empty) (*manager.AgentInfoSnapshot, error)
+	AdminRemoveIntercept(context.Context, *manager.AdminRemoveRequest) (*emptypb.Empty, error)
+	AdminRemoveSession(context.Context, *manager.AdminRemoveRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedConnectorServer()
 }
 
@@ -499,6 +615,9 @@ func (UnimplementedConnectorServer) RemoveIntercept(context.Context, *manager.Re
 func (UnimplementedConnectorServer) UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateIntercept not implemented")
 }
+func (UnimplementedConnectorServer) AcceptInterceptHandover(context.Context, *manager.AcceptInterceptHandoverRequest) (*manager.InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptInterceptHandover not implemented")
+}
 func (UnimplementedConnectorServer) Uninstall(context.Context, *UninstallRequest) (*common.Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Uninstall not implemented")
 }
@@ -541,6 +660,30 @@ func (UnimplementedConnectorServer) SetDNSExcludes(context.Context, *daemon.SetD
 func (UnimplementedConnectorServer) SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetDNSMappings not implemented")
 }
+func (UnimplementedConnectorServer) GetConnections(context.Context, *emptypb.Empty) (*daemon.Connections, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnections not implemented")
+}
+func (UnimplementedConnectorServer) SetTrafficShaping(context.Context, *daemon.TrafficShapingRules) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTrafficShaping not implemented")
+}
+func (UnimplementedConnectorServer) GetTrafficShaping(context.Context, *emptypb.Empty) (*daemon.TrafficShapingRules, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrafficShaping not implemented")
+}
+func (UnimplementedConnectorServer) GetAdminSessions(context.Context, *emptypb.Empty) (*manager.AdminSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAdminSessions not implemented")
+}
+func (UnimplementedConnectorServer) GetAdminIntercepts(context.Context, *emptypb.Empty) (*manager.InterceptInfoSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAdminIntercepts not implemented")
+}
+func (UnimplementedConnectorServer) GetAdminAgents(context.Context, *emptypb.Empty) (*manager.AgentInfoSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAdminAgents not implemented")
+}
+func (UnimplementedConnectorServer) AdminRemoveIntercept(context.Context, *manager.AdminRemoveRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminRemoveIntercept not implemented")
+}
+func (UnimplementedConnectorServer) AdminRemoveSession(context.Context, *manager.AdminRemoveRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminRemoveSession not implemented")
+}
 func (UnimplementedConnectorServer) mustEmbedUnimplementedConnectorServer() {}
 
 // UnsafeConnectorServer may be embedded to opt out of forward compatibility for this service.
@@ -770,6 +913,24 @@ func _Connector_UpdateIntercept_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Connector_AcceptInterceptHandover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.AcceptInterceptHandoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).AcceptInterceptHandover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_AcceptInterceptHandover_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).AcceptInterceptHandover(ctx, req.(*manager.AcceptInterceptHandoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Connector_Uninstall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UninstallRequest)
 	if err := dec(in); err != nil {
@@ -1025,6 +1186,150 @@ func _Connector_SetDNSMappings_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Connector_GetConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetConnections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetConnections(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_SetTrafficShaping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(daemon.TrafficShapingRules)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).SetTrafficShaping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_SetTrafficShaping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).SetTrafficShaping(ctx, req.(*daemon.TrafficShapingRules))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetTrafficShaping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetTrafficShaping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetTrafficShaping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetTrafficShaping(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetAdminSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetAdminSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetAdminSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetAdminSessions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetAdminIntercepts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetAdminIntercepts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetAdminIntercepts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetAdminIntercepts(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetAdminAgents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetAdminAgents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetAdminAgents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetAdminAgents(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_AdminRemoveIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.AdminRemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).AdminRemoveIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_AdminRemoveIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).AdminRemoveIntercept(ctx, req.(*manager.AdminRemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_AdminRemoveSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.AdminRemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).AdminRemoveSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_AdminRemoveSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).AdminRemoveSession(ctx, req.(*manager.AdminRemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Connector_ServiceDesc is the grpc.ServiceDesc for Connector service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1080,6 +1385,10 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateIntercept",
 			Handler:    _Connector_UpdateIntercept_Handler,
 		},
+		{
+			MethodName: "AcceptInterceptHandover",
+			Handler:    _Connector_AcceptInterceptHandover_Handler,
+		},
 		{
 			MethodName: "Uninstall",
 			Handler:    _Connector_Uninstall_Handler,
@@ -1132,6 +1441,38 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetDNSMappings",
 			Handler:    _Connector_SetDNSMappings_Handler,
 		},
+		{
+			MethodName: "GetConnections",
+			Handler:    _Connector_GetConnections_Handler,
+		},
+		{
+			MethodName: "SetTrafficShaping",
+			Handler:    _Connector_SetTrafficShaping_Handler,
+		},
+		{
+			MethodName: "GetTrafficShaping",
+			Handler:    _Connector_GetTrafficShaping_Handler,
+		},
+		{
+			MethodName: "GetAdminSessions",
+			Handler:    _Connector_GetAdminSessions_Handler,
+		},
+		{
+			MethodName: "GetAdminIntercepts",
+			Handler:    _Connector_GetAdminIntercepts_Handler,
+		},
+		{
+			MethodName: "GetAdminAgents",
+			Handler:    _Connector_GetAdminAgents_Handler,
+		},
+		{
+			MethodName: "AdminRemoveIntercept",
+			Handler:    _Connector_AdminRemoveIntercept_Handler,
+		},
+		{
+			MethodName: "AdminRemoveSession",
+			Handler:    _Connector_AdminRemoveSession_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
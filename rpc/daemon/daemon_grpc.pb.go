@@ -22,18 +22,21 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Daemon_Version_FullMethodName          = "/telepresence.daemon.Daemon/Version"
-	Daemon_Status_FullMethodName           = "/telepresence.daemon.Daemon/Status"
-	Daemon_Quit_FullMethodName             = "/telepresence.daemon.Daemon/Quit"
-	Daemon_Connect_FullMethodName          = "/telepresence.daemon.Daemon/Connect"
-	Daemon_Disconnect_FullMethodName       = "/telepresence.daemon.Daemon/Disconnect"
-	Daemon_GetNetworkConfig_FullMethodName = "/telepresence.daemon.Daemon/GetNetworkConfig"
-	Daemon_SetDnsSearchPath_FullMethodName = "/telepresence.daemon.Daemon/SetDnsSearchPath"
-	Daemon_SetDNSExcludes_FullMethodName   = "/telepresence.daemon.Daemon/SetDNSExcludes"
-	Daemon_SetDNSMappings_FullMethodName   = "/telepresence.daemon.Daemon/SetDNSMappings"
-	Daemon_SetLogLevel_FullMethodName      = "/telepresence.daemon.Daemon/SetLogLevel"
-	Daemon_WaitForNetwork_FullMethodName   = "/telepresence.daemon.Daemon/WaitForNetwork"
-	Daemon_WaitForAgentIP_FullMethodName   = "/telepresence.daemon.Daemon/WaitForAgentIP"
+	Daemon_Version_FullMethodName           = "/telepresence.daemon.Daemon/Version"
+	Daemon_Status_FullMethodName            = "/telepresence.daemon.Daemon/Status"
+	Daemon_Quit_FullMethodName              = "/telepresence.daemon.Daemon/Quit"
+	Daemon_Connect_FullMethodName           = "/telepresence.daemon.Daemon/Connect"
+	Daemon_Disconnect_FullMethodName        = "/telepresence.daemon.Daemon/Disconnect"
+	Daemon_GetNetworkConfig_FullMethodName  = "/telepresence.daemon.Daemon/GetNetworkConfig"
+	Daemon_SetDnsSearchPath_FullMethodName  = "/telepresence.daemon.Daemon/SetDnsSearchPath"
+	Daemon_SetDNSExcludes_FullMethodName    = "/telepresence.daemon.Daemon/SetDNSExcludes"
+	Daemon_SetDNSMappings_FullMethodName    = "/telepresence.daemon.Daemon/SetDNSMappings"
+	Daemon_SetLogLevel_FullMethodName       = "/telepresence.daemon.Daemon/SetLogLevel"
+	Daemon_WaitForNetwork_FullMethodName    = "/telepresence.daemon.Daemon/WaitForNetwork"
+	Daemon_WaitForAgentIP_FullMethodName    = "/telepresence.daemon.Daemon/WaitForAgentIP"
+	Daemon_GetConnections_FullMethodName    = "/telepresence.daemon.Daemon/GetConnections"
+	Daemon_SetTrafficShaping_FullMethodName = "/telepresence.daemon.Daemon/SetTrafficShaping"
+	Daemon_GetTrafficShaping_FullMethodName = "/telepresence.daemon.Daemon/GetTrafficShaping"
 )
 
 // DaemonClient is the client API for Daemon service.
@@ -64,6 +67,12 @@ type DaemonClient interface {
 	WaitForNetwork(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
 	WaitForAgentIP(ctx context.Context, in *WaitForAgentIPRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetConnections returns the connections that are currently routed to the cluster.
+	GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Connections, error)
+	// SetTrafficShaping replaces the rules used when shaping the traffic that is routed to the cluster.
+	SetTrafficShaping(ctx context.Context, in *TrafficShapingRules, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetTrafficShaping returns the rules used when shaping the traffic that is routed to the cluster.
+	GetTrafficShaping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TrafficShapingRules, error)
 }
 
 type daemonClient struct {
@@ -182,6 +191,33 @@ func (c *daemonClient) WaitForAgentIP(ctx context.Context, in *WaitForAgentIPReq
 	return out, nil
 }
 
+func (c *daemonClient) GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Connections, error) {
+	out := new(Connections)
+	err := c.cc.Invoke(ctx, Daemon_GetConnections_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) SetTrafficShaping(ctx context.Context, in *TrafficShapingRules, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Daemon_SetTrafficShaping_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) GetTrafficShaping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TrafficShapingRules, error) {
+	out := new(TrafficShapingRules)
+	err := c.cc.Invoke(ctx, Daemon_GetTrafficShaping_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
@@ -210,6 +246,12 @@ type DaemonServer interface {
 	WaitForNetwork(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
 	WaitForAgentIP(context.Context, *WaitForAgentIPRequest) (*emptypb.Empty, error)
+	// GetConnections returns the connections that are currently routed to the cluster.
+	GetConnections(context.Context, *emptypb.Empty) (*Connections, error)
+	// SetTrafficShaping replaces the rules used when shaping the traffic that is routed to the cluster.
+	SetTrafficShaping(context.Context, *TrafficShapingRules) (*emptypb.Empty, error)
+	// GetTrafficShaping returns the rules used when shaping the traffic that is routed to the cluster.
+	GetTrafficShaping(context.Context, *emptypb.Empty) (*TrafficShapingRules, error)
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -253,6 +295,15 @@ func (UnimplementedDaemonServer) WaitForNetwork(context.Context, *emptypb.Empty)
 func (UnimplementedDaemonServer) WaitForAgentIP(context.Context, *WaitForAgentIPRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WaitForAgentIP not implemented")
 }
+func (UnimplementedDaemonServer) GetConnections(context.Context, *emptypb.Empty) (*Connections, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnections not implemented")
+}
+func (UnimplementedDaemonServer) SetTrafficShaping(context.Context, *TrafficShapingRules) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTrafficShaping not implemented")
+}
+func (UnimplementedDaemonServer) GetTrafficShaping(context.Context, *emptypb.Empty) (*TrafficShapingRules, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrafficShaping not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -482,6 +533,60 @@ func _Daemon_WaitForAgentIP_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_GetConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_GetConnections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetConnections(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_SetTrafficShaping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrafficShapingRules)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).SetTrafficShaping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_SetTrafficShaping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).SetTrafficShaping(ctx, req.(*TrafficShapingRules))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_GetTrafficShaping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetTrafficShaping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_GetTrafficShaping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetTrafficShaping(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Daemon_ServiceDesc is the grpc.ServiceDesc for Daemon service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -537,6 +642,18 @@ var Daemon_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "WaitForAgentIP",
 			Handler:    _Daemon_WaitForAgentIP_Handler,
 		},
+		{
+			MethodName: "GetConnections",
+			Handler:    _Daemon_GetConnections_Handler,
+		},
+		{
+			MethodName: "SetTrafficShaping",
+			Handler:    _Daemon_SetTrafficShaping_Handler,
+		},
+		{
+			MethodName: "GetTrafficShaping",
+			Handler:    _Daemon_GetTrafficShaping_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "daemon/daemon.proto",
@@ -45,6 +45,7 @@ const (
 	Manager_CreateIntercept_FullMethodName           = "/telepresence.manager.Manager/CreateIntercept"
 	Manager_RemoveIntercept_FullMethodName           = "/telepresence.manager.Manager/RemoveIntercept"
 	Manager_UpdateIntercept_FullMethodName           = "/telepresence.manager.Manager/UpdateIntercept"
+	Manager_AcceptInterceptHandover_FullMethodName   = "/telepresence.manager.Manager/AcceptInterceptHandover"
 	Manager_GetIntercept_FullMethodName              = "/telepresence.manager.Manager/GetIntercept"
 	Manager_ReviewIntercept_FullMethodName           = "/telepresence.manager.Manager/ReviewIntercept"
 	Manager_LookupDNS_FullMethodName                 = "/telepresence.manager.Manager/LookupDNS"
@@ -54,6 +55,11 @@ const (
 	Manager_Tunnel_FullMethodName                    = "/telepresence.manager.Manager/Tunnel"
 	Manager_ReportMetrics_FullMethodName             = "/telepresence.manager.Manager/ReportMetrics"
 	Manager_WatchDial_FullMethodName                 = "/telepresence.manager.Manager/WatchDial"
+	Manager_GetAdminSessions_FullMethodName          = "/telepresence.manager.Manager/GetAdminSessions"
+	Manager_GetAdminIntercepts_FullMethodName        = "/telepresence.manager.Manager/GetAdminIntercepts"
+	Manager_GetAdminAgents_FullMethodName            = "/telepresence.manager.Manager/GetAdminAgents"
+	Manager_AdminRemoveIntercept_FullMethodName      = "/telepresence.manager.Manager/AdminRemoveIntercept"
+	Manager_AdminRemoveSession_FullMethodName        = "/telepresence.manager.Manager/AdminRemoveSession"
 )
 
 // ManagerClient is the client API for Manager service.
@@ -124,6 +130,10 @@ type ManagerClient interface {
 	// RemoveIntercept lets a client remove an intercept.
 	RemoveIntercept(ctx context.Context, in *RemoveInterceptRequest2, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	UpdateIntercept(ctx context.Context, in *UpdateInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
+	// AcceptInterceptHandover lets a client take over an intercept that another
+	// client has offered to it. The intercept retains its ID, disposition, and
+	// agent, so the traffic continues to flow while it's rebound.
+	AcceptInterceptHandover(ctx context.Context, in *AcceptInterceptHandoverRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
 	// GetIntercept gets info from intercept name
 	GetIntercept(ctx context.Context, in *GetInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
 	// ReviewIntercept lets an agent approve or reject an intercept by
@@ -158,6 +168,17 @@ type ManagerClient interface {
 	// connection and responds with a Tunnel. The manager then connects the
 	// two tunnels.
 	WatchDial(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchDialClient, error)
+	// GetAdminSessions returns all client sessions with their consumption metrics.
+	GetAdminSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AdminSessionsResponse, error)
+	// GetAdminIntercepts returns all intercepts in all namespaces.
+	GetAdminIntercepts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InterceptInfoSnapshot, error)
+	// GetAdminAgents returns all traffic-agents in all namespaces.
+	GetAdminAgents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AgentInfoSnapshot, error)
+	// AdminRemoveIntercept removes an intercept regardless of what client owns it.
+	AdminRemoveIntercept(ctx context.Context, in *AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// AdminRemoveSession removes a client session and all its intercepts. A
+	// client that is still running will arrive again with a new session.
+	AdminRemoveSession(ctx context.Context, in *AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type managerClient struct {
@@ -472,6 +493,15 @@ func (c *managerClient) UpdateIntercept(ctx context.Context, in *UpdateIntercept
 	return out, nil
 }
 
+func (c *managerClient) AcceptInterceptHandover(ctx context.Context, in *AcceptInterceptHandoverRequest, opts ...grpc.CallOption) (*InterceptInfo, error) {
+	out := new(InterceptInfo)
+	err := c.cc.Invoke(ctx, Manager_AcceptInterceptHandover_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) GetIntercept(ctx context.Context, in *GetInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error) {
 	out := new(InterceptInfo)
 	err := c.cc.Invoke(ctx, Manager_GetIntercept_FullMethodName, in, out, opts...)
@@ -644,6 +674,51 @@ func (x *managerWatchDialClient) Recv() (*DialRequest, error) {
 	return m, nil
 }
 
+func (c *managerClient) GetAdminSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AdminSessionsResponse, error) {
+	out := new(AdminSessionsResponse)
+	err := c.cc.Invoke(ctx, Manager_GetAdminSessions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) GetAdminIntercepts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InterceptInfoSnapshot, error) {
+	out := new(InterceptInfoSnapshot)
+	err := c.cc.Invoke(ctx, Manager_GetAdminIntercepts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) GetAdminAgents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AgentInfoSnapshot, error) {
+	out := new(AgentInfoSnapshot)
+	err := c.cc.Invoke(ctx, Manager_GetAdminAgents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) AdminRemoveIntercept(ctx context.Context, in *AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Manager_AdminRemoveIntercept_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) AdminRemoveSession(ctx context.Context, in *AdminRemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Manager_AdminRemoveSession_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagerServer is the server API for Manager service.
 // All implementations must embed UnimplementedManagerServer
 // for forward compatibility
@@ -712,6 +787,10 @@ type ManagerServer interface {
 	// RemoveIntercept lets a client remove an intercept.
 	RemoveIntercept(context.Context, *RemoveInterceptRequest2) (*emptypb.Empty, error)
 	UpdateIntercept(context.Context, *UpdateInterceptRequest) (*InterceptInfo, error)
+	// AcceptInterceptHandover lets a client take over an intercept that another
+	// client has offered to it. The intercept retains its ID, disposition, and
+	// agent, so the traffic continues to flow while it's rebound.
+	AcceptInterceptHandover(context.Context, *AcceptInterceptHandoverRequest) (*InterceptInfo, error)
 	// GetIntercept gets info from intercept name
 	GetIntercept(context.Context, *GetInterceptRequest) (*InterceptInfo, error)
 	// ReviewIntercept lets an agent approve or reject an intercept by
@@ -746,6 +825,17 @@ type ManagerServer interface {
 	// connection and responds with a Tunnel. The manager then connects the
 	// two tunnels.
 	WatchDial(*SessionInfo, Manager_WatchDialServer) error
+	// GetAdminSessions returns all client sessions with their consumption metrics.
+	GetAdminSessions(context.Context, *emptypb.Empty) (*AdminSessionsResponse, error)
+	// GetAdminIntercepts returns all intercepts in all namespaces.
+	GetAdminIntercepts(context.Context, *emptypb.Empty) (*InterceptInfoSnapshot, error)
+	// GetAdminAgents returns all traffic-agents in all namespaces.
+	GetAdminAgents(context.Context, *emptypb.Empty) (*AgentInfoSnapshot, error)
+	// AdminRemoveIntercept removes an intercept regardless of what client owns it.
+	AdminRemoveIntercept(context.Context, *AdminRemoveRequest) (*emptypb.Empty, error)
+	// AdminRemoveSession removes a client session and all its intercepts. A
+	// client that is still running will arrive again with a new session.
+	AdminRemoveSession(context.Context, *AdminRemoveRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedManagerServer()
 }
 
@@ -816,6 +906,9 @@ func (UnimplementedManagerServer) RemoveIntercept(context.Context, *RemoveInterc
 func (UnimplementedManagerServer) UpdateIntercept(context.Context, *UpdateInterceptRequest) (*InterceptInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateIntercept not implemented")
 }
+func (UnimplementedManagerServer) AcceptInterceptHandover(context.Context, *AcceptInterceptHandoverRequest) (*InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptInterceptHandover not implemented")
+}
 func (UnimplementedManagerServer) GetIntercept(context.Context, *GetInterceptRequest) (*InterceptInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetIntercept not implemented")
 }
@@ -843,6 +936,21 @@ func (UnimplementedManagerServer) ReportMetrics(context.Context, *TunnelMetrics)
 func (UnimplementedManagerServer) WatchDial(*SessionInfo, Manager_WatchDialServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchDial not implemented")
 }
+func (UnimplementedManagerServer) GetAdminSessions(context.Context, *emptypb.Empty) (*AdminSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAdminSessions not implemented")
+}
+func (UnimplementedManagerServer) GetAdminIntercepts(context.Context, *emptypb.Empty) (*InterceptInfoSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAdminIntercepts not implemented")
+}
+func (UnimplementedManagerServer) GetAdminAgents(context.Context, *emptypb.Empty) (*AgentInfoSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAdminAgents not implemented")
+}
+func (UnimplementedManagerServer) AdminRemoveIntercept(context.Context, *AdminRemoveRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminRemoveIntercept not implemented")
+}
+func (UnimplementedManagerServer) AdminRemoveSession(context.Context, *AdminRemoveRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminRemoveSession not implemented")
+}
 func (UnimplementedManagerServer) mustEmbedUnimplementedManagerServer() {}
 
 // UnsafeManagerServer may be embedded to opt out of forward compatibility for this service.
@@ -1249,6 +1357,24 @@ func _Manager_UpdateIntercept_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_AcceptInterceptHandover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptInterceptHandoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).AcceptInterceptHandover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_AcceptInterceptHandover_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).AcceptInterceptHandover(ctx, req.(*AcceptInterceptHandoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_GetIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetInterceptRequest)
 	if err := dec(in); err != nil {
@@ -1428,6 +1554,96 @@ func (x *managerWatchDialServer) Send(m *DialRequest) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_GetAdminSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetAdminSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetAdminSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetAdminSessions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_GetAdminIntercepts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetAdminIntercepts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetAdminIntercepts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetAdminIntercepts(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_GetAdminAgents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetAdminAgents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetAdminAgents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetAdminAgents(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_AdminRemoveIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminRemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).AdminRemoveIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_AdminRemoveIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).AdminRemoveIntercept(ctx, req.(*AdminRemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_AdminRemoveSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminRemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).AdminRemoveSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_AdminRemoveSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).AdminRemoveSession(ctx, req.(*AdminRemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Manager_ServiceDesc is the grpc.ServiceDesc for Manager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1499,6 +1715,10 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateIntercept",
 			Handler:    _Manager_UpdateIntercept_Handler,
 		},
+		{
+			MethodName: "AcceptInterceptHandover",
+			Handler:    _Manager_AcceptInterceptHandover_Handler,
+		},
 		{
 			MethodName: "GetIntercept",
 			Handler:    _Manager_GetIntercept_Handler,
@@ -1519,6 +1739,26 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ReportMetrics",
 			Handler:    _Manager_ReportMetrics_Handler,
 		},
+		{
+			MethodName: "GetAdminSessions",
+			Handler:    _Manager_GetAdminSessions_Handler,
+		},
+		{
+			MethodName: "GetAdminIntercepts",
+			Handler:    _Manager_GetAdminIntercepts_Handler,
+		},
+		{
+			MethodName: "GetAdminAgents",
+			Handler:    _Manager_GetAdminAgents_Handler,
+		},
+		{
+			MethodName: "AdminRemoveIntercept",
+			Handler:    _Manager_AdminRemoveIntercept_Handler,
+		},
+		{
+			MethodName: "AdminRemoveSession",
+			Handler:    _Manager_AdminRemoveSession_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{